@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Скачки короче этого расстояния не проверяются на скорость: это обычный шум GPS
+const anticheatMinJumpMeters = 200
+
+// Сколько событий показывает /suspicious
+const suspicionReportSize = 20
+
+// impliedSpeedKmh возвращает скорость в км/ч, с которой нужно было двигаться, чтобы пройти meters за elapsed
+func impliedSpeedKmh(meters float64, elapsed time.Duration) float64 {
+	if elapsed < time.Second {
+		elapsed = time.Second
+	}
+	return meters / elapsed.Seconds() * 3.6
+}
+
+// checkLocationSpoofing ищет признаки поддельной геопозиции в очередной точке трансляции
+// и записывает подозрительные события. Вызывается только для точек, принятых трекером:
+// отброшенные неточные точки не сохраняются и скачком не считаются. Точка все равно
+// обрабатывается дальше: решение, засчитывать ли находку, принимается при прибытии (см. isSessionSuspicious).
+func (b *Bot) checkLocationSpoofing(session *UserSession, cache *Cache, message *tgbotapi.Message, now time.Time) {
+	location := message.Location
+	event := &SuspicionEvent{
+		UserID:    session.UserID,
+		CacheID:   cache.ID,
+		Latitude:  location.Latitude,
+		Longitude: location.Longitude,
+		CreatedAt: now,
+	}
+
+	// Пересланная геопозиция принадлежит другому человеку; записываем один раз за поиск
+	if message.ForwardDate != 0 {
+		if count, err := b.DB.CountSuspicionEvents(session.UserID, cache.ID, SuspicionForwarded, session.StartedAt); err == nil && count == 0 {
+			event.Kind = SuspicionForwarded
+			b.recordSuspicion(event)
+		}
+	}
+
+	switch {
+	case session.LastMessageID == 0 && session.LastLatitude == 0 && session.LastLongitude == 0:
+		// Первая точка поиска: честный игрок обычно начинает далеко от тайника.
+		// Пока точка не сохранена, условие выполняется снова - записываем один раз за поиск.
+		distance := calculateDistance(location.Latitude, location.Longitude, cache.Latitude, cache.Longitude) * 1000
+		if distance > cache.TargetRadius(b.Config.TargetDistanceMeters) {
+			break
+		}
+		if count, err := b.DB.CountSuspicionEvents(session.UserID, cache.ID, SuspicionStartInside, session.StartedAt); err == nil && count == 0 {
+			event.Kind = SuspicionStartInside
+			event.DistanceMeters = distance
+			b.recordSuspicion(event)
+		}
+	case session.LastMessageID != 0 && !session.LastFixAt.IsZero() && b.Config.MaxPlausibleSpeedKmh > 0:
+		// Скорость от последней сохраненной точки. После /resume сообщение отправляется заново
+		// (LastMessageID = 0), поэтому перемещение за время паузы за скачок не считается.
+		distance := calculateDistance(session.LastLatitude, session.LastLongitude, location.Latitude, location.Longitude) * 1000
+		speed := impliedSpeedKmh(distance, now.Sub(session.LastFixAt))
+		if distance >= anticheatMinJumpMeters && speed > b.Config.MaxPlausibleSpeedKmh {
+			event.Kind = SuspicionJump
+			event.DistanceMeters = distance
+			event.SpeedKmh = speed
+			b.recordSuspicion(event)
+		}
+	}
+}
+
+// recordSuspicion сохраняет подозрительное событие
+func (b *Bot) recordSuspicion(event *SuspicionEvent) {
+	suspicionEventsTotal.WithLabelValues(event.Kind).Inc()
+	slog.Warn("Подозрительная геопозиция", "user_id", event.UserID, "cache_id", event.CacheID, "kind", event.Kind,
+		"distance_meters", int(event.DistanceMeters), "speed_kmh", int(event.SpeedKmh))
+
+	if err := b.DB.CreateSuspicionEvent(event); err != nil {
+		slog.Error("Ошибка записи подозрительного события", "user_id", event.UserID, "cache_id", event.CacheID, "error", err)
+	}
+}
+
+// isSessionSuspicious сообщает, были ли подозрительные события за текущий поиск
+func (b *Bot) isSessionSuspicious(session *UserSession) bool {
+	count, err := b.DB.CountSuspicionEvents(session.UserID, session.CacheID, "", session.StartedAt)
+	if err != nil {
+		slog.Error("Ошибка подсчета подозрительных событий", "user_id", session.UserID, "cache_id", session.CacheID, "error", err)
+		return false
+	}
+	return count > 0
+}
+
+// refuseFind завершает поиск без находки, если BLOCK_SUSPICIOUS_FINDS включен и поиск выглядит подделанным
+func (b *Bot) refuseFind(userID int64, cache *Cache) {
+	slog.Warn("Находка не засчитана из-за подозрительной геопозиции", "user_id", userID, "cache_id", cache.ID)
+
+	b.endUserSession(userID)
+	b.reply(userID, "anticheat.find_refused")
+}
+
+// describeSuspicion возвращает описание события для отчета /suspicious
+func describeSuspicion(lang string, event *SuspicionEvent) string {
+	switch event.Kind {
+	case SuspicionJump:
+		return translate(lang, "suspicious.jump", formatDistance(lang, UnitsMetric, event.DistanceMeters), int(event.SpeedKmh))
+	case SuspicionStartInside:
+		return translate(lang, "suspicious.start_inside", formatDistance(lang, UnitsMetric, event.DistanceMeters))
+	case SuspicionForwarded:
+		return translate(lang, "suspicious.forwarded")
+	default:
+		return event.Kind
+	}
+}
+
+// Обработчик команды /suspicious [ID игрока]
+func (b *Bot) handleSuspiciousCommand(userID int64, args string) {
+	lang := b.userLanguage(userID)
+
+	var playerID int64
+	if args = strings.TrimSpace(args); args != "" {
+		id, err := strconv.ParseInt(args, 10, 64)
+		if err != nil {
+			b.sendMessage(userID, translate(lang, "suspicious.invalid_user"))
+			return
+		}
+		playerID = id
+	}
+
+	events, err := b.DB.GetSuspicionEvents(playerID, suspicionReportSize)
+	if err != nil {
+		slog.Error("Ошибка получения подозрительных событий", "user_id", userID, "error", err)
+		b.sendMessage(userID, translate(lang, "error.generic"))
+		return
+	}
+	if len(events) == 0 {
+		b.sendMessage(userID, translate(lang, "suspicious.empty"))
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(translate(lang, "suspicious.header", len(events)))
+	for i, event := range events {
+		codeWord := event.CodeWord
+		if codeWord == "" {
+			codeWord = translate(lang, "history.cache_deleted")
+		}
+		fmt.Fprintf(&text, "%d. 👤 %s (%d) - 🔑 %s\n   %s\n   📅 %s - 📍 %.6f, %.6f\n",
+			i+1, displayName(lang, event.User), event.UserID, codeWord, describeSuspicion(lang, event),
+			event.CreatedAt.Local().Format(translate(lang, "format.datetime")), event.Latitude, event.Longitude)
+	}
+	if playerID == 0 {
+		text.WriteString(translate(lang, "suspicious.user_hint"))
+	}
+
+	b.sendMessage(userID, text.String())
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestImpliedSpeedKmh(t *testing.T) {
+	if speed := impliedSpeedKmh(1000, time.Minute); math.Round(speed) != 60 {
+		t.Errorf("1 км за минуту = %v км/ч, ожидалось 60", speed)
+	}
+	// Интервал меньше секунды округляется до секунды, чтобы не делить на ноль
+	if speed := impliedSpeedKmh(10, 0); math.Round(speed) != 36 {
+		t.Errorf("10 м без интервала = %v км/ч, ожидалось 36", speed)
+	}
+}
+
+func TestCheckLocationSpoofing(t *testing.T) {
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "anticheat.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	b := &Bot{DB: db, Config: &Config{TargetDistanceMeters: 30, MaxPlausibleSpeedKmh: 150}}
+	cache := &Cache{ID: 1, Latitude: 55.7558, Longitude: 37.6173}
+	started := time.Now().Add(-time.Hour)
+	session := &UserSession{UserID: 7, CacheID: cache.ID, StartedAt: started,
+		LastMessageID: 100, LastLatitude: 55.7658, LastLongitude: 37.6173, LastUpdate: started, LastFixAt: started}
+	location := func(lat, lon float64) *tgbotapi.Message {
+		return &tgbotapi.Message{Location: &tgbotapi.Location{Latitude: lat, Longitude: lon}}
+	}
+
+	// Прогулка: 500 м за 10 минут
+	b.checkLocationSpoofing(session, cache, location(55.7613, 37.6173), started.Add(10*time.Minute))
+	if b.isSessionSuspicious(session) {
+		t.Fatal("пешая прогулка помечена как подозрительная")
+	}
+
+	// Скачок на километр за 5 секунд
+	b.checkLocationSpoofing(session, cache, location(55.7558, 37.6173), started.Add(5*time.Second))
+	events, err := db.GetSuspicionEvents(session.UserID, 10)
+	if err != nil || len(events) != 1 || events[0].Kind != SuspicionJump {
+		t.Fatalf("скачок не записан: %+v (ошибка %v)", events, err)
+	}
+	if events[0].SpeedKmh < 150 || events[0].DistanceMeters < 1000 {
+		t.Errorf("неверные скорость и расстояние скачка: %+v", events[0])
+	}
+
+	// Скорость считается от сохраненной точки, а не от последнего обновления сессии
+	session.LastUpdate = started.Add(10*time.Minute - time.Second)
+	b.checkLocationSpoofing(session, cache, location(55.7613, 37.6173), started.Add(10*time.Minute))
+	if events, _ := db.GetSuspicionEvents(session.UserID, 10); len(events) != 1 {
+		t.Fatalf("скорость посчитана от last_update: %+v", events)
+	}
+
+	// Начало поиска у тайника записывается один раз, пока первая точка не сохранена
+	first := &UserSession{UserID: 8, CacheID: cache.ID, StartedAt: started}
+	for i := 0; i < 3; i++ {
+		b.checkLocationSpoofing(first, cache, location(55.75582, 37.61732), started.Add(time.Duration(i)*time.Second))
+	}
+	if count, err := db.CountSuspicionEvents(first.UserID, cache.ID, SuspicionStartInside, started); err != nil || count != 1 {
+		t.Errorf("начал у тайника %d (ошибка %v), ожидался 1", count, err)
+	}
+
+	// Пересланная геопозиция записывается один раз за поиск
+	forwarded := location(55.7600, 37.6173)
+	forwarded.ForwardDate = int(started.Unix())
+	for i := 0; i < 2; i++ {
+		b.checkLocationSpoofing(session, cache, forwarded, started.Add(time.Hour))
+	}
+	if count, err := db.CountSuspicionEvents(session.UserID, cache.ID, SuspicionForwarded, started); err != nil || count != 1 {
+		t.Errorf("пересланных геопозиций %d (ошибка %v), ожидалась 1", count, err)
+	}
+}
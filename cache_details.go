@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Дополнительные параметры тайника в порядке, в котором их спрашивают при создании
+var cacheDetailFields = []string{"radius", "difficulty", "terrain", "navigation", "public", "description"}
+
+// Режимы навигации в порядке кнопок выбора
+var navigationModes = []string{NavigationCompass, NavigationDistance, NavigationHotCold}
+
+// Допустимые границы радиуса обнаружения в метрах
+const (
+	minCacheRadius = 5
+	maxCacheRadius = 1000
+)
+
+// isCacheDetailStep сообщает, относится ли шаг к необязательным параметрам нового тайника
+func isCacheDetailStep(step string) bool {
+	for _, field := range cacheDetailFields {
+		if step == "waiting_"+field {
+			return true
+		}
+	}
+	return false
+}
+
+// isNavigationMode проверяет, что режим навигации поддерживается
+func isNavigationMode(mode string) bool {
+	for _, m := range navigationModes {
+		if mode == m {
+			return true
+		}
+	}
+	return false
+}
+
+// ratingStars отображает оценку 1-5 звездочками
+func ratingStars(lang string, rating int) string {
+	if rating <= 0 {
+		return translate(lang, "details.not_set")
+	}
+	return strings.Repeat("★", rating) + strings.Repeat("☆", 5-rating)
+}
+
+// formatCacheDetails возвращает строки с радиусом, сложностью и описанием тайника
+func formatCacheDetails(lang string, cache *Cache, defaultRadius float64) string {
+	var text strings.Builder
+
+	text.WriteString(translate(lang, "details.summary", translatePlural(lang, "unit.meters", int(cache.TargetRadius(defaultRadius))),
+		ratingStars(lang, cache.Difficulty), ratingStars(lang, cache.Terrain)))
+	text.WriteString(translate(lang, "details.navigation", translate(lang, "navigation.mode_"+cache.Navigation())))
+	if cache.IsPublic {
+		text.WriteString(translate(lang, "details.public"))
+	}
+	if cache.Description != "" {
+		fmt.Fprintf(&text, "\n\n📝 %s", cache.Description)
+	}
+
+	return text.String()
+}
+
+// askCacheDetail запрашивает у администратора параметр тайника.
+// stepPrefix - "waiting_" при создании тайника или "edit_" при редактировании.
+func (b *Bot) askCacheDetail(userID int64, codeWord, stepPrefix, field string) {
+	lang := b.userLanguage(userID)
+	session := &AdminSession{
+		UserID:   userID,
+		Step:     stepPrefix + field,
+		CodeWord: codeWord,
+	}
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка обновления админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.sendMessage(userID, translate(lang, "error.generic"))
+		return
+	}
+
+	skipHint := translate(lang, "details.skip_hint")
+	if stepPrefix == "edit_" {
+		skipHint = translate(lang, "details.reset_hint")
+	}
+
+	var msg tgbotapi.MessageConfig
+	switch field {
+	case "radius":
+		msg = tgbotapi.NewMessage(userID, translate(lang, "details.ask_radius",
+			minCacheRadius, maxCacheRadius, translatePlural(lang, "unit.meters", int(b.Config.TargetDistanceMeters)))+skipHint)
+	case "difficulty", "terrain":
+		msg = tgbotapi.NewMessage(userID, translate(lang, "details.ask_"+field)+skipHint)
+		keyboard := tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton("1"),
+				tgbotapi.NewKeyboardButton("2"),
+				tgbotapi.NewKeyboardButton("3"),
+				tgbotapi.NewKeyboardButton("4"),
+				tgbotapi.NewKeyboardButton("5"),
+				tgbotapi.NewKeyboardButton("-"),
+			),
+		)
+		keyboard.OneTimeKeyboard = true
+		msg.ReplyMarkup = keyboard
+	case "navigation":
+		msg = tgbotapi.NewMessage(userID, translate(lang, "details.ask_navigation")+skipHint)
+		var rows [][]tgbotapi.KeyboardButton
+		for _, mode := range navigationModes {
+			rows = append(rows, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(translate(lang, "navigation.mode_"+mode))))
+		}
+		rows = append(rows, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton("-")))
+		keyboard := tgbotapi.NewReplyKeyboard(rows...)
+		keyboard.OneTimeKeyboard = true
+		msg.ReplyMarkup = keyboard
+	case "public":
+		msg = tgbotapi.NewMessage(userID, translate(lang, "details.ask_public")+skipHint)
+		keyboard := tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButton(translate(lang, "details.public_yes")),
+				tgbotapi.NewKeyboardButton(translate(lang, "details.public_no")),
+			),
+		)
+		keyboard.OneTimeKeyboard = true
+		msg.ReplyMarkup = keyboard
+	case "description":
+		msg = tgbotapi.NewMessage(userID, translate(lang, "details.ask_description")+skipHint)
+		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+	default:
+		return
+	}
+
+	b.Outbox.Send(msg)
+}
+
+// applyCacheDetail разбирает введенное значение параметра и записывает его в тайник.
+// Возвращает текст ошибки для администратора на языке lang, если значение некорректно.
+func applyCacheDetail(lang string, cache *Cache, field, text string) string {
+	text = strings.TrimSpace(text)
+	reset := text == "-"
+
+	switch field {
+	case "radius":
+		if reset {
+			cache.RadiusMeters = 0
+			return ""
+		}
+		radius, err := parseNumber(text)
+		if err != nil || radius < minCacheRadius || radius > maxCacheRadius {
+			return translate(lang, "details.invalid_radius", minCacheRadius, maxCacheRadius)
+		}
+		cache.RadiusMeters = radius
+	case "difficulty", "terrain":
+		rating := 0
+		if !reset {
+			var err error
+			rating, err = strconv.Atoi(text)
+			if err != nil || rating < 1 || rating > 5 {
+				return translate(lang, "details.invalid_rating")
+			}
+		}
+		if field == "difficulty" {
+			cache.Difficulty = rating
+		} else {
+			cache.Terrain = rating
+		}
+	case "navigation":
+		if reset {
+			cache.NavigationMode = NavigationCompass
+			return ""
+		}
+		for _, mode := range navigationModes {
+			if isButton(text, "navigation.mode_"+mode) {
+				cache.NavigationMode = mode
+				return ""
+			}
+		}
+		return translate(lang, "details.invalid_navigation")
+	case "public":
+		switch {
+		case reset || isButton(text, "details.public_no"):
+			cache.IsPublic = false
+		case isButton(text, "details.public_yes"):
+			cache.IsPublic = true
+		default:
+			return translate(lang, "details.invalid_public")
+		}
+	case "description":
+		if text == "" {
+			return translate(lang, "details.invalid_description")
+		}
+		if reset {
+			text = ""
+		}
+		cache.Description = text
+	}
+
+	return ""
+}
+
+// Обработчик ввода параметров тайника при создании (шаги waiting_*) и редактировании (шаги edit_*)
+func (b *Bot) handleCacheDetailInput(userID int64, session *AdminSession, text string) {
+	editing := strings.HasPrefix(session.Step, "edit_")
+	field := strings.TrimPrefix(strings.TrimPrefix(session.Step, "edit_"), "waiting_")
+	lang := b.userLanguage(userID)
+
+	cache := b.loadEditedCache(userID, session)
+	if cache == nil {
+		return
+	}
+
+	if errText := applyCacheDetail(lang, cache, field, text); errText != "" {
+		b.sendMessage(userID, errText)
+		return
+	}
+
+	if editing {
+		b.finishCacheEdit(userID, cache, translate(lang, "details.updated",
+			cache.CodeWord, formatCacheDetails(lang, cache, b.Config.TargetDistanceMeters)))
+		return
+	}
+
+	if err := b.DB.UpdateCache(cache); err != nil {
+		slog.Error("Ошибка обновления тайника", "user_id", userID, "cache_id", cache.ID, "error", err)
+		b.sendMessage(userID, translate(lang, "cache.save_failed"))
+		return
+	}
+
+	// Переходим к следующему параметру, а после последнего - к вопросу на месте тайника
+	for i, f := range cacheDetailFields {
+		if f == field && i+1 < len(cacheDetailFields) {
+			b.askCacheDetail(userID, cache.CodeWord, "waiting_", cacheDetailFields[i+1])
+			return
+		}
+	}
+	b.askPuzzleKind(userID, cache)
+}
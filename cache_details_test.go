@@ -0,0 +1,59 @@
+package main
+
+import "testing"
+
+func TestApplyCacheDetail(t *testing.T) {
+	cache := &Cache{}
+
+	tests := []struct {
+		field, text string
+		wantErr     string
+	}{
+		{"radius", "4", "details.invalid_radius"},
+		{"radius", "1001", "details.invalid_radius"},
+		{"radius", "метр", "details.invalid_radius"},
+		{"radius", " 12,5 ", ""},
+		{"difficulty", "0", "details.invalid_rating"},
+		{"difficulty", "3", ""},
+		{"terrain", "6", "details.invalid_rating"},
+		{"terrain", "5", ""},
+		{"navigation", "куда-нибудь", "details.invalid_navigation"},
+		{"navigation", translate(LangEn, "navigation.mode_hotcold"), ""},
+		{"public", "может быть", "details.invalid_public"},
+		{"public", translate(LangRu, "details.public_yes"), ""},
+		{"description", "  ", "details.invalid_description"},
+		{"description", " Под корнями дуба ", ""},
+	}
+
+	for _, tt := range tests {
+		got := applyCacheDetail(LangEn, cache, tt.field, tt.text)
+		want := ""
+		switch tt.wantErr {
+		case "":
+		case "details.invalid_radius":
+			want = translate(LangEn, tt.wantErr, minCacheRadius, maxCacheRadius)
+		default:
+			want = translate(LangEn, tt.wantErr)
+		}
+		if got != want {
+			t.Errorf("applyCacheDetail(%s, %q) = %q, ожидалось %q", tt.field, tt.text, got, want)
+		}
+	}
+
+	// Некорректный ввод не портит уже записанные значения
+	if cache.RadiusMeters != 12.5 || cache.Difficulty != 3 || cache.Terrain != 5 ||
+		cache.NavigationMode != NavigationHotCold || !cache.IsPublic || cache.Description != "Под корнями дуба" {
+		t.Fatalf("параметры тайника: %+v", cache)
+	}
+
+	// "-" сбрасывает параметр к значению по умолчанию
+	for _, field := range cacheDetailFields {
+		if errText := applyCacheDetail(LangRu, cache, field, "-"); errText != "" {
+			t.Errorf("сброс %s: %s", field, errText)
+		}
+	}
+	if cache.RadiusMeters != 0 || cache.Difficulty != 0 || cache.Terrain != 0 ||
+		cache.NavigationMode != NavigationCompass || cache.IsPublic || cache.Description != "" {
+		t.Errorf("параметры после сброса: %+v", cache)
+	}
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Количество тайников на одной странице списка /list
+const cacheListPageSize = 10
+
+// Обработчик нажатий на inline-кнопки
+func (b *Bot) handleCallbackQuery(query *tgbotapi.CallbackQuery) {
+	userID := query.From.ID
+	b.rememberUser(query.From)
+
+	// Убираем индикатор загрузки на кнопке
+	if _, err := b.API.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		slog.Error("Ошибка ответа на callback", "user_id", userID, "error", err)
+	}
+
+	action, arg, _ := strings.Cut(query.Data, ":")
+
+	switch action {
+	case "list", "cache", "edit", "del", "delok", "delno":
+		if !b.isAdmin(userID) {
+			return
+		}
+		b.handleCatalogCallback(query, action, arg)
+	case "export":
+		if !b.isAdmin(userID) {
+			return
+		}
+		if format := parseFormat(arg); format != "" {
+			b.sendExport(userID, format)
+		}
+	case "ans":
+		b.handlePuzzleAnswerCallback(userID, arg)
+	case "hist":
+		b.handleHistoryPageCallback(query, arg)
+	case "find":
+		b.handleFindMediaCallback(userID, arg)
+	case "top":
+		b.handleTopCallback(query, arg)
+	case "lang":
+		b.handleLanguageCallback(query, arg)
+	case "settings":
+		b.handleSettingsCallback(query, arg)
+	case "near":
+		b.handleNearbyCallback(userID, arg)
+	}
+}
+
+// Обработчик кнопок каталога тайников администратора
+func (b *Bot) handleCatalogCallback(query *tgbotapi.CallbackQuery, action, arg string) {
+	userID := query.From.ID
+
+	switch action {
+	case "list":
+		page, _ := strconv.Atoi(arg)
+		if query.Message == nil {
+			return
+		}
+		text, keyboard, err := b.renderCacheListPage(b.userLanguage(userID), page)
+		if err != nil {
+			slog.Error("Ошибка получения списка тайников", "user_id", userID, "error", err)
+			return
+		}
+		edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID, text, keyboard)
+		if _, err := b.Outbox.Send(edit); err != nil {
+			slog.Error("Ошибка обновления списка тайников", "user_id", userID, "error", err)
+		}
+	case "cache":
+		if cache := b.getCacheForCallback(userID, arg); cache != nil {
+			b.sendCacheCard(userID, cache)
+		}
+	case "edit":
+		idStr, field, _ := strings.Cut(arg, ":")
+		if cache := b.getCacheForCallback(userID, idStr); cache != nil {
+			b.startCacheEdit(userID, cache, field)
+		}
+	case "del":
+		if cache := b.getCacheForCallback(userID, arg); cache != nil {
+			b.sendDeleteConfirmation(userID, cache)
+		}
+	case "delok":
+		cache := b.getCacheForCallback(userID, arg)
+		if cache == nil {
+			return
+		}
+		if err := b.DB.DeleteCache(cache.ID); err != nil {
+			slog.Error("Ошибка удаления тайника", "user_id", userID, "cache_id", cache.ID, "error", err)
+			b.reply(userID, "delete.failed")
+			return
+		}
+		b.reply(userID, "delete.done", cache.CodeWord)
+	case "delno":
+		b.reply(userID, "delete.cancelled")
+	}
+}
+
+// getCacheForCallback загружает тайник по ID из данных inline-кнопки
+func (b *Bot) getCacheForCallback(userID int64, idStr string) *Cache {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	cache, err := b.DB.GetCacheByID(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			b.reply(userID, "catalog.cache_not_found")
+		} else {
+			slog.Error("Ошибка получения тайника", "user_id", userID, "error", err)
+		}
+		return nil
+	}
+	if b.refuseTrailStage(userID, cache) {
+		return nil
+	}
+	return cache
+}
+
+// lookupCacheArgument ищет тайник по кодовому слову из аргумента команды
+func (b *Bot) lookupCacheArgument(userID int64, command, codeWord string) *Cache {
+	codeWord = strings.TrimSpace(codeWord)
+	if codeWord == "" {
+		b.reply(userID, "catalog.code_word_required", command)
+		return nil
+	}
+
+	cache, err := b.DB.GetCacheByCodeWord(codeWord)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			b.reply(userID, "catalog.code_word_not_found")
+		} else {
+			slog.Error("Ошибка поиска кэша", "user_id", userID, "error", err)
+			b.reply(userID, "search.failed")
+		}
+		return nil
+	}
+	if b.refuseTrailStage(userID, cache) {
+		return nil
+	}
+	return cache
+}
+
+// refuseTrailStage не дает управлять этапом маршрута как отдельным тайником: его удаление
+// оставило бы в маршруте пропуск, а игроков на маршруте направило бы к несуществующему тайнику
+func (b *Bot) refuseTrailStage(userID int64, cache *Cache) bool {
+	_, err := b.DB.GetTrailStageByCacheID(cache.ID)
+	if err == sql.ErrNoRows {
+		return false
+	}
+	if err != nil {
+		slog.Error("Ошибка получения этапа маршрута", "user_id", userID, "cache_id", cache.ID, "error", err)
+		b.reply(userID, "error.generic")
+		return true
+	}
+	b.reply(userID, "catalog.trail_stage", cache.CodeWord)
+	return true
+}
+
+// Обработчик команды /list
+func (b *Bot) handleListCommand(userID int64) {
+	text, keyboard, err := b.renderCacheListPage(b.userLanguage(userID), 0)
+	if err != nil {
+		slog.Error("Ошибка получения списка тайников", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(userID, text)
+	if len(keyboard.InlineKeyboard) > 0 {
+		msg.ReplyMarkup = keyboard
+	}
+	b.Outbox.Send(msg)
+}
+
+// renderCacheListPage формирует текст и клавиатуру страницы списка тайников
+func (b *Bot) renderCacheListPage(lang string, page int) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	var keyboard tgbotapi.InlineKeyboardMarkup
+
+	total, err := b.DB.CountCaches()
+	if err != nil {
+		return "", keyboard, err
+	}
+	if total == 0 {
+		return translate(lang, "list.empty"), keyboard, nil
+	}
+
+	pages := (total + cacheListPageSize - 1) / cacheListPageSize
+	if page < 0 {
+		page = 0
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+
+	caches, err := b.DB.ListCaches(cacheListPageSize, page*cacheListPageSize)
+	if err != nil {
+		return "", keyboard, err
+	}
+
+	var text strings.Builder
+	text.WriteString(translate(lang, "list.header", total, page+1, pages))
+	for i, cache := range caches {
+		fmt.Fprintf(&text, "%d. 🔑 %s - 📍 %.5f, %.5f - 🏆 %d\n",
+			page*cacheListPageSize+i+1, cache.CodeWord, cache.Latitude, cache.Longitude, cache.FindCount)
+
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔑 "+cache.CodeWord, fmt.Sprintf("cache:%d", cache.ID)),
+		))
+	}
+	text.WriteString(translate(lang, "list.hint"))
+
+	if pages > 1 {
+		var navigation []tgbotapi.InlineKeyboardButton
+		if page > 0 {
+			navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData(translate(lang, "button.prev"), fmt.Sprintf("list:%d", page-1)))
+		}
+		if page < pages-1 {
+			navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData(translate(lang, "button.next"), fmt.Sprintf("list:%d", page+1)))
+		}
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, navigation)
+	}
+
+	return text.String(), keyboard, nil
+}
+
+// Обработчик команды /cache <код>
+func (b *Bot) handleCacheCardCommand(userID int64, args string) {
+	if cache := b.lookupCacheArgument(userID, "cache", args); cache != nil {
+		b.sendCacheCard(userID, cache)
+	}
+}
+
+// sendCacheCard отправляет превью медиафайла и карточку тайника с кнопками управления
+func (b *Bot) sendCacheCard(userID int64, cache *Cache) {
+	lang := b.userLanguage(userID)
+
+	if err := b.sendMedia(userID, cache.FileID, cache.FileType, ""); err != nil {
+		slog.Error("Ошибка отправки превью тайника", "user_id", userID, "cache_id", cache.ID, "error", err)
+		b.sendMessage(userID, translate(lang, "card.media_failed"))
+	}
+
+	card := translate(lang, "card.message",
+		cache.ID, cache.CodeWord, cache.Latitude, cache.Longitude, mediaTypeName(lang, cache.FileID, cache.FileType),
+		cache.CreatedBy, cache.CreatedAt.Format(translate(lang, "format.datetime")), cache.FindCount,
+		formatCacheDetails(lang, cache, b.Config.TargetDistanceMeters))
+
+	msg := tgbotapi.NewMessage(userID, card)
+	msg.ReplyMarkup = cacheEditKeyboard(lang, cache.ID, true)
+	b.Outbox.Send(msg)
+}
+
+// cacheEditKeyboard возвращает кнопки редактирования (и при необходимости удаления) тайника
+func cacheEditKeyboard(lang string, cacheID int64, withDelete bool) tgbotapi.InlineKeyboardMarkup {
+	button := func(field string) tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardButtonData(translate(lang, "edit.button_"+field), fmt.Sprintf("edit:%d:%s", cacheID, field))
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(button("code"), button("location")),
+		tgbotapi.NewInlineKeyboardRow(button("media"), button("puzzle")),
+		tgbotapi.NewInlineKeyboardRow(button("radius"), button("description")),
+		tgbotapi.NewInlineKeyboardRow(button("difficulty"), button("terrain")),
+		tgbotapi.NewInlineKeyboardRow(button("navigation"), button("public")),
+	}
+	if withDelete {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(translate(lang, "edit.button_delete"), fmt.Sprintf("del:%d", cacheID)),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// mediaTypeName возвращает человекочитаемое название типа медиафайла
+func mediaTypeName(lang, fileID, fileType string) string {
+	if fileID == "" {
+		return translate(lang, "media.none")
+	}
+
+	switch fileType {
+	case "video", "video_note":
+		return translate(lang, "media."+fileType)
+	default:
+		return translate(lang, "media.photo")
+	}
+}
+
+// mediaTypeAccusative возвращает название типа медиафайла в винительном падеже для ответов пользователю
+func mediaTypeAccusative(lang, fileType string) string {
+	switch fileType {
+	case "video", "video_note":
+		return translate(lang, "media.accusative_"+fileType)
+	default:
+		return translate(lang, "media.accusative_photo")
+	}
+}
+
+// sendMedia отправляет медиафайл по file_id с учетом его типа.
+// Если медиафайл не прикреплен, отправляется только подпись.
+func (b *Bot) sendMedia(chatID int64, fileID, fileType, caption string) error {
+	if fileID == "" {
+		if caption != "" {
+			b.sendMessage(chatID, caption)
+		}
+		return nil
+	}
+
+	var media tgbotapi.Chattable
+
+	switch fileType {
+	case "video_note":
+		// Видео-заметки не поддерживают подписи
+		media = tgbotapi.NewVideoNote(chatID, 0, tgbotapi.FileID(fileID))
+	case "video":
+		video := tgbotapi.NewVideo(chatID, tgbotapi.FileID(fileID))
+		video.Caption = caption
+		media = video
+	default:
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileID(fileID))
+		photo.Caption = caption
+		media = photo
+	}
+
+	_, err := b.Outbox.Send(media)
+	return err
+}
+
+// Обработчик команды /edit <код>
+func (b *Bot) handleEditCommand(userID int64, args string) {
+	cache := b.lookupCacheArgument(userID, "edit", args)
+	if cache == nil {
+		return
+	}
+
+	lang := b.userLanguage(userID)
+	msg := tgbotapi.NewMessage(userID, translate(lang, "edit.prompt", cache.CodeWord))
+	msg.ReplyMarkup = cacheEditKeyboard(lang, cache.ID, false)
+	b.Outbox.Send(msg)
+}
+
+// Обработчик команды /delete <код>
+func (b *Bot) handleDeleteCommand(userID int64, args string) {
+	if cache := b.lookupCacheArgument(userID, "delete", args); cache != nil {
+		b.sendDeleteConfirmation(userID, cache)
+	}
+}
+
+// sendDeleteConfirmation запрашивает подтверждение удаления тайника
+func (b *Bot) sendDeleteConfirmation(userID int64, cache *Cache) {
+	lang := b.userLanguage(userID)
+
+	msg := tgbotapi.NewMessage(userID, translate(lang, "delete.confirm", cache.CodeWord))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(translate(lang, "delete.button_yes"), fmt.Sprintf("delok:%d", cache.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(translate(lang, "delete.button_no"), fmt.Sprintf("delno:%d", cache.ID)),
+		),
+	)
+	b.Outbox.Send(msg)
+}
+
+// startCacheEdit переводит администратора в шаг редактирования выбранного поля
+func (b *Bot) startCacheEdit(userID int64, cache *Cache, field string) {
+	session := &AdminSession{
+		UserID:   userID,
+		CodeWord: cache.CodeWord,
+	}
+
+	if field == "puzzle" {
+		b.askPuzzleKind(userID, cache)
+		return
+	}
+	for _, detail := range cacheDetailFields {
+		if field == detail {
+			b.askCacheDetail(userID, cache.CodeWord, "edit_", field)
+			return
+		}
+	}
+
+	lang := b.userLanguage(userID)
+
+	var msg tgbotapi.MessageConfig
+	switch field {
+	case "code":
+		session.Step = "edit_code"
+		msg = tgbotapi.NewMessage(userID, translate(lang, "edit.ask_code", cache.CodeWord))
+	case "location":
+		session.Step = "edit_location"
+		msg = tgbotapi.NewMessage(userID, translate(lang, "edit.ask_location"))
+		keyboard := tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(
+				tgbotapi.NewKeyboardButtonLocation(translate(lang, "button.send_location")),
+			),
+		)
+		keyboard.OneTimeKeyboard = true
+		msg.ReplyMarkup = keyboard
+	case "media":
+		session.Step = "edit_media"
+		msg = tgbotapi.NewMessage(userID, translate(lang, "edit.ask_media"))
+	default:
+		return
+	}
+
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка создания админской сессии", "user_id", userID, "cache_id", cache.ID, "step", session.Step, "error", err)
+		b.sendMessage(userID, translate(lang, "error.generic"))
+		return
+	}
+
+	msg.Text += translate(lang, "edit.cancel_hint")
+	b.Outbox.Send(msg)
+}
+
+// loadEditedCache загружает тайник, который редактируется в админской сессии
+func (b *Bot) loadEditedCache(userID int64, session *AdminSession) *Cache {
+	cache, err := b.DB.GetCacheByCodeWord(session.CodeWord)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.Error("Ошибка получения тайника", "user_id", userID, "error", err)
+		}
+		b.DB.DeleteAdminSession(userID)
+		b.reply(userID, "edit.cache_missing")
+		return nil
+	}
+	return cache
+}
+
+// finishCacheEdit сохраняет изменения тайника и завершает админскую сессию
+func (b *Bot) finishCacheEdit(userID int64, cache *Cache, result string) {
+	if err := b.DB.UpdateCache(cache); err != nil {
+		slog.Error("Ошибка обновления тайника", "user_id", userID, "cache_id", cache.ID, "error", err)
+		b.reply(userID, "cache.save_failed")
+		return
+	}
+
+	b.DB.DeleteAdminSession(userID)
+
+	msg := tgbotapi.NewMessage(userID, result)
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+	b.Outbox.Send(msg)
+}
+
+// Обработчик ввода нового кодового слова
+func (b *Bot) handleEditCodeWordInput(userID int64, session *AdminSession, codeWord string) {
+	codeWord = strings.TrimSpace(codeWord)
+	if len(codeWord) < 3 {
+		b.reply(userID, "create.code_word_too_short")
+		return
+	}
+
+	if codeWord != session.CodeWord {
+		if b.isCodeWordTaken(codeWord) {
+			b.reply(userID, "create.code_word_taken")
+			return
+		}
+	}
+
+	cache := b.loadEditedCache(userID, session)
+	if cache == nil {
+		return
+	}
+
+	cache.CodeWord = codeWord
+	b.finishCacheEdit(userID, cache, b.text(userID, "edit.code_changed", session.CodeWord, codeWord))
+}
+
+// Обработчик ввода новой геолокации тайника
+func (b *Bot) handleEditLocationInput(userID int64, session *AdminSession, message *tgbotapi.Message) {
+	if message.Location == nil {
+		b.reply(userID, "create.location_required")
+		return
+	}
+
+	cache := b.loadEditedCache(userID, session)
+	if cache == nil {
+		return
+	}
+
+	cache.Latitude = float64(message.Location.Latitude)
+	cache.Longitude = float64(message.Location.Longitude)
+	b.finishCacheEdit(userID, cache, b.text(userID, "edit.location_changed",
+		cache.CodeWord, cache.Latitude, cache.Longitude))
+}
+
+// Обработчик замены медиафайла тайника
+func (b *Bot) handleEditMediaInput(userID int64, session *AdminSession, message *tgbotapi.Message) {
+	fileID, fileType, ok := extractMedia(message)
+	if !ok {
+		b.reply(userID, "create.media_required")
+		return
+	}
+
+	cache := b.loadEditedCache(userID, session)
+	if cache == nil {
+		return
+	}
+
+	cache.FileID = fileID
+	cache.FileType = fileType
+	lang := b.userLanguage(userID)
+	b.finishCacheEdit(userID, cache, translate(lang, "edit.media_changed", cache.CodeWord, mediaTypeAccusative(lang, fileType)))
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestE2ECatalogCallbacks(t *testing.T) {
+	h := newE2EHarness(t)
+
+	var caches []*Cache
+	for i := 1; i <= cacheListPageSize+1; i++ {
+		cache := &Cache{CodeWord: fmt.Sprintf("тайник %02d", i), Latitude: e2eCacheLat, Longitude: e2eCacheLon,
+			FileID: fmt.Sprintf("PHOTO_%d", i), FileType: "photo", CreatedBy: e2eAdminID}
+		if err := h.db.CreateCache(cache); err != nil {
+			t.Fatal(err)
+		}
+		caches = append(caches, cache)
+	}
+	target := caches[0]
+
+	// Листание списка правит то же сообщение
+	h.sendText(e2eAdminID, "/list")
+	request := h.expect(t, "sendMessage", e2eAdminID, "Тайники (11), страница 1 из 2")
+	if markup := request.Params.Get("reply_markup"); !strings.Contains(markup, `"list:1"`) {
+		t.Errorf("нет кнопки следующей страницы: %s", markup)
+	}
+	h.pressMessageButton(e2eAdminID, 700, "list:1")
+	request = h.expect(t, "editMessageText", e2eAdminID, "страница 2 из 2")
+	if request.Params.Get("message_id") != "700" || !strings.Contains(request.Text(), "11. 🔑") {
+		t.Errorf("вторая страница списка: %v", request.Params)
+	}
+
+	// Карточка тайника: превью медиафайла и кнопки управления
+	h.pressButton(e2eAdminID, fmt.Sprintf("cache:%d", target.ID))
+	if photo := h.expect(t, "sendPhoto", e2eAdminID, ""); photo.Params.Get("photo") != target.FileID {
+		t.Errorf("в карточке не то превью: %v", photo.Params)
+	}
+	card := h.expect(t, "sendMessage", e2eAdminID, target.CodeWord)
+	if markup := card.Params.Get("reply_markup"); !strings.Contains(markup, fmt.Sprintf(`"del:%d"`, target.ID)) {
+		t.Errorf("в карточке нет кнопки удаления: %s", markup)
+	}
+
+	// Переименование через кнопку карточки
+	h.pressButton(e2eAdminID, fmt.Sprintf("edit:%d:code", target.ID))
+	h.expect(t, "sendMessage", e2eAdminID, "новое кодовое слово")
+	h.sendText(e2eAdminID, caches[1].CodeWord)
+	h.expect(t, "sendMessage", e2eAdminID, "уже существует")
+	h.sendText(e2eAdminID, "новое имя")
+	h.expect(t, "sendMessage", e2eAdminID, "Кодовое слово изменено: тайник 01 → новое имя")
+
+	// Игрок не может управлять каталогом (его обновления попадают в тот же воркер,
+	// что и обновления администратора, поэтому нажатие обработано до следующих шагов)
+	h.pressButton(e2ePlayerID, fmt.Sprintf("delok:%d", target.ID))
+
+	// Удаление требует подтверждения
+	h.pressButton(e2eAdminID, fmt.Sprintf("del:%d", target.ID))
+	h.expect(t, "sendMessage", e2eAdminID, "Удалить тайник «новое имя»?")
+	h.pressButton(e2eAdminID, fmt.Sprintf("delno:%d", target.ID))
+	h.expect(t, "sendMessage", e2eAdminID, "Удаление отменено")
+	if _, err := h.db.GetCacheByID(target.ID); err != nil {
+		t.Fatalf("тайник удален без подтверждения: %v", err)
+	}
+
+	h.pressButton(e2eAdminID, fmt.Sprintf("delok:%d", target.ID))
+	h.expect(t, "sendMessage", e2eAdminID, "Тайник «новое имя» удален")
+	h.pressButton(e2eAdminID, fmt.Sprintf("cache:%d", target.ID))
+	h.expect(t, "sendMessage", e2eAdminID, "Тайник не найден")
+
+	for _, request := range h.api.Requests() {
+		if request.ChatID() == e2ePlayerID && request.Method != "answerCallbackQuery" {
+			t.Errorf("игроку отправлен ответ на кнопку каталога: %s %q", request.Method, request.Text())
+		}
+	}
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Database - хранилище на database/sql. Запросы пишутся с плейсхолдерами «?»
+// и переписываются под диалект PostgreSQL, если база открыта через NewPostgresDatabase.
 type Database struct {
-	db *sql.DB
+	db       *sql.DB
+	postgres bool
 }
 
 type Cache struct {
@@ -20,6 +25,97 @@ type Cache struct {
 	FileType  string    `json:"file_type"` // "photo", "video", "video_note"
 	CreatedAt time.Time `json:"created_at"`
 	CreatedBy int64     `json:"created_by"`
+	FindCount int       `json:"find_count"` // Сколько раз тайник был найден
+
+	RadiusMeters float64 `json:"radius_meters"` // Радиус обнаружения; 0 - используется TARGET_DISTANCE_METERS
+	Difficulty   int     `json:"difficulty"`    // Сложность поиска 1-5; 0 - не указана
+	Terrain      int     `json:"terrain"`       // Сложность местности 1-5; 0 - не указана
+	Description  string  `json:"description"`
+
+	NavigationMode string `json:"navigation_mode"` // NavigationCompass, NavigationDistance или NavigationHotCold
+	IsPublic       bool   `json:"is_public"`       // Тайник виден игрокам в /nearby
+}
+
+// Режимы навигации к тайнику
+const (
+	NavigationCompass  = "compass"  // Компас, направление и расстояние
+	NavigationDistance = "distance" // Только расстояние
+	NavigationHotCold  = "hotcold"  // Только «теплее/холоднее» относительно предыдущей точки
+)
+
+// Navigation возвращает режим навигации тайника; по умолчанию - компас
+func (c *Cache) Navigation() string {
+	if c.NavigationMode == "" {
+		return NavigationCompass
+	}
+	return c.NavigationMode
+}
+
+// TargetRadius возвращает радиус, в котором тайник считается найденным
+func (c *Cache) TargetRadius(defaultRadius float64) float64 {
+	if c.RadiusMeters > 0 {
+		return c.RadiusMeters
+	}
+	return defaultRadius
+}
+
+// Trail - маршрут из нескольких последовательных этапов.
+// Каждый этап - обычный тайник, связанный с маршрутом через trail_stages.
+type Trail struct {
+	ID          int64     `json:"id"`
+	CodeWord    string    `json:"code_word"`
+	IsPublished bool      `json:"is_published"` // Маршрут доступен для поиска только после /done
+	CreatedAt   time.Time `json:"created_at"`
+	CreatedBy   int64     `json:"created_by"`
+}
+
+type TrailStage struct {
+	TrailID  int64  `json:"trail_id"`
+	Position int    `json:"position"` // Порядковый номер этапа, начиная с 1
+	CacheID  int64  `json:"cache_id"`
+	Clue     string `json:"clue"` // Подсказка, которая открывается при достижении этапа
+}
+
+// TrailProgress - прогресс пользователя по маршруту
+type TrailProgress struct {
+	UserID        int64        `json:"user_id"`
+	TrailID       int64        `json:"trail_id"`
+	StagePosition int          `json:"stage_position"` // Последний пройденный этап (0 - ни одного)
+	StartedAt     time.Time    `json:"started_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	CompletedAt   sql.NullTime `json:"completed_at"`
+}
+
+// Типы вопросов-загадок тайника
+const (
+	PuzzleText   = "text"   // Свободный текстовый ответ
+	PuzzleChoice = "choice" // Выбор одного варианта кнопками
+	PuzzleNumber = "number" // Число с допустимой погрешностью
+)
+
+// CachePuzzle - вопрос, на который нужно ответить на месте тайника,
+// чтобы получить медиафайл
+type CachePuzzle struct {
+	CacheID     int64   `json:"cache_id"`
+	Kind        string  `json:"kind"`
+	Question    string  `json:"question"`
+	Answer      string  `json:"answer"`       // Для choice - номер верного варианта, для text - варианты через "|"
+	Options     string  `json:"options"`      // Варианты ответа для choice, по одному на строку
+	Tolerance   float64 `json:"tolerance"`    // Допустимая погрешность для number
+	MaxAttempts int     `json:"max_attempts"` // 0 - без ограничений
+}
+
+// IsComplete сообщает, заполнен ли вопрос полностью (создание могло быть прервано)
+func (p *CachePuzzle) IsComplete() bool {
+	return p.Question != "" && p.Answer != ""
+}
+
+// PuzzleAttempt - попытки пользователя ответить на вопрос тайника
+type PuzzleAttempt struct {
+	UserID   int64 `json:"user_id"`
+	CacheID  int64 `json:"cache_id"`
+	Attempts int   `json:"attempts"`
+	Awaiting bool  `json:"awaiting"` // Пользователь на месте и бот ждет ответа
 }
 
 type UserSession struct {
@@ -31,95 +127,192 @@ type UserSession struct {
 	LastMessageText string    `json:"last_message_text"`
 	IsActive        bool      `json:"is_active"`
 	LastUpdate      time.Time `json:"last_update"`
+	StartedAt       time.Time `json:"started_at"`      // Начало поиска текущего тайника
+	DistanceWalked  float64   `json:"distance_walked"` // Пройденное расстояние в метрах
+	LastFixAt       time.Time `json:"last_fix_at"`     // Когда сохранена LastLatitude/LastLongitude; нулевое - точки еще не было
+}
+
+// User - известные боту пользователи (для отображения имен в рейтинге и выбора языка)
+type User struct {
+	ID           int64  `json:"id"`
+	Username     string `json:"username"`
+	FirstName    string `json:"first_name"`
+	LanguageCode string `json:"language_code"` // Язык клиента Telegram
+	Language     string `json:"language"`      // Язык, выбранный командой /lang (пусто - по LanguageCode)
+}
+
+// Системы единиц для расстояний
+const (
+	UnitsMetric   = "metric"   // Метры и километры
+	UnitsImperial = "imperial" // Футы и мили
+)
+
+// DisplaySettings - настройки отображения навигации, выбранные пользователем в /settings
+type DisplaySettings struct {
+	Units         string `json:"units"`          // UnitsMetric или UnitsImperial
+	ShowBearing   bool   `json:"show_bearing"`   // Показывать азимут в градусах
+	CompassPoints int    `json:"compass_points"` // Точность направления: 8 или 16 румбов
+	Compact       bool   `json:"compact"`        // Навигация без ASCII-компаса
+}
+
+// defaultDisplaySettings - настройки для пользователей, которые не меняли их в /settings
+func defaultDisplaySettings() DisplaySettings {
+	return DisplaySettings{Units: UnitsMetric, CompassPoints: 8}
+}
+
+// LeaderboardEntry - строка рейтинга игроков
+type LeaderboardEntry struct {
+	User           User    `json:"user"`
+	Finds          int     `json:"finds"` // Число разных найденных тайников
+	FastestSeconds int64   `json:"fastest_seconds"`
+	DistanceMeters float64 `json:"distance_meters"`
+}
+
+// UserStats - личная статистика игрока
+type UserStats struct {
+	Finds          int     `json:"finds"`           // Число разных найденных тайников
+	FastestSeconds int64   `json:"fastest_seconds"` // 0 - нет данных о времени
+	DistanceMeters float64 `json:"distance_meters"`
+	Rank           int     `json:"rank"` // 0 - игрок еще ничего не нашел
+	Players        int     `json:"players"`
+}
+
+// Find - запись о найденном тайнике
+type Find struct {
+	ID              int64     `json:"id"`
+	UserID          int64     `json:"user_id"`
+	CacheID         int64     `json:"cache_id"`
+	FoundAt         time.Time `json:"found_at"`
+	DurationSeconds int64     `json:"duration_seconds"` // Время от начала поиска до находки
+	DistanceMeters  float64   `json:"distance_meters"`  // Пройденное за поиск расстояние
+
+	// Данные тайника для истории; пустые, если тайник удален
+	CodeWord string `json:"code_word"`
+	FileID   string `json:"file_id"`
+	FileType string `json:"file_type"`
+}
+
+// Виды подозрительных событий трансляции геопозиции
+const (
+	SuspicionJump        = "jump"         // Скачок с невозможной скоростью
+	SuspicionForwarded   = "forwarded"    // Пересланная, а не собственная геопозиция
+	SuspicionStartInside = "start_inside" // Первая точка поиска уже в радиусе обнаружения
+)
+
+// SuspicionEvent - признак подделки геопозиции во время поиска
+type SuspicionEvent struct {
+	ID             int64     `json:"id"`
+	UserID         int64     `json:"user_id"`
+	CacheID        int64     `json:"cache_id"`
+	Kind           string    `json:"kind"`
+	DistanceMeters float64   `json:"distance_meters"` // Для jump - длина скачка, для start_inside - расстояние до тайника
+	SpeedKmh       float64   `json:"speed_kmh"`       // Для jump - скорость скачка
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Данные для отчета; пустые, если пользователь неизвестен или тайник удален
+	User     User   `json:"user"`
+	CodeWord string `json:"code_word"`
 }
 
 type AdminSession struct {
 	UserID    int64   `json:"user_id"`
-	Step      string  `json:"step"` // "waiting_code", "waiting_location", "waiting_media"
-	CodeWord  string  `json:"code_word"`
+	Step      string  `json:"step"`      // "waiting_code", "waiting_location", "waiting_media", "edit_*", "trail_*"
+	CodeWord  string  `json:"code_word"` // При редактировании - кодовое слово тайника, при создании маршрута - кодовое слово маршрута
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
 }
 
 func NewDatabase(dataSourceName string) (*Database, error) {
-	db, err := sql.Open("sqlite3", dataSourceName)
+	database, err := openSQLite(dataSourceName)
 	if err != nil {
 		return nil, err
 	}
 
-	database := &Database{db: db}
-
-	if err := database.createTables(); err != nil {
+	if _, err := database.Migrate(); err != nil {
+		database.Close()
 		return nil, err
 	}
 
 	return database, nil
 }
 
-func (d *Database) createTables() error {
-	// Таблица тайников
-	cacheTable := `
-	CREATE TABLE IF NOT EXISTS caches (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		code_word TEXT UNIQUE NOT NULL,
-		latitude REAL NOT NULL,
-		longitude REAL NOT NULL,
-		file_id TEXT NOT NULL,
-		file_type TEXT NOT NULL DEFAULT 'photo',
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		created_by INTEGER NOT NULL
-	);`
-
-	// Таблица пользовательских сессий
-	userSessionTable := `
-	CREATE TABLE IF NOT EXISTS user_sessions (
-		user_id INTEGER PRIMARY KEY,
-		cache_id INTEGER NOT NULL,
-		last_latitude REAL,
-		last_longitude REAL,
-		last_message_id INTEGER,
-		last_message_text TEXT,
-		is_active BOOLEAN DEFAULT TRUE,
-		last_update DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (cache_id) REFERENCES caches (id)
-	);`
-
-	// Таблица админских сессий
-	adminSessionTable := `
-	CREATE TABLE IF NOT EXISTS admin_sessions (
-		user_id INTEGER PRIMARY KEY,
-		step TEXT NOT NULL,
-		code_word TEXT,
-		latitude REAL,
-		longitude REAL
-	);`
-
-	queries := []string{cacheTable, userSessionTable, adminSessionTable}
-
-	for _, query := range queries {
-		if _, err := d.db.Exec(query); err != nil {
-			return err
-		}
+// openSQLite открывает файл SQLite без применения миграций
+func openSQLite(dataSourceName string) (*Database, error) {
+	db, err := sql.Open("sqlite3", dataSourceName)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &Database{db: db}, nil
 }
 
 func (d *Database) Close() error {
 	return d.db.Close()
 }
 
-// Методы для работы с тайниками
-func (d *Database) CreateCache(cache *Cache) error {
-	query := `INSERT INTO caches (code_word, latitude, longitude, file_id, file_type, created_by) 
-			  VALUES (?, ?, ?, ?, ?, ?)`
+// Ping проверяет соединение с базой
+func (d *Database) Ping() error {
+	return d.db.Ping()
+}
 
-	result, err := d.db.Exec(query, cache.CodeWord, cache.Latitude, cache.Longitude, cache.FileID, cache.FileType, cache.CreatedBy)
-	if err != nil {
-		return err
+// rebind заменяет плейсхолдеры «?» на $1, $2, ... для PostgreSQL
+func (d *Database) rebind(query string) string {
+	if !d.postgres {
+		return query
 	}
 
-	id, err := result.LastInsertId()
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (d *Database) exec(query string, args ...interface{}) (sql.Result, error) {
+	defer observeDB(query, time.Now())
+	return d.db.Exec(d.rebind(query), args...)
+}
+
+func (d *Database) query(query string, args ...interface{}) (*sql.Rows, error) {
+	defer observeDB(query, time.Now())
+	return d.db.Query(d.rebind(query), args...)
+}
+
+func (d *Database) queryRow(query string, args ...interface{}) *sql.Row {
+	defer observeDB(query, time.Now())
+	return d.db.QueryRow(d.rebind(query), args...)
+}
+
+// insert выполняет INSERT и возвращает id новой строки.
+// LastInsertId не поддерживается драйвером PostgreSQL, поэтому используется RETURNING (есть и в SQLite 3.35+).
+func (d *Database) insert(query string, args ...interface{}) (int64, error) {
+	var id int64
+	err := d.queryRow(query+" RETURNING id", args...).Scan(&id)
+	return id, err
+}
+
+// Методы для работы с тайниками
+const insertCacheQuery = `INSERT INTO caches (code_word, latitude, longitude, file_id, file_type, created_by, radius_meters, difficulty, terrain, description,
+			  navigation_mode, is_public, grid_cell) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// insertCacheArgs возвращает значения колонок insertCacheQuery
+func insertCacheArgs(cache *Cache) []interface{} {
+	return []interface{}{cache.CodeWord, cache.Latitude, cache.Longitude, cache.FileID, cache.FileType, cache.CreatedBy,
+		cache.RadiusMeters, cache.Difficulty, cache.Terrain, cache.Description, cache.Navigation(), cache.IsPublic,
+		gridCell(cache.Latitude, cache.Longitude)}
+}
+
+func (d *Database) CreateCache(cache *Cache) error {
+	id, err := d.insert(insertCacheQuery, insertCacheArgs(cache)...)
 	if err != nil {
 		return err
 	}
@@ -128,64 +321,289 @@ func (d *Database) CreateCache(cache *Cache) error {
 	return nil
 }
 
-func (d *Database) GetCacheByCodeWord(codeWord string) (*Cache, error) {
-	query := `SELECT id, code_word, latitude, longitude, file_id, file_type, created_at, created_by 
-			  FROM caches WHERE code_word = ?`
+const cacheColumns = `id, code_word, latitude, longitude, file_id, file_type, created_at, created_by, find_count,
+	radius_meters, difficulty, terrain, description, navigation_mode, is_public`
 
+// rowScanner - общий интерфейс для *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCache(row rowScanner) (*Cache, error) {
 	cache := &Cache{}
-	err := d.db.QueryRow(query, codeWord).Scan(
+	err := row.Scan(
 		&cache.ID, &cache.CodeWord, &cache.Latitude, &cache.Longitude,
-		&cache.FileID, &cache.FileType, &cache.CreatedAt, &cache.CreatedBy,
+		&cache.FileID, &cache.FileType, &cache.CreatedAt, &cache.CreatedBy, &cache.FindCount,
+		&cache.RadiusMeters, &cache.Difficulty, &cache.Terrain, &cache.Description, &cache.NavigationMode, &cache.IsPublic,
 	)
+	if err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
 
+func (d *Database) GetCacheByCodeWord(codeWord string) (*Cache, error) {
+	query := `SELECT ` + cacheColumns + ` FROM caches WHERE code_word = ?`
+	return scanCache(d.queryRow(query, codeWord))
+}
+
+func (d *Database) GetCacheByID(id int64) (*Cache, error) {
+	query := `SELECT ` + cacheColumns + ` FROM caches WHERE id = ?`
+	return scanCache(d.queryRow(query, id))
+}
+
+// ListCaches возвращает страницу самостоятельных тайников (без этапов маршрутов), начиная с самых новых
+func (d *Database) ListCaches(limit, offset int) ([]*Cache, error) {
+	query := `SELECT ` + cacheColumns + ` FROM caches 
+			  WHERE id NOT IN (SELECT cache_id FROM trail_stages) ORDER BY id DESC LIMIT ? OFFSET ?`
+
+	rows, err := d.query(query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return cache, nil
+	var caches []*Cache
+	for rows.Next() {
+		cache, err := scanCache(rows)
+		if err != nil {
+			return nil, err
+		}
+		caches = append(caches, cache)
+	}
+
+	return caches, rows.Err()
+}
+
+// ExportableCaches возвращает все самостоятельные тайники (без этапов маршрутов) для выгрузки
+func (d *Database) ExportableCaches() ([]*Cache, error) {
+	query := `SELECT ` + cacheColumns + ` FROM caches 
+			  WHERE id NOT IN (SELECT cache_id FROM trail_stages) ORDER BY id`
+
+	rows, err := d.query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var caches []*Cache
+	for rows.Next() {
+		cache, err := scanCache(rows)
+		if err != nil {
+			return nil, err
+		}
+		caches = append(caches, cache)
+	}
+
+	return caches, rows.Err()
+}
+
+// CountCaches возвращает число самостоятельных тайников (без этапов маршрутов) для каталога
+func (d *Database) CountCaches() (int, error) {
+	var count int
+	err := d.queryRow(`SELECT COUNT(*) FROM caches WHERE id NOT IN (SELECT cache_id FROM trail_stages)`).Scan(&count)
+	return count, err
+}
+
+// UpdateCache сохраняет изменяемые администратором поля тайника
+func (d *Database) UpdateCache(cache *Cache) error {
+	query := `UPDATE caches SET code_word = ?, latitude = ?, longitude = ?, file_id = ?, file_type = ?,
+			  radius_meters = ?, difficulty = ?, terrain = ?, description = ?, navigation_mode = ?, is_public = ?,
+			  grid_cell = ? WHERE id = ?`
+	_, err := d.exec(query, cache.CodeWord, cache.Latitude, cache.Longitude, cache.FileID, cache.FileType,
+		cache.RadiusMeters, cache.Difficulty, cache.Terrain, cache.Description, cache.Navigation(), cache.IsPublic,
+		gridCell(cache.Latitude, cache.Longitude), cache.ID)
+	return err
+}
+
+// DeleteCache удаляет тайник вместе с пользовательскими сессиями, которые на него ссылаются
+func (d *Database) DeleteCache(id int64) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := d.deleteCacheTx(tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d *Database) deleteCacheTx(tx *sql.Tx, id int64) error {
+	queries := []string{
+		`DELETE FROM user_sessions WHERE cache_id = ?`,
+		`DELETE FROM trail_stages WHERE cache_id = ?`,
+		`DELETE FROM cache_puzzles WHERE cache_id = ?`,
+		`DELETE FROM puzzle_attempts WHERE cache_id = ?`,
+		`DELETE FROM caches WHERE id = ?`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(d.rebind(query), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Database) IncrementCacheFindCount(id int64) error {
+	_, err := d.exec(`UPDATE caches SET find_count = find_count + 1 WHERE id = ?`, id)
+	return err
 }
 
 // Методы для работы с пользовательскими сессиями
 func (d *Database) CreateOrUpdateUserSession(session *UserSession) error {
-	query := `INSERT OR REPLACE INTO user_sessions 
-			  (user_id, cache_id, last_latitude, last_longitude, last_message_id, last_message_text, is_active, last_update) 
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	if session.StartedAt.IsZero() {
+		session.StartedAt = time.Now()
+	}
+
+	query := `INSERT INTO user_sessions 
+			  (user_id, cache_id, last_latitude, last_longitude, last_message_id, last_message_text, is_active, last_update, started_at, distance_walked,
+			  last_fix_at) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT (user_id) DO UPDATE SET cache_id = excluded.cache_id, last_latitude = excluded.last_latitude,
+			  last_longitude = excluded.last_longitude, last_message_id = excluded.last_message_id,
+			  last_message_text = excluded.last_message_text, is_active = excluded.is_active, last_update = excluded.last_update,
+			  started_at = excluded.started_at, distance_walked = excluded.distance_walked, last_fix_at = excluded.last_fix_at,
+			  paused = FALSE`
 
-	_, err := d.db.Exec(query, session.UserID, session.CacheID, session.LastLatitude,
-		session.LastLongitude, session.LastMessageID, session.LastMessageText, session.IsActive, time.Now())
+	_, err := d.exec(query, session.UserID, session.CacheID, session.LastLatitude,
+		session.LastLongitude, session.LastMessageID, session.LastMessageText, session.IsActive, time.Now().UTC(),
+		session.StartedAt, session.DistanceWalked, sql.NullTime{Time: session.LastFixAt, Valid: !session.LastFixAt.IsZero()})
 	return err
 }
 
 func (d *Database) GetUserSession(userID int64) (*UserSession, error) {
-	query := `SELECT user_id, cache_id, last_latitude, last_longitude, last_message_id, last_message_text, is_active, last_update 
+	query := `SELECT user_id, cache_id, last_latitude, last_longitude, last_message_id, last_message_text, is_active, last_update,
+			  started_at, distance_walked, last_fix_at 
 			  FROM user_sessions WHERE user_id = ? AND is_active = TRUE`
 
 	session := &UserSession{}
-	err := d.db.QueryRow(query, userID).Scan(
+	var startedAt, lastFixAt sql.NullTime
+	err := d.queryRow(query, userID).Scan(
 		&session.UserID, &session.CacheID, &session.LastLatitude, &session.LastLongitude,
 		&session.LastMessageID, &session.LastMessageText, &session.IsActive, &session.LastUpdate,
+		&startedAt, &session.DistanceWalked, &lastFixAt,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// Сессии, созданные до появления started_at, считаем начатыми в момент последнего обновления
+	session.StartedAt = session.LastUpdate
+	if startedAt.Valid {
+		session.StartedAt = startedAt.Time
+	}
+	if lastFixAt.Valid {
+		session.LastFixAt = lastFixAt.Time
+	}
+
 	return session, nil
 }
 
 func (d *Database) DeactivateUserSession(userID int64) error {
-	query := `UPDATE user_sessions SET is_active = FALSE WHERE user_id = ?`
-	_, err := d.db.Exec(query, userID)
+	query := `UPDATE user_sessions SET is_active = FALSE, paused = FALSE WHERE user_id = ?`
+	_, err := d.exec(query, userID)
+	return err
+}
+
+// SetUserSessionMessageID запоминает новое навигационное сообщение активного поиска тайника cacheID.
+// Если пользователь тем временем остановил поиск или ищет другой тайник, ничего не меняется.
+func (d *Database) SetUserSessionMessageID(userID, cacheID int64, messageID int) error {
+	_, err := d.exec(`UPDATE user_sessions SET last_message_id = ? WHERE user_id = ? AND cache_id = ? AND is_active = TRUE`,
+		messageID, userID, cacheID)
+	return err
+}
+
+// UpdateUserSessionPosition сохраняет очередную точку активной сессии: позицию, текст навигации
+// и пройденное расстояние. last_message_id не трогается - его независимо меняет очередь
+// сообщений, когда правка не удалась и сообщение отправлено заново.
+func (d *Database) UpdateUserSessionPosition(session *UserSession) error {
+	query := `UPDATE user_sessions SET last_latitude = ?, last_longitude = ?, last_message_text = ?, distance_walked = ?,
+			  last_fix_at = ?, last_update = ? 
+			  WHERE user_id = ? AND is_active = TRUE`
+	_, err := d.exec(query, session.LastLatitude, session.LastLongitude, session.LastMessageText, session.DistanceWalked,
+		sql.NullTime{Time: session.LastFixAt, Valid: !session.LastFixAt.IsZero()}, time.Now().UTC(), session.UserID)
+	return err
+}
+
+// TouchUserSession отмечает, что от пользователя пришло обновление геопозиции
+func (d *Database) TouchUserSession(userID int64) error {
+	_, err := d.exec(`UPDATE user_sessions SET last_update = ? WHERE user_id = ? AND is_active = TRUE`, time.Now().UTC(), userID)
+	return err
+}
+
+// CountActiveUserSessions возвращает число активных сессий поиска
+func (d *Database) CountActiveUserSessions() (int, error) {
+	var count int
+	err := d.queryRow(`SELECT COUNT(*) FROM user_sessions WHERE is_active = TRUE`).Scan(&count)
+	return count, err
+}
+
+// GetStaleUserSessions возвращает активные сессии, не обновлявшиеся с момента before.
+// last_update хранится в UTC, чтобы SQLite сравнивал строки времени в одном формате.
+func (d *Database) GetStaleUserSessions(before time.Time) ([]*UserSession, error) {
+	rows, err := d.query(`SELECT user_id, cache_id, last_update FROM user_sessions WHERE is_active = TRUE AND last_update < ?`, before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*UserSession
+	for rows.Next() {
+		session := &UserSession{IsActive: true}
+		if err := rows.Scan(&session.UserID, &session.CacheID, &session.LastUpdate); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// PauseUserSession приостанавливает активную сессию, сохраняя цель поиска.
+// Возвращает false, если сессия уже не активна.
+func (d *Database) PauseUserSession(userID int64) (bool, error) {
+	result, err := d.exec(`UPDATE user_sessions SET is_active = FALSE, paused = TRUE WHERE user_id = ? AND is_active = TRUE`, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetPausedUserSession возвращает приостановленную сессию пользователя
+func (d *Database) GetPausedUserSession(userID int64) (*UserSession, error) {
+	query := `SELECT user_id, cache_id, last_update FROM user_sessions WHERE user_id = ? AND paused = TRUE`
+
+	session := &UserSession{}
+	err := d.queryRow(query, userID).Scan(&session.UserID, &session.CacheID, &session.LastUpdate)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ResumeUserSession возобновляет приостановленную сессию.
+// Навигационное сообщение будет отправлено заново при первом обновлении геопозиции.
+func (d *Database) ResumeUserSession(userID int64) error {
+	query := `UPDATE user_sessions SET is_active = TRUE, paused = FALSE, last_message_id = 0, last_message_text = '', last_update = ?
+			  WHERE user_id = ? AND paused = TRUE`
+	_, err := d.exec(query, time.Now().UTC(), userID)
 	return err
 }
 
 // Методы для работы с админскими сессиями
 func (d *Database) CreateOrUpdateAdminSession(session *AdminSession) error {
-	query := `INSERT OR REPLACE INTO admin_sessions 
+	query := `INSERT INTO admin_sessions 
 			  (user_id, step, code_word, latitude, longitude) 
-			  VALUES (?, ?, ?, ?, ?)`
+			  VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT (user_id) DO UPDATE SET step = excluded.step, code_word = excluded.code_word,
+			  latitude = excluded.latitude, longitude = excluded.longitude`
 
-	_, err := d.db.Exec(query, session.UserID, session.Step, session.CodeWord, session.Latitude, session.Longitude)
+	_, err := d.exec(query, session.UserID, session.Step, session.CodeWord, session.Latitude, session.Longitude)
 	return err
 }
 
@@ -194,7 +612,7 @@ func (d *Database) GetAdminSession(userID int64) (*AdminSession, error) {
 			  FROM admin_sessions WHERE user_id = ?`
 
 	session := &AdminSession{}
-	err := d.db.QueryRow(query, userID).Scan(
+	err := d.queryRow(query, userID).Scan(
 		&session.UserID, &session.Step, &session.CodeWord, &session.Latitude, &session.Longitude,
 	)
 
@@ -207,6 +625,491 @@ func (d *Database) GetAdminSession(userID int64) (*AdminSession, error) {
 
 func (d *Database) DeleteAdminSession(userID int64) error {
 	query := `DELETE FROM admin_sessions WHERE user_id = ?`
-	_, err := d.db.Exec(query, userID)
+	_, err := d.exec(query, userID)
 	return err
 }
+
+// Методы для работы с маршрутами
+func (d *Database) CreateTrail(trail *Trail) error {
+	query := `INSERT INTO trails (code_word, is_published, created_by) VALUES (?, ?, ?)`
+
+	id, err := d.insert(query, trail.CodeWord, trail.IsPublished, trail.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	trail.ID = id
+	return nil
+}
+
+const trailColumns = `id, code_word, is_published, created_at, created_by`
+
+func scanTrail(row rowScanner) (*Trail, error) {
+	trail := &Trail{}
+	err := row.Scan(&trail.ID, &trail.CodeWord, &trail.IsPublished, &trail.CreatedAt, &trail.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	return trail, nil
+}
+
+func (d *Database) GetTrailByCodeWord(codeWord string) (*Trail, error) {
+	query := `SELECT ` + trailColumns + ` FROM trails WHERE code_word = ?`
+	return scanTrail(d.queryRow(query, codeWord))
+}
+
+func (d *Database) GetTrailByID(id int64) (*Trail, error) {
+	query := `SELECT ` + trailColumns + ` FROM trails WHERE id = ?`
+	return scanTrail(d.queryRow(query, id))
+}
+
+func (d *Database) PublishTrail(id int64) error {
+	_, err := d.exec(`UPDATE trails SET is_published = TRUE WHERE id = ?`, id)
+	return err
+}
+
+// DeleteTrail удаляет маршрут вместе с тайниками его этапов и прогрессом пользователей
+func (d *Database) DeleteTrail(id int64) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(d.rebind(`SELECT cache_id FROM trail_stages WHERE trail_id = ?`), id)
+	if err != nil {
+		return err
+	}
+	var cacheIDs []int64
+	for rows.Next() {
+		var cacheID int64
+		if err := rows.Scan(&cacheID); err != nil {
+			rows.Close()
+			return err
+		}
+		cacheIDs = append(cacheIDs, cacheID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, cacheID := range cacheIDs {
+		if err := d.deleteCacheTx(tx, cacheID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(d.rebind(`DELETE FROM trail_progress WHERE trail_id = ?`), id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(d.rebind(`DELETE FROM trails WHERE id = ?`), id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateTrailStage создает тайник этапа и добавляет его в маршрут одной транзакцией:
+// тайник без маршрута не должен остаться, если вторая запись не удалась
+func (d *Database) CreateTrailStage(cache *Cache, stage *TrailStage) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	if err := tx.QueryRow(d.rebind(insertCacheQuery+" RETURNING id"), insertCacheArgs(cache)...).Scan(&id); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO trail_stages (trail_id, position, cache_id, clue) VALUES (?, ?, ?, ?)`
+	if _, err := tx.Exec(d.rebind(query), stage.TrailID, stage.Position, id, stage.Clue); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	cache.ID = id
+	stage.CacheID = id
+	return nil
+}
+
+func (d *Database) AddTrailStage(stage *TrailStage) error {
+	query := `INSERT INTO trail_stages (trail_id, position, cache_id, clue) VALUES (?, ?, ?, ?)`
+	_, err := d.exec(query, stage.TrailID, stage.Position, stage.CacheID, stage.Clue)
+	return err
+}
+
+func (d *Database) UpdateTrailStageClue(trailID int64, position int, clue string) error {
+	query := `UPDATE trail_stages SET clue = ? WHERE trail_id = ? AND position = ?`
+	_, err := d.exec(query, clue, trailID, position)
+	return err
+}
+
+func scanTrailStage(row rowScanner) (*TrailStage, error) {
+	stage := &TrailStage{}
+	err := row.Scan(&stage.TrailID, &stage.Position, &stage.CacheID, &stage.Clue)
+	if err != nil {
+		return nil, err
+	}
+	return stage, nil
+}
+
+// GetTrailStages возвращает этапы маршрута по порядку
+func (d *Database) GetTrailStages(trailID int64) ([]*TrailStage, error) {
+	query := `SELECT trail_id, position, cache_id, clue FROM trail_stages WHERE trail_id = ? ORDER BY position`
+
+	rows, err := d.query(query, trailID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stages []*TrailStage
+	for rows.Next() {
+		stage, err := scanTrailStage(rows)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+
+	return stages, rows.Err()
+}
+
+// GetTrailStageByCacheID возвращает этап маршрута, которому принадлежит тайник
+func (d *Database) GetTrailStageByCacheID(cacheID int64) (*TrailStage, error) {
+	query := `SELECT trail_id, position, cache_id, clue FROM trail_stages WHERE cache_id = ?`
+	return scanTrailStage(d.queryRow(query, cacheID))
+}
+
+// GetNextTrailStage возвращает первый этап маршрута после указанной позиции
+func (d *Database) GetNextTrailStage(trailID int64, afterPosition int) (*TrailStage, error) {
+	query := `SELECT trail_id, position, cache_id, clue FROM trail_stages 
+			  WHERE trail_id = ? AND position > ? ORDER BY position LIMIT 1`
+	return scanTrailStage(d.queryRow(query, trailID, afterPosition))
+}
+
+func (d *Database) GetTrailProgress(userID, trailID int64) (*TrailProgress, error) {
+	query := `SELECT user_id, trail_id, stage_position, started_at, updated_at, completed_at 
+			  FROM trail_progress WHERE user_id = ? AND trail_id = ?`
+
+	progress := &TrailProgress{}
+	err := d.queryRow(query, userID, trailID).Scan(
+		&progress.UserID, &progress.TrailID, &progress.StagePosition,
+		&progress.StartedAt, &progress.UpdatedAt, &progress.CompletedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+func (d *Database) SaveTrailProgress(progress *TrailProgress) error {
+	query := `INSERT INTO trail_progress 
+			  (user_id, trail_id, stage_position, started_at, updated_at, completed_at) 
+			  VALUES (?, ?, ?, ?, ?, ?)
+			  ON CONFLICT (user_id, trail_id) DO UPDATE SET stage_position = excluded.stage_position,
+			  started_at = excluded.started_at, updated_at = excluded.updated_at, completed_at = excluded.completed_at`
+
+	_, err := d.exec(query, progress.UserID, progress.TrailID, progress.StagePosition,
+		progress.StartedAt, time.Now(), progress.CompletedAt)
+	return err
+}
+
+// Методы для работы с вопросами тайников
+func (d *Database) SaveCachePuzzle(puzzle *CachePuzzle) error {
+	query := `INSERT INTO cache_puzzles 
+			  (cache_id, kind, question, answer, options, tolerance, max_attempts) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT (cache_id) DO UPDATE SET kind = excluded.kind, question = excluded.question, answer = excluded.answer,
+			  options = excluded.options, tolerance = excluded.tolerance, max_attempts = excluded.max_attempts`
+
+	_, err := d.exec(query, puzzle.CacheID, puzzle.Kind, puzzle.Question, puzzle.Answer,
+		puzzle.Options, puzzle.Tolerance, puzzle.MaxAttempts)
+	return err
+}
+
+func (d *Database) GetCachePuzzle(cacheID int64) (*CachePuzzle, error) {
+	query := `SELECT cache_id, kind, question, answer, options, tolerance, max_attempts 
+			  FROM cache_puzzles WHERE cache_id = ?`
+
+	puzzle := &CachePuzzle{}
+	err := d.queryRow(query, cacheID).Scan(
+		&puzzle.CacheID, &puzzle.Kind, &puzzle.Question, &puzzle.Answer,
+		&puzzle.Options, &puzzle.Tolerance, &puzzle.MaxAttempts,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return puzzle, nil
+}
+
+func (d *Database) DeleteCachePuzzle(cacheID int64) error {
+	_, err := d.exec(`DELETE FROM cache_puzzles WHERE cache_id = ?`, cacheID)
+	return err
+}
+
+func (d *Database) GetPuzzleAttempt(userID, cacheID int64) (*PuzzleAttempt, error) {
+	query := `SELECT user_id, cache_id, attempts, awaiting FROM puzzle_attempts WHERE user_id = ? AND cache_id = ?`
+
+	attempt := &PuzzleAttempt{}
+	err := d.queryRow(query, userID, cacheID).Scan(&attempt.UserID, &attempt.CacheID, &attempt.Attempts, &attempt.Awaiting)
+	if err != nil {
+		return nil, err
+	}
+
+	return attempt, nil
+}
+
+func (d *Database) SavePuzzleAttempt(attempt *PuzzleAttempt) error {
+	query := `INSERT INTO puzzle_attempts (user_id, cache_id, attempts, awaiting) VALUES (?, ?, ?, ?)
+			  ON CONFLICT (user_id, cache_id) DO UPDATE SET attempts = excluded.attempts, awaiting = excluded.awaiting`
+	_, err := d.exec(query, attempt.UserID, attempt.CacheID, attempt.Attempts, attempt.Awaiting)
+	return err
+}
+
+// ClearPuzzleAwaiting снимает ожидание ответа у пользователя (например, при остановке поиска).
+// Счетчик попыток при этом сохраняется, чтобы лимит нельзя было обойти перезапуском поиска.
+func (d *Database) ClearPuzzleAwaiting(userID int64) error {
+	_, err := d.exec(`UPDATE puzzle_attempts SET awaiting = FALSE WHERE user_id = ?`, userID)
+	return err
+}
+
+// Методы для работы с журналом находок
+func (d *Database) CreateFind(find *Find) error {
+	query := `INSERT INTO finds (user_id, cache_id, found_at, duration_seconds, distance_meters) 
+			  VALUES (?, ?, ?, ?, ?)`
+
+	// Время находок храним в UTC, чтобы фильтр по периоду сравнивал строки в одном формате
+	id, err := d.insert(query, find.UserID, find.CacheID, find.FoundAt.UTC(), find.DurationSeconds, find.DistanceMeters)
+	if err != nil {
+		return err
+	}
+
+	find.ID = id
+	return nil
+}
+
+const findColumns = `f.id, f.user_id, f.cache_id, f.found_at, f.duration_seconds, f.distance_meters,
+	COALESCE(c.code_word, ''), COALESCE(c.file_id, ''), COALESCE(c.file_type, '')`
+
+func scanFind(row rowScanner) (*Find, error) {
+	find := &Find{}
+	err := row.Scan(
+		&find.ID, &find.UserID, &find.CacheID, &find.FoundAt, &find.DurationSeconds, &find.DistanceMeters,
+		&find.CodeWord, &find.FileID, &find.FileType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return find, nil
+}
+
+func (d *Database) GetFindByID(id int64) (*Find, error) {
+	query := `SELECT ` + findColumns + ` FROM finds f LEFT JOIN caches c ON c.id = f.cache_id WHERE f.id = ?`
+	return scanFind(d.queryRow(query, id))
+}
+
+// GetUserFinds возвращает находки пользователя, начиная с последней
+func (d *Database) GetUserFinds(userID int64, limit, offset int) ([]*Find, error) {
+	query := `SELECT ` + findColumns + ` FROM finds f LEFT JOIN caches c ON c.id = f.cache_id 
+			  WHERE f.user_id = ? ORDER BY f.found_at DESC, f.id DESC LIMIT ? OFFSET ?`
+
+	rows, err := d.query(query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var finds []*Find
+	for rows.Next() {
+		find, err := scanFind(rows)
+		if err != nil {
+			return nil, err
+		}
+		finds = append(finds, find)
+	}
+
+	return finds, rows.Err()
+}
+
+func (d *Database) CountUserFinds(userID int64) (int, error) {
+	var count int
+	err := d.queryRow(`SELECT COUNT(*) FROM finds WHERE user_id = ?`, userID).Scan(&count)
+	return count, err
+}
+
+// Методы для работы с подозрительными событиями
+
+func (d *Database) CreateSuspicionEvent(event *SuspicionEvent) error {
+	query := `INSERT INTO suspicion_events (user_id, cache_id, kind, distance_meters, speed_kmh, latitude, longitude, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// Как и находки, храним время в UTC, чтобы сравнение с началом поиска шло в одном формате
+	id, err := d.insert(query, event.UserID, event.CacheID, event.Kind, event.DistanceMeters, event.SpeedKmh,
+		event.Latitude, event.Longitude, event.CreatedAt.UTC())
+	if err != nil {
+		return err
+	}
+
+	event.ID = id
+	return nil
+}
+
+// CountSuspicionEvents возвращает число событий пользователя при поиске тайника начиная с since; kind "" - любого вида
+func (d *Database) CountSuspicionEvents(userID, cacheID int64, kind string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM suspicion_events
+			  WHERE user_id = ? AND cache_id = ? AND created_at >= ? AND (? = '' OR kind = ?)`
+	var count int
+	err := d.queryRow(query, userID, cacheID, since.UTC(), kind, kind).Scan(&count)
+	return count, err
+}
+
+// GetSuspicionEvents возвращает последние подозрительные события; userID 0 - всех пользователей
+func (d *Database) GetSuspicionEvents(userID int64, limit int) ([]*SuspicionEvent, error) {
+	query := `SELECT e.id, e.user_id, e.cache_id, e.kind, e.distance_meters, e.speed_kmh, e.latitude, e.longitude, e.created_at,
+			  COALESCE(u.username, ''), COALESCE(u.first_name, ''), COALESCE(c.code_word, '')
+			  FROM suspicion_events e
+			  LEFT JOIN users u ON u.user_id = e.user_id
+			  LEFT JOIN caches c ON c.id = e.cache_id
+			  WHERE ? = 0 OR e.user_id = ?
+			  ORDER BY e.created_at DESC, e.id DESC LIMIT ?`
+
+	rows, err := d.query(query, userID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*SuspicionEvent
+	for rows.Next() {
+		event := &SuspicionEvent{}
+		err := rows.Scan(&event.ID, &event.UserID, &event.CacheID, &event.Kind, &event.DistanceMeters, &event.SpeedKmh,
+			&event.Latitude, &event.Longitude, &event.CreatedAt, &event.User.Username, &event.User.FirstName, &event.CodeWord)
+		if err != nil {
+			return nil, err
+		}
+		event.User.ID = event.UserID
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// Методы для работы с пользователями и статистикой
+
+// SaveUser сохраняет имя и язык клиента пользователя. Telegram присылает language_code
+// не во всех обновлениях, поэтому пустое значение не затирает ранее известный язык.
+func (d *Database) SaveUser(user *User) error {
+	query := `INSERT INTO users (user_id, username, first_name, language_code, updated_at) VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT (user_id) DO UPDATE SET username = excluded.username, first_name = excluded.first_name,
+			  language_code = CASE WHEN excluded.language_code <> '' THEN excluded.language_code ELSE users.language_code END,
+			  updated_at = excluded.updated_at`
+	_, err := d.exec(query, user.ID, user.Username, user.FirstName, user.LanguageCode, time.Now())
+	return err
+}
+
+func (d *Database) GetUser(userID int64) (*User, error) {
+	user := &User{}
+	query := `SELECT user_id, username, first_name, language_code, language FROM users WHERE user_id = ?`
+	err := d.queryRow(query, userID).Scan(&user.ID, &user.Username, &user.FirstName, &user.LanguageCode, &user.Language)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetUserLanguage сохраняет язык, выбранный пользователем (пусто - определять по клиенту Telegram)
+func (d *Database) SetUserLanguage(userID int64, language string) error {
+	query := `INSERT INTO users (user_id, language, updated_at) VALUES (?, ?, ?)
+			  ON CONFLICT (user_id) DO UPDATE SET language = excluded.language, updated_at = excluded.updated_at`
+	_, err := d.exec(query, userID, language, time.Now())
+	return err
+}
+
+// GetDisplaySettings возвращает настройки отображения пользователя
+func (d *Database) GetDisplaySettings(userID int64) (*DisplaySettings, error) {
+	settings := &DisplaySettings{}
+	query := `SELECT units, show_bearing, compass_points, compact_navigation FROM users WHERE user_id = ?`
+	err := d.queryRow(query, userID).Scan(&settings.Units, &settings.ShowBearing, &settings.CompassPoints, &settings.Compact)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SaveDisplaySettings сохраняет настройки отображения пользователя
+func (d *Database) SaveDisplaySettings(userID int64, settings *DisplaySettings) error {
+	query := `INSERT INTO users (user_id, units, show_bearing, compass_points, compact_navigation, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+			  ON CONFLICT (user_id) DO UPDATE SET units = excluded.units, show_bearing = excluded.show_bearing,
+			  compass_points = excluded.compass_points, compact_navigation = excluded.compact_navigation, updated_at = excluded.updated_at`
+	_, err := d.exec(query, userID, settings.Units, settings.ShowBearing, settings.CompassPoints, settings.Compact, time.Now())
+	return err
+}
+
+// GetLeaderboard возвращает лучших игроков по числу находок начиная с since (нулевое время - за все время).
+// Тайник засчитывается игроку один раз, сколько бы раз его ни нашли; этап маршрута - такой же тайник.
+func (d *Database) GetLeaderboard(since time.Time, limit int) ([]*LeaderboardEntry, error) {
+	query := `SELECT f.user_id, COALESCE(u.username, ''), COALESCE(u.first_name, ''),
+			  COUNT(DISTINCT f.cache_id) AS finds, COALESCE(MIN(NULLIF(f.duration_seconds, 0)), 0), SUM(f.distance_meters)
+			  FROM finds f LEFT JOIN users u ON u.user_id = f.user_id
+			  WHERE f.found_at >= ?
+			  GROUP BY f.user_id, u.username, u.first_name
+			  ORDER BY finds DESC, MAX(f.found_at) ASC, f.user_id
+			  LIMIT ?`
+
+	rows, err := d.query(query, since.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*LeaderboardEntry
+	for rows.Next() {
+		entry := &LeaderboardEntry{}
+		err := rows.Scan(&entry.User.ID, &entry.User.Username, &entry.User.FirstName,
+			&entry.Finds, &entry.FastestSeconds, &entry.DistanceMeters)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetUserStats возвращает статистику игрока и его место в общем рейтинге
+func (d *Database) GetUserStats(userID int64) (*UserStats, error) {
+	stats := &UserStats{}
+
+	query := `SELECT COUNT(DISTINCT cache_id), COALESCE(MIN(NULLIF(duration_seconds, 0)), 0), COALESCE(SUM(distance_meters), 0)
+			  FROM finds WHERE user_id = ?`
+	if err := d.queryRow(query, userID).Scan(&stats.Finds, &stats.FastestSeconds, &stats.DistanceMeters); err != nil {
+		return nil, err
+	}
+
+	if err := d.queryRow(`SELECT COUNT(DISTINCT user_id) FROM finds`).Scan(&stats.Players); err != nil {
+		return nil, err
+	}
+
+	if stats.Finds == 0 {
+		return stats, nil
+	}
+
+	// Место - число игроков, нашедших больше разных тайников, плюс один
+	query = `SELECT COUNT(*) FROM (SELECT user_id FROM finds GROUP BY user_id HAVING COUNT(DISTINCT cache_id) > ?) AS better`
+	if err := d.queryRow(query, stats.Finds).Scan(&stats.Rank); err != nil {
+		return nil, err
+	}
+	stats.Rank++
+
+	return stats, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"log/slog"
+	"runtime/debug"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Dispatcher распределяет обновления по фиксированному набору воркеров.
+// Все обновления одного пользователя попадают в один и тот же воркер и обрабатываются строго по порядку,
+// а обновления разных пользователей обрабатываются параллельно.
+type Dispatcher struct {
+	queues []chan tgbotapi.Update
+	handle func(tgbotapi.Update)
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher запускает workers воркеров с очередями на queueSize обновлений каждая
+func NewDispatcher(workers, queueSize int, handle func(tgbotapi.Update)) *Dispatcher {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+
+	d := &Dispatcher{
+		queues: make([]chan tgbotapi.Update, workers),
+		handle: handle,
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan tgbotapi.Update, queueSize)
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+
+	return d
+}
+
+func (d *Dispatcher) work(queue chan tgbotapi.Update) {
+	defer d.wg.Done()
+
+	for update := range queue {
+		d.process(update)
+	}
+}
+
+// process обрабатывает одно обновление; паника в обработчике не должна останавливать воркер
+func (d *Dispatcher) process(update tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Паника при обработке обновления", "update_id", update.UpdateID, "user_id", updateUserID(update), "panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+
+	d.handle(update)
+}
+
+// Dispatch ставит обновление в очередь воркера, закрепленного за пользователем.
+// Если очередь переполнена, устаревшие тики трансляции геопозиции отбрасываются (следом придет более свежий),
+// а остальные обновления ждут освобождения места.
+func (d *Dispatcher) Dispatch(update tgbotapi.Update) {
+	queue := d.queues[d.workerFor(update)]
+
+	select {
+	case queue <- update:
+		return
+	default:
+	}
+
+	if isLocationTick(update) {
+		slog.Warn("Очередь обработки переполнена, обновление геопозиции отброшено", "update_id", update.UpdateID, "user_id", updateUserID(update))
+		return
+	}
+	queue <- update
+}
+
+// Stop дожидается обработки всех поставленных в очередь обновлений
+func (d *Dispatcher) Stop() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.wg.Wait()
+}
+
+// workerFor выбирает воркер по ID пользователя
+func (d *Dispatcher) workerFor(update tgbotapi.Update) int {
+	key := updateUserID(update)
+	if key < 0 {
+		key = -key
+	}
+	return int(key % int64(len(d.queues)))
+}
+
+// isLocationTick сообщает, что обновление - очередная точка трансляции геопозиции
+func isLocationTick(update tgbotapi.Update) bool {
+	return update.EditedMessage != nil && update.EditedMessage.Location != nil
+}
+
+// updateUserID возвращает ID отправителя обновления (или чата, если отправителя нет)
+func updateUserID(update tgbotapi.Update) int64 {
+	if from := update.SentFrom(); from != nil {
+		return from.ID
+	}
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	return 0
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func newDispatcherTestUpdate(id int, userID int64) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: id,
+		Message:  &tgbotapi.Message{From: &tgbotapi.User{ID: userID}, Chat: &tgbotapi.Chat{ID: userID}},
+	}
+}
+
+func newDispatcherTestTick(id int, userID int64) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: id,
+		EditedMessage: &tgbotapi.Message{
+			From:     &tgbotapi.User{ID: userID},
+			Chat:     &tgbotapi.Chat{ID: userID},
+			Location: &tgbotapi.Location{Latitude: 55.75, Longitude: 37.61},
+		},
+	}
+}
+
+func TestDispatcherKeepsPerUserOrder(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen = make(map[int64][]int)
+	)
+	dispatcher := NewDispatcher(4, 8, func(update tgbotapi.Update) {
+		// Обработка разной длительности перемешала бы обновления без привязки к воркеру
+		time.Sleep(time.Duration(update.UpdateID%3) * time.Millisecond)
+		userID := updateUserID(update)
+		mu.Lock()
+		seen[userID] = append(seen[userID], update.UpdateID)
+		mu.Unlock()
+	})
+
+	const users, perUser = 10, 20
+	id := 0
+	for i := 0; i < perUser; i++ {
+		for user := int64(1); user <= users; user++ {
+			id++
+			dispatcher.Dispatch(newDispatcherTestUpdate(id, user))
+		}
+	}
+	dispatcher.Stop()
+
+	for user := int64(1); user <= users; user++ {
+		updates := seen[user]
+		if len(updates) != perUser {
+			t.Errorf("пользователь %d: обработано %d обновлений из %d", user, len(updates), perUser)
+			continue
+		}
+		for i := 1; i < len(updates); i++ {
+			if updates[i] < updates[i-1] {
+				t.Errorf("пользователь %d: нарушен порядок обновлений %v", user, updates)
+				break
+			}
+		}
+	}
+}
+
+func TestDispatcherDoesNotBlockOtherUsers(t *testing.T) {
+	release := make(chan struct{})
+	handled := make(chan int64, 1)
+	dispatcher := NewDispatcher(2, 1, func(update tgbotapi.Update) {
+		userID := updateUserID(update)
+		if userID == 2 {
+			<-release
+			return
+		}
+		handled <- userID
+	})
+	defer dispatcher.Stop()
+
+	// Пользователи 2 и 3 попадают в разные воркеры: зависший обработчик одного не задерживает другого
+	dispatcher.Dispatch(newDispatcherTestUpdate(1, 2))
+	dispatcher.Dispatch(newDispatcherTestUpdate(2, 3))
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Error("обновление другого пользователя ждет чужой обработчик")
+	}
+	close(release)
+}
+
+func TestDispatcherDropsLocationTicksWhenQueueIsFull(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var (
+		mu        sync.Mutex
+		processed []int
+	)
+	dispatcher := NewDispatcher(1, 1, func(update tgbotapi.Update) {
+		if update.UpdateID == 1 {
+			started <- struct{}{}
+			<-release
+		}
+		mu.Lock()
+		processed = append(processed, update.UpdateID)
+		mu.Unlock()
+	})
+
+	dispatcher.Dispatch(newDispatcherTestUpdate(1, 5))
+	<-started
+	dispatcher.Dispatch(newDispatcherTestTick(2, 5)) // Занимает единственное место в очереди
+	dispatcher.Dispatch(newDispatcherTestTick(3, 5)) // Очередь полна - тик отбрасывается
+
+	// Обычное сообщение не отбрасывается, а ждет места в очереди
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	dispatcher.Dispatch(newDispatcherTestUpdate(4, 5))
+	dispatcher.Stop()
+
+	if len(processed) != 3 || processed[0] != 1 || processed[1] != 2 || processed[2] != 4 {
+		t.Errorf("обработаны обновления %v, ожидалось [1 2 4]", processed)
+	}
+}
+
+func TestDispatcherSurvivesPanic(t *testing.T) {
+	var handled []int
+	dispatcher := NewDispatcher(1, 4, func(update tgbotapi.Update) {
+		if update.UpdateID == 1 {
+			panic("сбой обработчика")
+		}
+		handled = append(handled, update.UpdateID)
+	})
+
+	dispatcher.Dispatch(newDispatcherTestUpdate(1, 5))
+	dispatcher.Dispatch(newDispatcherTestUpdate(2, 5))
+	dispatcher.Stop()
+
+	if len(handled) != 1 || handled[0] != 2 {
+		t.Errorf("после паники воркер обработал %v", handled)
+	}
+}
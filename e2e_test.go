@@ -0,0 +1,457 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	e2eAdminID  int64 = 100
+	e2ePlayerID int64 = 200
+)
+
+// Координаты тайника и точки, через которые идет игрок
+const (
+	e2eCacheLat = 55.7558
+	e2eCacheLon = 37.6173
+)
+
+// e2eHarness - бот, запущенный в режиме long polling против поддельного Bot API
+type e2eHarness struct {
+	api  *fakeBotAPI
+	bot  *Bot
+	db   *Database
+	next int // Номер запроса, с которого ищется следующий ответ бота
+
+	nextMessageID int
+}
+
+func newE2EHarness(t *testing.T) *e2eHarness {
+	t.Helper()
+
+	api := newFakeBotAPI(t)
+
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "e2e.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := tgbotapi.NewBotAPIWithClient("123456:TEST", api.Endpoint(), api.server.Client())
+	if err != nil {
+		t.Fatalf("подключение к поддельному Bot API: %v", err)
+	}
+
+	config := &Config{
+		TargetDistanceMeters:  30,
+		UpdateIntervalSeconds: 0,
+		MessagesPerSecond:     1000,
+		Workers:               4,
+		WorkerQueueSize:       10,
+		NearbyRadiusMeters:    5000,
+	}
+	bot := NewBot(client, db, []int64{e2eAdminID}, config)
+	dispatcher := NewDispatcher(config.Workers, config.WorkerQueueSize, bot.handleUpdate)
+
+	stopped := make(chan struct{})
+	go func() {
+		bot.runPolling(dispatcher)
+		close(stopped)
+	}()
+
+	t.Cleanup(func() {
+		client.StopReceivingUpdates()
+		<-stopped
+		dispatcher.Stop()
+		db.Close()
+	})
+
+	return &e2eHarness{api: api, bot: bot, db: db, nextMessageID: 1}
+}
+
+// newMessage создает входящее сообщение от пользователя
+func (h *e2eHarness) newMessage(userID int64) *tgbotapi.Message {
+	h.nextMessageID++
+	return &tgbotapi.Message{
+		MessageID: h.nextMessageID,
+		From:      &tgbotapi.User{ID: userID, FirstName: "Игрок"},
+		Chat:      &tgbotapi.Chat{ID: userID, Type: "private"},
+		Date:      int(time.Now().Unix()),
+	}
+}
+
+// sendText отправляет боту текст; сообщения, начинающиеся с «/», отправляются как команды
+func (h *e2eHarness) sendText(userID int64, text string) {
+	message := h.newMessage(userID)
+	message.Text = text
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}}
+	}
+	h.api.PushUpdate(tgbotapi.Update{Message: message})
+}
+
+// sendLocation отправляет обычную геопозицию (livePeriod = 0) или начинает трансляцию.
+// Возвращает ID сообщения, которое затем правится при движении.
+func (h *e2eHarness) sendLocation(userID int64, lat, lon float64, livePeriod int) int {
+	message := h.newMessage(userID)
+	message.Location = &tgbotapi.Location{Latitude: lat, Longitude: lon, LivePeriod: livePeriod}
+	h.api.PushUpdate(tgbotapi.Update{Message: message})
+	return message.MessageID
+}
+
+// moveTo отправляет очередную точку трансляции геопозиции
+func (h *e2eHarness) moveTo(userID int64, messageID int, lat, lon float64) {
+	message := h.newMessage(userID)
+	message.MessageID = messageID
+	message.EditDate = int(time.Now().Unix())
+	message.Location = &tgbotapi.Location{Latitude: lat, Longitude: lon, LivePeriod: 3600}
+	h.api.PushUpdate(tgbotapi.Update{EditedMessage: message})
+}
+
+func (h *e2eHarness) sendPhoto(userID int64, fileID string) {
+	message := h.newMessage(userID)
+	message.Photo = []tgbotapi.PhotoSize{
+		{FileID: fileID + "_small", Width: 90, Height: 90},
+		{FileID: fileID, Width: 1280, Height: 960},
+	}
+	h.api.PushUpdate(tgbotapi.Update{Message: message})
+}
+
+// pressButton нажимает inline-кнопку с callback-данными data
+func (h *e2eHarness) pressButton(userID int64, data string) {
+	h.pressMessageButton(userID, 0, data)
+}
+
+// pressMessageButton нажимает inline-кнопку под сообщением бота messageID
+// (такие кнопки бот обрабатывает, редактируя само сообщение)
+func (h *e2eHarness) pressMessageButton(userID int64, messageID int, data string) {
+	h.nextMessageID++
+	query := &tgbotapi.CallbackQuery{
+		ID:   strconv.Itoa(h.nextMessageID),
+		From: &tgbotapi.User{ID: userID, FirstName: "Игрок"},
+		Data: data,
+	}
+	if messageID != 0 {
+		query.Message = &tgbotapi.Message{MessageID: messageID, Chat: &tgbotapi.Chat{ID: userID, Type: "private"}}
+	}
+	h.api.PushUpdate(tgbotapi.Update{CallbackQuery: query})
+}
+
+// expect ждет, что бот вызовет method для пользователя с текстом, содержащим substring
+func (h *e2eHarness) expect(t *testing.T, method string, userID int64, substring string) fakeAPIRequest {
+	t.Helper()
+
+	request, next := h.api.WaitFor(h.next, method, func(r fakeAPIRequest) bool {
+		return r.ChatID() == userID && strings.Contains(r.Text(), substring)
+	})
+	h.next = next
+	return request
+}
+
+// waitForNavigation ждет, пока обработчик сохранит в сессии первое навигационное сообщение:
+// expect возвращается, как только сообщение отправлено, а сессия записывается следом
+func (h *e2eHarness) waitForNavigation(t *testing.T, userID int64) {
+	t.Helper()
+
+	deadline := time.Now().Add(fakeAPIWaitTimeout)
+	for {
+		session, err := h.db.GetUserSession(userID)
+		if err == nil && session.LastMessageID != 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("первая точка не сохранена (ошибка %v)", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// createCache проходит мастер создания тайника от имени администратора
+func (h *e2eHarness) createCache(t *testing.T, codeWord, photoID string) {
+	t.Helper()
+
+	h.sendText(e2eAdminID, "/create")
+	h.expect(t, "sendMessage", e2eAdminID, "кодовое слово")
+
+	h.sendText(e2eAdminID, codeWord)
+	h.expect(t, "sendMessage", e2eAdminID, "отправьте геолокацию")
+
+	h.sendLocation(e2eAdminID, e2eCacheLat, e2eCacheLon, 0)
+	h.expect(t, "sendMessage", e2eAdminID, "фотографию, видео или видео-заметку")
+
+	h.sendPhoto(e2eAdminID, photoID)
+	h.expect(t, "sendMessage", e2eAdminID, "Тайник успешно создан")
+	h.expect(t, "sendMessage", e2eAdminID, "радиус обнаружения")
+
+	// Необязательные параметры пропускаем
+	h.sendText(e2eAdminID, "-")
+	h.expect(t, "sendMessage", e2eAdminID, "сложность поиска")
+	h.sendText(e2eAdminID, "-")
+	h.expect(t, "sendMessage", e2eAdminID, "сложность местности")
+	h.sendText(e2eAdminID, "-")
+	h.expect(t, "sendMessage", e2eAdminID, "режим навигации")
+	h.sendText(e2eAdminID, "-")
+	h.expect(t, "sendMessage", e2eAdminID, "Показывать тайник")
+	h.sendText(e2eAdminID, "-")
+	h.expect(t, "sendMessage", e2eAdminID, "описание тайника")
+	h.sendText(e2eAdminID, "-")
+	h.expect(t, "sendMessage", e2eAdminID, "Выберите тип вопроса")
+
+	h.sendText(e2eAdminID, translate(LangRu, puzzleButtonNone))
+	h.expect(t, "sendMessage", e2eAdminID, "без вопроса")
+}
+
+func TestE2EAdminCreatesCacheAndPlayerFindsIt(t *testing.T) {
+	h := newE2EHarness(t)
+
+	h.createCache(t, "старый дуб", "PHOTO_FILE_ID")
+
+	cache, err := h.db.GetCacheByCodeWord("старый дуб")
+	if err != nil {
+		t.Fatalf("тайник не сохранен: %v", err)
+	}
+	if cache.Latitude != e2eCacheLat || cache.FileID != "PHOTO_FILE_ID" || cache.FileType != "photo" {
+		t.Fatalf("тайник сохранен неверно: %+v", cache)
+	}
+
+	// Игрок находит тайник по кодовому слову
+	h.sendText(e2ePlayerID, "старый дуб")
+	h.expect(t, "sendMessage", e2ePlayerID, "Тайник найден")
+
+	// Статичная геопозиция не подходит для навигации
+	h.sendLocation(e2ePlayerID, 55.7658, 37.6173, 0)
+	h.expect(t, "sendMessage", e2ePlayerID, "статичная геопозиция")
+
+	// Трансляция примерно в километре к северу от тайника
+	liveID := h.sendLocation(e2ePlayerID, 55.7658, 37.6173, 3600)
+	navigation := h.expect(t, "sendMessage", e2ePlayerID, "Направление к тайнику")
+
+	// Игрок приближается: навигационное сообщение правится, а не отправляется заново
+	h.moveTo(e2ePlayerID, liveID, 55.7600, 37.6173)
+	edit := h.expect(t, "editMessageText", e2ePlayerID, "Направление к тайнику")
+	if edit.Params.Get("message_id") == "" || edit.Text() == navigation.Text() {
+		t.Fatalf("навигация не обновилась: было %q, стало %q", navigation.Text(), edit.Text())
+	}
+
+	// Игрок на месте
+	h.moveTo(e2ePlayerID, liveID, 55.75582, 37.61732)
+	h.expect(t, "sendMessage", e2ePlayerID, "Вы нашли тайник: старый дуб")
+	photo := h.expect(t, "sendPhoto", e2ePlayerID, "Поиск завершен")
+	if photo.Params.Get("photo") != "PHOTO_FILE_ID" {
+		t.Fatalf("отправлен не тот медиафайл: %q", photo.Params.Get("photo"))
+	}
+
+	// Находка записана, поиск завершен
+	finds, err := h.db.CountUserFinds(e2ePlayerID)
+	if err != nil || finds != 1 {
+		t.Fatalf("находок %d (ошибка %v), ожидалась 1", finds, err)
+	}
+	cache, err = h.db.GetCacheByID(cache.ID)
+	if err != nil || cache.FindCount != 1 {
+		t.Fatalf("счетчик находок тайника не увеличен: %+v (ошибка %v)", cache, err)
+	}
+	if _, err := h.db.GetUserSession(e2ePlayerID); err != sql.ErrNoRows {
+		t.Fatalf("сессия поиска осталась активной (ошибка %v)", err)
+	}
+}
+
+func TestE2ETrailStagesAreNotInCatalog(t *testing.T) {
+	h := newE2EHarness(t)
+
+	trail := &Trail{CodeWord: "маршрут", CreatedBy: e2eAdminID, IsPublished: true}
+	stage := &Cache{CodeWord: "маршрут#1", Latitude: e2eCacheLat, Longitude: e2eCacheLon, FileID: "PHOTO", FileType: "photo"}
+	if err := h.db.CreateTrail(trail); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.db.CreateCache(stage); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.db.AddTrailStage(&TrailStage{TrailID: trail.ID, Position: 1, CacheID: stage.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Этап не показывается в каталоге и не удаляется отдельно от маршрута
+	h.sendText(e2eAdminID, "/list")
+	h.expect(t, "sendMessage", e2eAdminID, "Тайников пока нет")
+
+	h.sendText(e2eAdminID, "/delete маршрут#1")
+	h.expect(t, "sendMessage", e2eAdminID, "этап маршрута")
+	h.pressButton(e2eAdminID, fmt.Sprintf("delok:%d", stage.ID))
+	h.expect(t, "sendMessage", e2eAdminID, "этап маршрута")
+	h.pressButton(e2eAdminID, fmt.Sprintf("edit:%d:code", stage.ID))
+	h.expect(t, "sendMessage", e2eAdminID, "этап маршрута")
+
+	if _, err := h.db.GetTrailStageByCacheID(stage.ID); err != nil {
+		t.Fatalf("этап маршрута удален: %v", err)
+	}
+}
+
+func TestE2EUnknownCodeWord(t *testing.T) {
+	h := newE2EHarness(t)
+
+	h.sendText(e2ePlayerID, "/start")
+	h.expect(t, "sendMessage", e2ePlayerID, "Введите кодовое слово")
+
+	h.sendText(e2ePlayerID, "несуществующий")
+	h.expect(t, "sendMessage", e2ePlayerID, "не найден")
+}
+
+func TestE2ESuspiciousFindIsRefused(t *testing.T) {
+	h := newE2EHarness(t)
+	h.bot.Config.BlockSuspiciousFinds = true
+
+	h.createCache(t, "старый дуб", "PHOTO_FILE_ID")
+
+	// Трансляция начата прямо у тайника - типичный признак подделки
+	h.sendText(e2ePlayerID, "старый дуб")
+	h.expect(t, "sendMessage", e2ePlayerID, "Тайник найден")
+	h.sendLocation(e2ePlayerID, 55.75582, 37.61732, 3600)
+	h.expect(t, "sendMessage", e2ePlayerID, "Находка не засчитана")
+
+	if finds, err := h.db.CountUserFinds(e2ePlayerID); err != nil || finds != 0 {
+		t.Fatalf("находок %d (ошибка %v), ожидалось 0", finds, err)
+	}
+	if _, err := h.db.GetUserSession(e2ePlayerID); err != sql.ErrNoRows {
+		t.Fatalf("сессия поиска осталась активной (ошибка %v)", err)
+	}
+
+	// Администратор видит событие в отчете
+	h.sendText(e2eAdminID, "/suspicious")
+	h.expect(t, "sendMessage", e2eAdminID, "Поиск начат в")
+	h.sendText(e2eAdminID, "/suspicious abc")
+	h.expect(t, "sendMessage", e2eAdminID, "числовой ID игрока")
+}
+
+func TestE2EDiscardedFixesAreNotJumps(t *testing.T) {
+	h := newE2EHarness(t)
+	h.bot.Config.MaxPlausibleSpeedKmh = 150
+	h.bot.Tracker.MaxAccuracy = 50
+
+	h.createCache(t, "старый дуб", "PHOTO_FILE_ID")
+	h.sendText(e2ePlayerID, "старый дуб")
+	h.expect(t, "sendMessage", e2ePlayerID, "Тайник найден")
+
+	liveID := h.sendLocation(e2ePlayerID, 55.7658, 37.6173, 3600)
+	h.expect(t, "sendMessage", e2ePlayerID, "Направление к тайнику")
+
+	// Ждем, пока первая точка сохранится, и отодвигаем ее на 10 минут назад
+	h.waitForNavigation(t, e2ePlayerID)
+	fixAt := time.Now().Add(-10 * time.Minute)
+	if _, err := h.db.exec(`UPDATE user_sessions SET last_fix_at = ? WHERE user_id = ?`, fixAt, e2ePlayerID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Неточные точки далеко от игрока отбрасываются: не сохраняются и скачком не считаются
+	for i := 0; i < 3; i++ {
+		message := h.newMessage(e2ePlayerID)
+		message.MessageID = liveID
+		message.EditDate = int(time.Now().Unix())
+		message.Location = &tgbotapi.Location{Latitude: 55.8000, Longitude: 37.6173, LivePeriod: 3600, HorizontalAccuracy: 500}
+		h.api.PushUpdate(tgbotapi.Update{EditedMessage: message})
+	}
+
+	// 640 м за 10 минут от последней сохраненной точки - обычная прогулка
+	h.moveTo(e2ePlayerID, liveID, 55.7600, 37.6173)
+	h.expect(t, "editMessageText", e2ePlayerID, "Направление к тайнику")
+
+	if events, err := h.db.GetSuspicionEvents(e2ePlayerID, 10); err != nil || len(events) != 0 {
+		t.Fatalf("после отброшенных точек записаны подозрительные события: %+v (ошибка %v)", events, err)
+	}
+}
+
+func TestE2EHotColdNavigation(t *testing.T) {
+	h := newE2EHarness(t)
+
+	h.createCache(t, "старый дуб", "PHOTO_FILE_ID")
+	cache, err := h.db.GetCacheByCodeWord("старый дуб")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.NavigationMode = NavigationHotCold
+	if err := h.db.UpdateCache(cache); err != nil {
+		t.Fatal(err)
+	}
+
+	h.sendText(e2ePlayerID, "старый дуб")
+	h.expect(t, "sendMessage", e2ePlayerID, "Тайник найден")
+
+	// Направление и расстояние не раскрываются - только сравнение с предыдущей точкой
+	liveID := h.sendLocation(e2ePlayerID, 55.7658, 37.6173, 3600)
+	start := h.expect(t, "sendMessage", e2ePlayerID, "Поиск начался")
+	if strings.Contains(start.Text(), "Направление") {
+		t.Fatalf("в режиме «горячо-холодно» раскрыто направление:\n%s", start.Text())
+	}
+
+	h.moveTo(e2ePlayerID, liveID, 55.7600, 37.6173)
+	h.expect(t, "editMessageText", e2ePlayerID, "Теплее")
+
+	h.moveTo(e2ePlayerID, liveID, 55.7700, 37.6173)
+	h.expect(t, "editMessageText", e2ePlayerID, "Холоднее")
+
+	h.moveTo(e2ePlayerID, liveID, 55.75582, 37.61732)
+	h.expect(t, "sendMessage", e2ePlayerID, "Вы нашли тайник: старый дуб")
+}
+
+func TestE2ENearby(t *testing.T) {
+	h := newE2EHarness(t)
+
+	h.createCache(t, "старый дуб", "PHOTO_FILE_ID")
+
+	// Непубличный тайник в /nearby не показывается
+	h.sendText(e2ePlayerID, "/nearby")
+	h.expect(t, "sendMessage", e2ePlayerID, "Отправьте свою геолокацию")
+	h.sendLocation(e2ePlayerID, 55.7658, 37.6173, 0)
+	h.expect(t, "sendMessage", e2ePlayerID, "нет публичных тайников")
+
+	cache, err := h.db.GetCacheByCodeWord("старый дуб")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.IsPublic = true
+	if err := h.db.UpdateCache(cache); err != nil {
+		t.Fatal(err)
+	}
+
+	// Публичный тайник примерно в километре к югу
+	h.sendLocation(e2ePlayerID, 55.7658, 37.6173, 0)
+	list := h.expect(t, "sendMessage", e2ePlayerID, "Тайники поблизости")
+	if !strings.Contains(list.Text(), "старый дуб") || !strings.Contains(list.Text(), "Юг") {
+		t.Fatalf("в списке нет тайника или направления:\n%s", list.Text())
+	}
+
+	// Список не подсказывает больше, чем навигация тайника
+	cache.NavigationMode = NavigationDistance
+	if err := h.db.UpdateCache(cache); err != nil {
+		t.Fatal(err)
+	}
+	h.sendLocation(e2ePlayerID, 55.7658, 37.6173, 0)
+	list = h.expect(t, "sendMessage", e2ePlayerID, "Тайники поблизости")
+	if !strings.Contains(list.Text(), "старый дуб") || !strings.Contains(list.Text(), "1.1 км") || strings.Contains(list.Text(), "Юг") {
+		t.Fatalf("в режиме «только расстояние» список должен показывать расстояние без направления:\n%s", list.Text())
+	}
+
+	cache.NavigationMode = NavigationHotCold
+	if err := h.db.UpdateCache(cache); err != nil {
+		t.Fatal(err)
+	}
+	h.sendLocation(e2ePlayerID, 55.7658, 37.6173, 0)
+	list = h.expect(t, "sendMessage", e2ePlayerID, "Тайники поблизости")
+	if !strings.Contains(list.Text(), "где-то в радиусе 5.0 км") || strings.Contains(list.Text(), "Юг") ||
+		strings.Contains(list.Text(), "1.1 км") {
+		t.Fatalf("в режиме «горячо-холодно» список раскрывает расстояние или направление:\n%s", list.Text())
+	}
+
+	// Кнопка начинает поиск, как кодовое слово
+	h.pressButton(e2ePlayerID, fmt.Sprintf("near:%d", cache.ID))
+	h.expect(t, "sendMessage", e2ePlayerID, "Тайник найден")
+
+	h.sendText(e2ePlayerID, "/nearby")
+	h.expect(t, "sendMessage", e2ePlayerID, "Вы уже ищете тайник")
+}
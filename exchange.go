@@ -0,0 +1,753 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Форматы импорта и экспорта тайников
+const (
+	FormatGPX     = "gpx"
+	FormatGeoJSON = "geojson"
+	FormatCSV     = "csv"
+)
+
+var exchangeFormats = []string{FormatGPX, FormatGeoJSON, FormatCSV}
+
+// Максимальный размер файла импорта
+const maxImportFileSize = 5 << 20
+
+// Колонки CSV в порядке выгрузки
+var csvHeader = []string{"code_word", "latitude", "longitude", "file_id", "file_type", "radius_meters", "difficulty", "terrain", "description", "navigation_mode", "is_public"}
+
+// parseFormat нормализует название формата; пустая строка - формат не поддерживается
+func parseFormat(name string) string {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), ".")) {
+	case "gpx":
+		return FormatGPX
+	case "geojson", "json":
+		return FormatGeoJSON
+	case "csv":
+		return FormatCSV
+	default:
+		return ""
+	}
+}
+
+// formatFromFileName определяет формат по расширению файла
+func formatFromFileName(fileName string) string {
+	return parseFormat(filepath.Ext(fileName))
+}
+
+// exportFileName возвращает имя файла выгрузки для формата
+func exportFileName(format string, now time.Time) string {
+	return fmt.Sprintf("caches-%s.%s", now.Format("2006-01-02"), format)
+}
+
+// exportCaches записывает тайники в выбранном формате
+func exportCaches(w io.Writer, format string, caches []*Cache) error {
+	switch format {
+	case FormatGPX:
+		return encodeGPX(w, caches)
+	case FormatGeoJSON:
+		return encodeGeoJSON(w, caches)
+	case FormatCSV:
+		return encodeCSV(w, caches)
+	default:
+		return fmt.Errorf("неизвестный формат: %s", format)
+	}
+}
+
+// ImportRow - разобранная строка (точка, объект) файла импорта
+type ImportRow struct {
+	Ref   ImportRef
+	Cache *Cache
+	Err   error
+}
+
+// ImportRef - номер строки или объекта в исходном файле; переводится при выводе отчета
+type ImportRef struct {
+	Kind   string // importRefPoint, importRefFeature или importRefLine
+	Number int
+}
+
+// Виды номеров в файле импорта; ключ каталога сообщений - "import.ref." + вид
+const (
+	importRefPoint   = "point"   // Точка GPX
+	importRefFeature = "feature" // Объект GeoJSON
+	importRefLine    = "line"    // Строка CSV
+)
+
+// importError - ошибка в файле импорта. Текст хранится ключом каталога сообщений с аргументами,
+// чтобы отчет был на языке администратора; Error() возвращает русский текст для логов.
+type importError struct {
+	key  string
+	args []interface{}
+}
+
+func newImportError(key string, args ...interface{}) error {
+	return &importError{key: "import.error." + key, args: args}
+}
+
+func (e *importError) Error() string {
+	return translate(LangRu, e.key, e.args...)
+}
+
+// importErrorText возвращает текст ошибки импорта на языке lang
+func importErrorText(lang string, err error) string {
+	var importErr *importError
+	if errors.As(err, &importErr) {
+		return translate(lang, importErr.key, importErr.args...)
+	}
+	return err.Error()
+}
+
+// parseCacheImport разбирает файл импорта; ошибки отдельных строк возвращаются в ImportRow.Err
+func parseCacheImport(format string, data []byte) ([]*ImportRow, error) {
+	switch format {
+	case FormatGPX:
+		return decodeGPX(data)
+	case FormatGeoJSON:
+		return decodeGeoJSON(data)
+	case FormatCSV:
+		return decodeCSV(data)
+	default:
+		return nil, fmt.Errorf("неизвестный формат: %s", format)
+	}
+}
+
+// validateImportedCache проверяет поля тайника из файла импорта
+func validateImportedCache(cache *Cache) error {
+	cache.CodeWord = strings.TrimSpace(cache.CodeWord)
+	if len(cache.CodeWord) < 3 {
+		return newImportError("code_word_short")
+	}
+	if math.IsNaN(cache.Latitude) || cache.Latitude < -90 || cache.Latitude > 90 {
+		return newImportError("latitude", cache.Latitude)
+	}
+	if math.IsNaN(cache.Longitude) || cache.Longitude < -180 || cache.Longitude > 180 {
+		return newImportError("longitude", cache.Longitude)
+	}
+	if cache.Latitude == 0 && cache.Longitude == 0 {
+		return newImportError("no_coordinates")
+	}
+
+	switch cache.FileType {
+	case "":
+		cache.FileType = "photo"
+	case "photo", "video", "video_note":
+	default:
+		return newImportError("file_type", cache.FileType)
+	}
+
+	if cache.RadiusMeters != 0 && (cache.RadiusMeters < minCacheRadius || cache.RadiusMeters > maxCacheRadius) {
+		return newImportError("radius", minCacheRadius, maxCacheRadius)
+	}
+	if cache.Difficulty < 0 || cache.Difficulty > 5 || cache.Terrain < 0 || cache.Terrain > 5 {
+		return newImportError("difficulty")
+	}
+	if cache.NavigationMode != "" && !isNavigationMode(cache.NavigationMode) {
+		return newImportError("navigation_mode", cache.NavigationMode)
+	}
+
+	return nil
+}
+
+// GPX 1.1
+
+type gpxFile struct {
+	XMLName   xml.Name      `xml:"http://www.topografix.com/GPX/1/1 gpx"`
+	Version   string        `xml:"version,attr"`
+	Creator   string        `xml:"creator,attr"`
+	Waypoints []gpxWaypoint `xml:"wpt"`
+}
+
+type gpxWaypoint struct {
+	Lat        float64        `xml:"lat,attr"`
+	Lon        float64        `xml:"lon,attr"`
+	Time       string         `xml:"time,omitempty"`
+	Name       string         `xml:"name"`
+	Desc       string         `xml:"desc,omitempty"`
+	Type       string         `xml:"type,omitempty"`
+	Extensions *gpxExtensions `xml:"extensions,omitempty"`
+}
+
+// gpxImportFile - корень GPX при импорте: без привязки к пространству имен,
+// чтобы принимать и GPX 1.0, и файлы без xmlns
+type gpxImportFile struct {
+	XMLName   xml.Name      `xml:"gpx"`
+	Version   string        `xml:"version,attr"`
+	Waypoints []gpxWaypoint `xml:"wpt"`
+}
+
+// gpxExtensions - поля тайника, которых нет в стандарте GPX, в собственном пространстве имен
+type gpxExtensions struct {
+	FileID       string  `xml:"https://github.com/memrook/GeoCachingBot file_id,omitempty"`
+	FileType     string  `xml:"https://github.com/memrook/GeoCachingBot file_type,omitempty"`
+	RadiusMeters float64 `xml:"https://github.com/memrook/GeoCachingBot radius_meters,omitempty"`
+	Difficulty   int     `xml:"https://github.com/memrook/GeoCachingBot difficulty,omitempty"`
+	Terrain      int     `xml:"https://github.com/memrook/GeoCachingBot terrain,omitempty"`
+	Navigation   string  `xml:"https://github.com/memrook/GeoCachingBot navigation_mode,omitempty"`
+	Public       bool    `xml:"https://github.com/memrook/GeoCachingBot is_public,omitempty"`
+}
+
+func encodeGPX(w io.Writer, caches []*Cache) error {
+	file := gpxFile{Version: "1.1", Creator: "GeoCachingBot"}
+	for _, cache := range caches {
+		waypoint := gpxWaypoint{
+			Lat:  cache.Latitude,
+			Lon:  cache.Longitude,
+			Name: cache.CodeWord,
+			Desc: cache.Description,
+			Type: "Geocache",
+			Extensions: &gpxExtensions{
+				FileID:       cache.FileID,
+				FileType:     cache.FileType,
+				RadiusMeters: cache.RadiusMeters,
+				Difficulty:   cache.Difficulty,
+				Terrain:      cache.Terrain,
+				Navigation:   cache.NavigationMode,
+				Public:       cache.IsPublic,
+			},
+		}
+		if !cache.CreatedAt.IsZero() {
+			waypoint.Time = cache.CreatedAt.UTC().Format(time.RFC3339)
+		}
+		file.Waypoints = append(file.Waypoints, waypoint)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func decodeGPX(data []byte) ([]*ImportRow, error) {
+	var file gpxImportFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return nil, newImportError("invalid_gpx", err)
+	}
+	switch file.Version {
+	case "", "1.0", "1.1":
+	default:
+		return nil, newImportError("gpx_version", file.Version)
+	}
+
+	var rows []*ImportRow
+	for i, waypoint := range file.Waypoints {
+		cache := &Cache{
+			CodeWord:    waypoint.Name,
+			Latitude:    waypoint.Lat,
+			Longitude:   waypoint.Lon,
+			Description: strings.TrimSpace(waypoint.Desc),
+		}
+		if ext := waypoint.Extensions; ext != nil {
+			cache.FileID = strings.TrimSpace(ext.FileID)
+			cache.FileType = strings.TrimSpace(ext.FileType)
+			cache.RadiusMeters = ext.RadiusMeters
+			cache.Difficulty = ext.Difficulty
+			cache.Terrain = ext.Terrain
+			cache.NavigationMode = strings.TrimSpace(ext.Navigation)
+			cache.IsPublic = ext.Public
+		}
+		rows = append(rows, &ImportRow{Ref: ImportRef{importRefPoint, i + 1}, Cache: cache})
+	}
+
+	return rows, nil
+}
+
+// GeoJSON
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	Geometry   geoJSONGeometry   `json:"geometry"`
+	Properties geoJSONProperties `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"` // [долгота, широта]
+}
+
+type geoJSONProperties struct {
+	CodeWord     string  `json:"code_word"`
+	FileID       string  `json:"file_id,omitempty"`
+	FileType     string  `json:"file_type,omitempty"`
+	RadiusMeters float64 `json:"radius_meters,omitempty"`
+	Difficulty   int     `json:"difficulty,omitempty"`
+	Terrain      int     `json:"terrain,omitempty"`
+	Description  string  `json:"description,omitempty"`
+	Navigation   string  `json:"navigation_mode,omitempty"`
+	Public       bool    `json:"is_public,omitempty"`
+	CreatedAt    string  `json:"created_at,omitempty"`
+}
+
+func encodeGeoJSON(w io.Writer, caches []*Cache) error {
+	collection := geoJSONCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	for _, cache := range caches {
+		feature := geoJSONFeature{
+			Type: "Feature",
+			Geometry: geoJSONGeometry{
+				Type:        "Point",
+				Coordinates: []float64{cache.Longitude, cache.Latitude},
+			},
+			Properties: geoJSONProperties{
+				CodeWord:     cache.CodeWord,
+				FileID:       cache.FileID,
+				FileType:     cache.FileType,
+				RadiusMeters: cache.RadiusMeters,
+				Difficulty:   cache.Difficulty,
+				Terrain:      cache.Terrain,
+				Description:  cache.Description,
+				Navigation:   cache.NavigationMode,
+				Public:       cache.IsPublic,
+			},
+		}
+		if !cache.CreatedAt.IsZero() {
+			feature.Properties.CreatedAt = cache.CreatedAt.UTC().Format(time.RFC3339)
+		}
+		collection.Features = append(collection.Features, feature)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(collection)
+}
+
+func decodeGeoJSON(data []byte) ([]*ImportRow, error) {
+	var collection geoJSONCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, newImportError("invalid_geojson", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, newImportError("not_feature_collection")
+	}
+
+	var rows []*ImportRow
+	for i, feature := range collection.Features {
+		row := &ImportRow{Ref: ImportRef{importRefFeature, i + 1}}
+		rows = append(rows, row)
+
+		if feature.Geometry.Type != "Point" || len(feature.Geometry.Coordinates) < 2 {
+			row.Err = newImportError("not_point")
+			continue
+		}
+
+		props := feature.Properties
+		row.Cache = &Cache{
+			CodeWord:       props.CodeWord,
+			Latitude:       feature.Geometry.Coordinates[1],
+			Longitude:      feature.Geometry.Coordinates[0],
+			FileID:         strings.TrimSpace(props.FileID),
+			FileType:       strings.TrimSpace(props.FileType),
+			RadiusMeters:   props.RadiusMeters,
+			Difficulty:     props.Difficulty,
+			Terrain:        props.Terrain,
+			Description:    strings.TrimSpace(props.Description),
+			NavigationMode: strings.TrimSpace(props.Navigation),
+			IsPublic:       props.Public,
+		}
+	}
+
+	return rows, nil
+}
+
+// CSV
+
+func encodeCSV(w io.Writer, caches []*Cache) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, cache := range caches {
+		record := []string{
+			cache.CodeWord,
+			strconv.FormatFloat(cache.Latitude, 'f', -1, 64),
+			strconv.FormatFloat(cache.Longitude, 'f', -1, 64),
+			cache.FileID,
+			cache.FileType,
+			strconv.FormatFloat(cache.RadiusMeters, 'f', -1, 64),
+			strconv.Itoa(cache.Difficulty),
+			strconv.Itoa(cache.Terrain),
+			cache.Description,
+			cache.NavigationMode,
+			strconv.FormatBool(cache.IsPublic),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func decodeCSV(data []byte) ([]*ImportRow, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, newImportError("invalid_csv", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"code_word", "latitude", "longitude"} {
+		if _, ok := columns[required]; !ok {
+			return nil, newImportError("missing_column", required)
+		}
+	}
+
+	var rows []*ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &ImportRow{Ref: ImportRef{importRefLine, line}}
+		rows = append(rows, row)
+
+		if err != nil {
+			row.Err = newImportError("invalid_record", err)
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		cache := &Cache{
+			CodeWord:       field("code_word"),
+			FileID:         field("file_id"),
+			FileType:       field("file_type"),
+			Description:    field("description"),
+			NavigationMode: field("navigation_mode"),
+		}
+		if cache.Latitude, err = parseNumber(field("latitude")); err != nil {
+			row.Err = newImportError("latitude_value", field("latitude"))
+			continue
+		}
+		if cache.Longitude, err = parseNumber(field("longitude")); err != nil {
+			row.Err = newImportError("longitude_value", field("longitude"))
+			continue
+		}
+		if value := field("radius_meters"); value != "" {
+			if cache.RadiusMeters, err = parseNumber(value); err != nil {
+				row.Err = newImportError("radius_value", value)
+				continue
+			}
+		}
+		if value := field("difficulty"); value != "" {
+			if cache.Difficulty, err = strconv.Atoi(value); err != nil {
+				row.Err = newImportError("difficulty_value", value)
+				continue
+			}
+		}
+		if value := field("terrain"); value != "" {
+			if cache.Terrain, err = strconv.Atoi(value); err != nil {
+				row.Err = newImportError("terrain_value", value)
+				continue
+			}
+		}
+		if value := field("is_public"); value != "" {
+			if cache.IsPublic, err = strconv.ParseBool(value); err != nil {
+				row.Err = newImportError("public_value", value)
+				continue
+			}
+		}
+
+		row.Cache = cache
+	}
+
+	return rows, nil
+}
+
+// ImportResult - итог импорта одной строки
+type ImportResult struct {
+	Ref      ImportRef
+	CodeWord string
+	Err      error
+}
+
+// importCaches проверяет и сохраняет разобранные тайники.
+// Кодовые слова проверяются на совпадение с существующими тайниками, маршрутами и другими строками файла.
+func (b *Bot) importCaches(rows []*ImportRow, createdBy int64) []ImportResult {
+	seen := make(map[string]bool)
+	results := make([]ImportResult, 0, len(rows))
+
+	for _, row := range rows {
+		result := ImportResult{Ref: row.Ref, Err: row.Err}
+		if row.Cache != nil {
+			result.CodeWord = strings.TrimSpace(row.Cache.CodeWord)
+		}
+
+		if result.Err == nil {
+			result.Err = validateImportedCache(row.Cache)
+		}
+		if result.Err == nil && (seen[row.Cache.CodeWord] || b.isCodeWordTaken(row.Cache.CodeWord)) {
+			result.Err = newImportError("duplicate")
+		}
+		if result.Err == nil {
+			row.Cache.CreatedBy = createdBy
+			if err := b.DB.CreateCache(row.Cache); err != nil {
+				slog.Error("Ошибка сохранения импортированного тайника", "code_word", row.Cache.CodeWord, "error", err)
+				result.Err = newImportError("save_failed")
+			}
+		}
+		if row.Cache != nil && result.Err == nil {
+			seen[row.Cache.CodeWord] = true
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// Обработчик команды /export [gpx|geojson|csv]
+func (b *Bot) handleExportCommand(userID int64, args string) {
+	if format := parseFormat(args); format != "" {
+		b.sendExport(userID, format)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.text(userID, "export.choose_format"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("GPX", "export:"+FormatGPX),
+			tgbotapi.NewInlineKeyboardButtonData("GeoJSON", "export:"+FormatGeoJSON),
+			tgbotapi.NewInlineKeyboardButtonData("CSV", "export:"+FormatCSV),
+		),
+	)
+	b.Outbox.Send(msg)
+}
+
+// sendExport выгружает все тайники (кроме этапов маршрутов) и отправляет файл администратору
+func (b *Bot) sendExport(userID int64, format string) {
+	caches, err := b.DB.ExportableCaches()
+	if err != nil {
+		slog.Error("Ошибка получения тайников для выгрузки", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+	if len(caches) == 0 {
+		b.reply(userID, "export.empty")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := exportCaches(&buf, format, caches); err != nil {
+		slog.Error("Ошибка выгрузки тайников", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	doc := tgbotapi.NewDocument(userID, tgbotapi.FileBytes{
+		Name:  exportFileName(format, time.Now()),
+		Bytes: buf.Bytes(),
+	})
+	lang := b.userLanguage(userID)
+	doc.Caption = translate(lang, "export.caption", translatePlural(lang, "count.caches", len(caches)))
+	if _, err := b.Outbox.Send(doc); err != nil {
+		slog.Error("Ошибка отправки файла выгрузки", "user_id", userID, "error", err)
+		b.reply(userID, "export.send_failed")
+	}
+}
+
+// Обработчик команды /import
+func (b *Bot) handleImportCommand(userID int64) {
+	session := &AdminSession{
+		UserID: userID,
+		Step:   "waiting_import",
+	}
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка создания админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	b.reply(userID, "import.prompt")
+}
+
+// Обработчик загруженного файла импорта
+func (b *Bot) handleImportDocument(userID int64, message *tgbotapi.Message) {
+	if message.Document == nil {
+		b.reply(userID, "import.document_required")
+		return
+	}
+
+	format := formatFromFileName(message.Document.FileName)
+	if format == "" {
+		b.reply(userID, "import.unsupported_format")
+		return
+	}
+	if message.Document.FileSize > maxImportFileSize {
+		b.reply(userID, "import.too_large", maxImportFileSize>>20)
+		return
+	}
+
+	data, err := b.downloadFile(message.Document.FileID, maxImportFileSize)
+	if err != nil {
+		slog.Error("Ошибка загрузки файла импорта", "user_id", userID, "error", err)
+		b.reply(userID, "import.download_failed")
+		return
+	}
+
+	rows, err := parseCacheImport(format, data)
+	if err != nil {
+		b.reply(userID, "import.parse_failed", importErrorText(b.userLanguage(userID), err))
+		return
+	}
+	if len(rows) == 0 {
+		b.reply(userID, "import.no_rows")
+		return
+	}
+
+	results := b.importCaches(rows, userID)
+	b.DB.DeleteAdminSession(userID)
+	b.sendMessage(userID, formatImportReport(b.userLanguage(userID), results))
+}
+
+// downloadFile скачивает файл из Telegram, ограничивая его размер
+func (b *Bot) downloadFile(fileID string, limit int64) ([]byte, error) {
+	url, err := b.API.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("неожиданный статус ответа: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errors.New("файл превышает допустимый размер")
+	}
+	return data, nil
+}
+
+// formatImportReport формирует построчный отчет об импорте, укладываясь в лимит длины сообщения
+func formatImportReport(lang string, results []ImportResult) string {
+	imported := 0
+	for _, result := range results {
+		if result.Err == nil {
+			imported++
+		}
+	}
+
+	var text strings.Builder
+	text.WriteString(translate(lang, "import.report", imported, len(results)))
+
+	const limit = 3800
+	for i, result := range results {
+		var line string
+		ref := translate(lang, "import.ref."+result.Ref.Kind, result.Ref.Number)
+		if result.Err == nil {
+			line = fmt.Sprintf("✅ %s: %s\n", ref, result.CodeWord)
+		} else if result.CodeWord != "" {
+			line = fmt.Sprintf("❌ %s: %s - %s\n", ref, result.CodeWord, importErrorText(lang, result.Err))
+		} else {
+			line = fmt.Sprintf("❌ %s: %s\n", ref, importErrorText(lang, result.Err))
+		}
+
+		if text.Len()+len(line) > limit {
+			text.WriteString(translatePlural(lang, "import.more_rows", len(results)-i))
+			break
+		}
+		text.WriteString(line)
+	}
+
+	if imported > 0 {
+		text.WriteString(translate(lang, "import.list_hint"))
+	}
+	return text.String()
+}
+
+// runExportCommand выгружает тайники из командной строки:
+// geocaching-bot export [-format gpx|geojson|csv] [-o файл]
+func runExportCommand(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	formatName := flags.String("format", FormatGPX, "формат выгрузки: gpx, geojson или csv")
+	output := flags.String("o", "", "файл для записи (по умолчанию - стандартный вывод)")
+	flags.Parse(args)
+
+	format := parseFormat(*formatName)
+	if format == "" {
+		return fmt.Errorf("неизвестный формат: %s", *formatName)
+	}
+
+	db, err := OpenStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	caches, err := db.ExportableCaches()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := exportCaches(w, format, caches); err != nil {
+		return err
+	}
+
+	slog.Info("Тайники выгружены", "count", len(caches), "format", format)
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportReportIsTranslated(t *testing.T) {
+	rows, err := decodeCSV([]byte("code_word,latitude,longitude\nab,55.75,37.61\nдуб,север,37.61\nклен,95,37.61\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []ImportResult
+	for _, row := range rows {
+		result := ImportResult{Ref: row.Ref, Err: row.Err}
+		if result.Err == nil {
+			result.CodeWord = row.Cache.CodeWord
+			result.Err = validateImportedCache(row.Cache)
+		}
+		results = append(results, result)
+	}
+
+	report := formatImportReport(LangEn, results)
+	for _, want := range []string{"line 2: ab - code word is shorter", `line 3: invalid latitude "север"`, "line 4: клен - invalid latitude 95"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("в отчете нет %q:\n%s", want, report)
+		}
+	}
+	if ru := formatImportReport(LangRu, results); !strings.Contains(ru, "строка 4: клен - некорректная широта 95") {
+		t.Errorf("русский отчет:\n%s", ru)
+	}
+
+	// Ошибка разбора всего файла тоже переводится
+	_, err = decodeCSV([]byte("name,lat\n"))
+	if got := importErrorText(LangEn, err); got != "the CSV header has no code_word column" {
+		t.Errorf("ошибка разбора: %q", got)
+	}
+}
+
+func TestDecodeGPXAcceptsOlderAndUnqualifiedFiles(t *testing.T) {
+	files := map[string]string{
+		"GPX 1.1":          `<gpx xmlns="http://www.topografix.com/GPX/1/1" version="1.1"><wpt lat="55.75" lon="37.61"><name>дуб</name></wpt></gpx>`,
+		"GPX 1.0":          `<gpx xmlns="http://www.topografix.com/GPX/1/0" version="1.0"><wpt lat="55.75" lon="37.61"><name>дуб</name></wpt></gpx>`,
+		"без пространства": `<gpx version="1.1"><wpt lat="55.75" lon="37.61"><name>дуб</name></wpt></gpx>`,
+	}
+	for name, data := range files {
+		rows, err := decodeGPX([]byte(data))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(rows) != 1 || rows[0].Cache.CodeWord != "дуб" || rows[0].Cache.Latitude != 55.75 {
+			t.Errorf("%s: точки разобраны неверно: %+v", name, rows)
+		}
+	}
+
+	// Обратная совместимость с собственным экспортом
+	var buf strings.Builder
+	if err := encodeGPX(&buf, []*Cache{{CodeWord: "клен", Latitude: 55.7, Longitude: 37.6, RadiusMeters: 25}}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := decodeGPX([]byte(buf.String()))
+	if err != nil || len(rows) != 1 || rows[0].Cache.RadiusMeters != 25 {
+		t.Fatalf("экспорт не читается обратно: %v %+v", err, rows)
+	}
+
+	_, err = decodeGPX([]byte(`<gpx version="2.0"></gpx>`))
+	if got := importErrorText(LangEn, err); got != `unsupported GPX version "2.0", 1.0 or 1.1 is expected` {
+		t.Errorf("неизвестная версия: %q", got)
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Сколько тест ждет ожидаемого запроса бота к Bot API
+const fakeAPIWaitTimeout = 5 * time.Second
+
+// Сколько getUpdates держит соединение, если новых обновлений нет
+const fakeAPIPollTimeout = 200 * time.Millisecond
+
+// fakeAPIRequest - запрос бота к поддельному Bot API
+type fakeAPIRequest struct {
+	Method string
+	Params url.Values
+}
+
+// ChatID возвращает получателя сообщения
+func (r fakeAPIRequest) ChatID() int64 {
+	id, _ := strconv.ParseInt(r.Params.Get("chat_id"), 10, 64)
+	return id
+}
+
+// Text возвращает текст сообщения или подпись к медиафайлу
+func (r fakeAPIRequest) Text() string {
+	if text := r.Params.Get("text"); text != "" {
+		return text
+	}
+	return r.Params.Get("caption")
+}
+
+// fakeAPIFailure - ошибка, которую поддельный Bot API вернет вместо ответа на запрос
+type fakeAPIFailure struct {
+	Code        int
+	Description string
+	RetryAfter  int // Для 429: сколько секунд Telegram просит подождать
+}
+
+// fakeBotAPI - поддельный сервер Telegram Bot API для сквозных тестов.
+// Отдает обновления, добавленные тестом, через getUpdates и запоминает все исходящие запросы бота.
+type fakeBotAPI struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu            sync.Mutex
+	updates       []tgbotapi.Update
+	nextUpdateID  int
+	nextMessageID int
+	requests      []fakeAPIRequest
+	failures      map[string][]fakeAPIFailure // Ошибки, которые получат следующие запросы метода
+	changed       chan struct{}               // Закрывается и пересоздается при каждом новом обновлении или запросе
+	closed        chan struct{}
+}
+
+func newFakeBotAPI(t *testing.T) *fakeBotAPI {
+	t.Helper()
+
+	f := &fakeBotAPI{
+		t:             t,
+		nextUpdateID:  1,
+		nextMessageID: 1000,
+		failures:      make(map[string][]fakeAPIFailure),
+		changed:       make(chan struct{}),
+		closed:        make(chan struct{}),
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+// Endpoint возвращает шаблон адреса API в формате TELEGRAM_API_ENDPOINT
+func (f *fakeBotAPI) Endpoint() string {
+	return f.server.URL + "/bot%s/%s"
+}
+
+// Close прерывает ожидающие getUpdates и останавливает сервер
+func (f *fakeBotAPI) Close() {
+	f.mu.Lock()
+	select {
+	case <-f.closed:
+		f.mu.Unlock()
+		return
+	default:
+		close(f.closed)
+	}
+	f.mu.Unlock()
+
+	f.server.Close()
+}
+
+// notifyLocked будит всех, кто ждет новых обновлений или запросов; вызывается под f.mu
+func (f *fakeBotAPI) notifyLocked() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// PushUpdate добавляет обновление, которое бот получит следующим вызовом getUpdates
+func (f *fakeBotAPI) PushUpdate(update tgbotapi.Update) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	update.UpdateID = f.nextUpdateID
+	f.nextUpdateID++
+	f.updates = append(f.updates, update)
+	f.notifyLocked()
+}
+
+// FailNext заставляет следующий запрос method завершиться ошибкой failure.
+// Несколько вызовов подряд ставят ошибки в очередь.
+func (f *fakeBotAPI) FailNext(method string, failure fakeAPIFailure) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures[method] = append(f.failures[method], failure)
+}
+
+// Requests возвращает копию всех запросов бота
+func (f *fakeBotAPI) Requests() []fakeAPIRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]fakeAPIRequest(nil), f.requests...)
+}
+
+// WaitFor ждет запрос method, удовлетворяющий match, начиная с запроса номер from.
+// Возвращает найденный запрос и номер, с которого стоит искать следующий.
+func (f *fakeBotAPI) WaitFor(from int, method string, match func(fakeAPIRequest) bool) (fakeAPIRequest, int) {
+	f.t.Helper()
+
+	deadline := time.After(fakeAPIWaitTimeout)
+	for {
+		f.mu.Lock()
+		for i := from; i < len(f.requests); i++ {
+			if request := f.requests[i]; request.Method == method && (match == nil || match(request)) {
+				f.mu.Unlock()
+				return request, i + 1
+			}
+		}
+		changed := f.changed
+		f.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline:
+			f.t.Fatalf("бот не вызвал %s за %v; запросы: %s", method, fakeAPIWaitTimeout, f.describeRequests(from))
+		}
+	}
+}
+
+func (f *fakeBotAPI) describeRequests(from int) string {
+	var lines []string
+	for i, request := range f.Requests() {
+		if i >= from && request.Method != "getUpdates" {
+			lines = append(lines, request.Method+" "+strconv.Quote(request.Text()))
+		}
+	}
+	return "\n" + strings.Join(lines, "\n")
+}
+
+func (f *fakeBotAPI) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	// Путь запроса - /bot<токен>/<метод>
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "bot") {
+		http.NotFound(w, r)
+		return
+	}
+	method := parts[1]
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil && err != http.ErrNotMultipart {
+		f.reply(w, nil, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if method != "getUpdates" {
+		f.mu.Lock()
+		f.requests = append(f.requests, fakeAPIRequest{Method: method, Params: r.Form})
+		f.notifyLocked()
+		var failure *fakeAPIFailure
+		if queue := f.failures[method]; len(queue) > 0 {
+			failure = &queue[0]
+			f.failures[method] = queue[1:]
+		}
+		f.mu.Unlock()
+
+		if failure != nil {
+			f.replyFailure(w, *failure)
+			return
+		}
+	}
+
+	switch method {
+	case "getMe":
+		f.reply(w, tgbotapi.User{ID: 999, IsBot: true, FirstName: "GeoCaching", UserName: "geocaching_test_bot"}, 0, "")
+	case "getUpdates":
+		offset, _ := strconv.Atoi(r.Form.Get("offset"))
+		f.reply(w, f.pollUpdates(r, offset), 0, "")
+	case "sendMessage", "sendPhoto", "sendVideo", "sendVideoNote", "sendDocument":
+		f.reply(w, f.newMessage(r.Form), 0, "")
+	case "editMessageText":
+		messageID, _ := strconv.Atoi(r.Form.Get("message_id"))
+		message := f.newMessage(r.Form)
+		message.MessageID = messageID
+		f.reply(w, message, 0, "")
+	case "deleteWebhook", "setWebhook", "answerCallbackQuery":
+		f.reply(w, true, 0, "")
+	default:
+		f.reply(w, nil, http.StatusNotFound, "Not Found: method not found")
+	}
+}
+
+// pollUpdates возвращает обновления начиная с offset, подождав немного, если их пока нет
+func (f *fakeBotAPI) pollUpdates(r *http.Request, offset int) []tgbotapi.Update {
+	timeout := time.After(fakeAPIPollTimeout)
+	for {
+		f.mu.Lock()
+		var updates []tgbotapi.Update
+		for _, update := range f.updates {
+			if update.UpdateID >= offset {
+				updates = append(updates, update)
+			}
+		}
+		changed := f.changed
+		f.mu.Unlock()
+
+		if len(updates) > 0 {
+			return updates
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+			return []tgbotapi.Update{}
+		case <-f.closed:
+			return []tgbotapi.Update{}
+		case <-r.Context().Done():
+			return []tgbotapi.Update{}
+		}
+	}
+}
+
+// newMessage формирует ответ на отправку сообщения
+func (f *fakeBotAPI) newMessage(params url.Values) *tgbotapi.Message {
+	chatID, _ := strconv.ParseInt(params.Get("chat_id"), 10, 64)
+
+	f.mu.Lock()
+	f.nextMessageID++
+	messageID := f.nextMessageID
+	f.mu.Unlock()
+
+	return &tgbotapi.Message{
+		MessageID: messageID,
+		Date:      int(time.Now().Unix()),
+		Chat:      &tgbotapi.Chat{ID: chatID, Type: "private"},
+		Text:      params.Get("text"),
+		Caption:   params.Get("caption"),
+	}
+}
+
+// replyFailure отвечает ошибкой; для 429 добавляет retry_after, как это делает Telegram
+func (f *fakeBotAPI) replyFailure(w http.ResponseWriter, failure fakeAPIFailure) {
+	response := map[string]interface{}{
+		"ok":          false,
+		"error_code":  failure.Code,
+		"description": failure.Description,
+	}
+	if failure.RetryAfter > 0 {
+		response["parameters"] = map[string]interface{}{"retry_after": failure.RetryAfter}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (f *fakeBotAPI) reply(w http.ResponseWriter, result interface{}, errorCode int, description string) {
+	response := map[string]interface{}{"ok": errorCode == 0}
+	if errorCode == 0 {
+		response["result"] = result
+	} else {
+		response["error_code"] = errorCode
+		response["description"] = description
+	}
+
+	// Ошибку записи не проверяем: бот мог закрыть соединение, не дождавшись ответа getUpdates
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Количество находок на одной странице истории /history
+const historyPageSize = 10
+
+// recordFind записывает находку в журнал по данным текущей сессии пользователя
+func (b *Bot) recordFind(userID int64, cache *Cache) {
+	if err := b.DB.IncrementCacheFindCount(cache.ID); err != nil {
+		slog.Error("Ошибка обновления счетчика находок", "user_id", userID, "cache_id", cache.ID, "error", err)
+	}
+
+	now := time.Now()
+	find := &Find{
+		UserID:  userID,
+		CacheID: cache.ID,
+		FoundAt: now,
+	}
+
+	session, err := b.DB.GetUserSession(userID)
+	if err != nil {
+		slog.Error("Ошибка получения пользовательской сессии", "user_id", userID, "cache_id", cache.ID, "error", err)
+	} else if session.CacheID == cache.ID {
+		find.DurationSeconds = int64(now.Sub(session.StartedAt).Seconds())
+		find.DistanceMeters = session.DistanceWalked
+	}
+
+	if err := b.DB.CreateFind(find); err != nil {
+		slog.Error("Ошибка записи находки", "user_id", userID, "cache_id", cache.ID, "error", err)
+	}
+	findsTotal.Inc()
+}
+
+// Обработчик команды /history
+func (b *Bot) handleHistoryCommand(userID int64) {
+	text, keyboard, err := b.renderHistoryPage(userID, 0)
+	if err != nil {
+		slog.Error("Ошибка получения истории находок", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(userID, text)
+	if len(keyboard.InlineKeyboard) > 0 {
+		msg.ReplyMarkup = keyboard
+	}
+	b.Outbox.Send(msg)
+}
+
+// renderHistoryPage формирует текст и клавиатуру страницы истории находок
+func (b *Bot) renderHistoryPage(userID int64, page int) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	var keyboard tgbotapi.InlineKeyboardMarkup
+	lang := b.userLanguage(userID)
+	units := b.displaySettings(userID).Units
+
+	total, err := b.DB.CountUserFinds(userID)
+	if err != nil {
+		return "", keyboard, err
+	}
+	if total == 0 {
+		return translate(lang, "history.empty"), keyboard, nil
+	}
+
+	pages := (total + historyPageSize - 1) / historyPageSize
+	if page < 0 {
+		page = 0
+	}
+	if page >= pages {
+		page = pages - 1
+	}
+
+	finds, err := b.DB.GetUserFinds(userID, historyPageSize, page*historyPageSize)
+	if err != nil {
+		return "", keyboard, err
+	}
+
+	var text strings.Builder
+	text.WriteString(translate(lang, "history.header", total, page+1, pages))
+	for i, find := range finds {
+		codeWord := find.CodeWord
+		if codeWord == "" {
+			codeWord = translate(lang, "history.cache_deleted")
+		}
+		fmt.Fprintf(&text, "%d. 🔑 %s\n   📅 %s - ⏱ %s - 👣 %s\n",
+			page*historyPageSize+i+1, codeWord, find.FoundAt.Format(translate(lang, "format.datetime")),
+			formatDuration(lang, time.Duration(find.DurationSeconds)*time.Second), formatDistance(lang, units, find.DistanceMeters))
+
+		if find.FileID != "" {
+			keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🖼 "+codeWord, fmt.Sprintf("find:%d", find.ID)),
+			))
+		}
+	}
+	if len(keyboard.InlineKeyboard) > 0 {
+		text.WriteString(translate(lang, "history.media_hint"))
+	}
+
+	if pages > 1 {
+		var navigation []tgbotapi.InlineKeyboardButton
+		if page > 0 {
+			navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData(translate(lang, "button.prev"), fmt.Sprintf("hist:%d", page-1)))
+		}
+		if page < pages-1 {
+			navigation = append(navigation, tgbotapi.NewInlineKeyboardButtonData(translate(lang, "button.next"), fmt.Sprintf("hist:%d", page+1)))
+		}
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, navigation)
+	}
+
+	return text.String(), keyboard, nil
+}
+
+// Обработчик кнопок навигации по истории находок
+func (b *Bot) handleHistoryPageCallback(query *tgbotapi.CallbackQuery, arg string) {
+	if query.Message == nil {
+		return
+	}
+
+	page, _ := strconv.Atoi(arg)
+	text, keyboard, err := b.renderHistoryPage(query.From.ID, page)
+	if err != nil {
+		slog.Error("Ошибка получения истории находок", "user_id", query.From.ID, "error", err)
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID, text, keyboard)
+	if _, err := b.Outbox.Send(edit); err != nil {
+		slog.Error("Ошибка обновления истории находок", "user_id", query.From.ID, "error", err)
+	}
+}
+
+// Обработчик повторного просмотра медиафайла найденного тайника
+func (b *Bot) handleFindMediaCallback(userID int64, arg string) {
+	findID, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return
+	}
+
+	find, err := b.DB.GetFindByID(findID)
+	if err != nil || find.UserID != userID {
+		return
+	}
+	if find.FileID == "" {
+		b.reply(userID, "history.media_deleted")
+		return
+	}
+
+	lang := b.userLanguage(userID)
+	caption := translate(lang, "history.media_caption", find.CodeWord, find.FoundAt.Format(translate(lang, "format.datetime")))
+	if err := b.sendMedia(userID, find.FileID, find.FileType, caption); err != nil {
+		slog.Error("Ошибка отправки медиафайла", "user_id", userID, "error", err)
+		b.sendMessage(userID, translate(lang, "media.send_failed"))
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordFindTakesStatsFromSession(t *testing.T) {
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "finds.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	b := &Bot{DB: db}
+
+	cache := &Cache{CodeWord: "дуб", Latitude: 55.75, Longitude: 37.61}
+	other := &Cache{CodeWord: "клен", Latitude: 55.76, Longitude: 37.62}
+	for _, c := range []*Cache{cache, other} {
+		if err := db.CreateCache(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := db.CreateOrUpdateUserSession(&UserSession{UserID: 7, CacheID: cache.ID, IsActive: true,
+		StartedAt: time.Now().Add(-10 * time.Minute), DistanceWalked: 750}); err != nil {
+		t.Fatal(err)
+	}
+	b.recordFind(7, cache)
+	// Находка чужого тайника (например, этапа, открытого без поиска) не берет данные текущей сессии
+	b.recordFind(7, other)
+
+	finds, err := db.GetUserFinds(7, 10, 0)
+	if err != nil || len(finds) != 2 {
+		t.Fatalf("записано находок: %d (ошибка %v)", len(finds), err)
+	}
+	byCode := map[string]*Find{finds[0].CodeWord: finds[0], finds[1].CodeWord: finds[1]}
+	if find := byCode["дуб"]; find == nil || find.DurationSeconds < 590 || find.DurationSeconds > 610 || find.DistanceMeters != 750 {
+		t.Errorf("длительность и путь не взяты из сессии: %+v", find)
+	}
+	if find := byCode["клен"]; find == nil || find.DurationSeconds != 0 || find.DistanceMeters != 0 {
+		t.Errorf("находке другого тайника приписаны данные сессии: %+v", find)
+	}
+	if saved, err := db.GetCacheByID(cache.ID); err != nil || saved.FindCount != 1 {
+		t.Errorf("счетчик находок не увеличен: %+v (ошибка %v)", saved, err)
+	}
+}
+
+func TestE2EHistoryPagination(t *testing.T) {
+	h := newE2EHarness(t)
+
+	cache := &Cache{CodeWord: "старый дуб", Latitude: e2eCacheLat, Longitude: e2eCacheLon, FileID: "photo-1", FileType: "photo"}
+	if err := h.db.CreateCache(cache); err != nil {
+		t.Fatal(err)
+	}
+	// 12 находок: одна страница - 10 записей, самые свежие сверху
+	now := time.Now()
+	for i := 0; i < historyPageSize+2; i++ {
+		find := &Find{UserID: e2ePlayerID, CacheID: cache.ID, FoundAt: now.Add(-time.Duration(i) * time.Hour)}
+		if i == historyPageSize+1 {
+			find.CacheID = cache.ID + 100 // Тайник этой находки удален
+		}
+		if err := h.db.CreateFind(find); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h.sendText(e2ePlayerID, "/history")
+	request := h.expect(t, "sendMessage", e2ePlayerID, "Ваши находки (12), страница 1 из 2")
+	if !strings.Contains(request.Text(), "10. 🔑 старый дуб") || strings.Contains(request.Text(), "11.") {
+		t.Errorf("первая страница:\n%s", request.Text())
+	}
+	markup := request.Params.Get("reply_markup")
+	if !strings.Contains(markup, `"hist:1"`) || strings.Contains(markup, `"hist:0"`) {
+		t.Errorf("кнопки первой страницы: %s", markup)
+	}
+
+	h.pressMessageButton(e2ePlayerID, 500, "hist:1")
+	request = h.expect(t, "editMessageText", e2ePlayerID, "страница 2 из 2")
+	if request.Params.Get("message_id") != "500" {
+		t.Errorf("отредактировано не то сообщение: %v", request.Params)
+	}
+	if !strings.Contains(request.Text(), "11. 🔑 старый дуб") || !strings.Contains(request.Text(), "12. 🔑 тайник удален") {
+		t.Errorf("вторая страница:\n%s", request.Text())
+	}
+	markup = request.Params.Get("reply_markup")
+	if !strings.Contains(markup, `"hist:0"`) || strings.Contains(markup, `"hist:2"`) {
+		t.Errorf("кнопки второй страницы: %s", markup)
+	}
+
+	// Медиафайл найденного тайника можно посмотреть снова
+	finds, err := h.db.GetUserFinds(e2ePlayerID, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.pressButton(e2ePlayerID, fmt.Sprintf("find:%d", finds[0].ID))
+	if request := h.expect(t, "sendPhoto", e2ePlayerID, "старый дуб"); request.Params.Get("photo") != "photo-1" {
+		t.Errorf("отправлен не тот медиафайл: %v", request.Params)
+	}
+}
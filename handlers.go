@@ -2,19 +2,35 @@ package main
 
 import (
 	"database/sql"
-	"fmt"
-	"log"
+	"log/slog"
 	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // Основной обработчик обновлений
 func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	kind := updateType(update)
+	updatesTotal.WithLabelValues(kind).Inc()
+
+	attrs := []any{"update_id", update.UpdateID, "user_id", updateUserID(update), "type", kind}
+	message := update.Message
+	if message == nil {
+		message = update.EditedMessage
+	}
+	if message != nil && message.Location != nil {
+		// Точные координаты попадают в журнал только в режиме отладки
+		attrs = append(attrs, "lat", message.Location.Latitude, "lon", message.Location.Longitude)
+	}
+	slog.Debug("Получено обновление", attrs...)
+
 	if update.Message != nil {
 		b.handleMessage(update.Message)
 	} else if update.EditedMessage != nil {
 		b.handleMessage(update.EditedMessage)
+	} else if update.CallbackQuery != nil {
+		b.handleCallbackQuery(update.CallbackQuery)
 	}
 }
 
@@ -22,6 +38,11 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 func (b *Bot) handleMessage(message *tgbotapi.Message) {
 	userID := message.From.ID
 
+	// Обновления трансляции геопозиции приходят часто, имя запоминаем только по остальным сообщениям
+	if message.Location == nil {
+		b.rememberUser(message.From)
+	}
+
 	// Проверяем, является ли пользователь администратором
 	if b.isAdmin(userID) {
 		// Проверяем, есть ли активная админская сессия
@@ -55,12 +76,44 @@ func (b *Bot) handleAdminMessage(message *tgbotapi.Message) {
 		switch message.Command() {
 		case "create":
 			b.handleCreateCommand(userID)
+		case "create_trail":
+			b.handleCreateTrailCommand(userID)
+		case "done":
+			b.handleTrailDoneCommand(userID)
 		case "start", "help":
 			b.sendAdminWelcome(userID)
 		case "stop":
 			b.handleAdminStopCommand(userID)
+		case "list":
+			b.handleListCommand(userID)
+		case "cache":
+			b.handleCacheCardCommand(userID, message.CommandArguments())
+		case "edit":
+			b.handleEditCommand(userID, message.CommandArguments())
+		case "delete":
+			b.handleDeleteCommand(userID, message.CommandArguments())
+		case "history":
+			b.handleHistoryCommand(userID)
+		case "top":
+			b.handleTopCommand(userID, message.CommandArguments())
+		case "me":
+			b.handleMeCommand(userID)
+		case "resume":
+			b.handleResumeCommand(userID)
+		case "export":
+			b.handleExportCommand(userID, message.CommandArguments())
+		case "import":
+			b.handleImportCommand(userID)
+		case "lang":
+			b.handleLanguageCommand(userID, message.CommandArguments())
+		case "settings":
+			b.handleSettingsCommand(userID)
+		case "nearby":
+			b.handleNearbyCommand(userID)
+		case "suspicious":
+			b.handleSuspiciousCommand(userID, message.CommandArguments())
 		default:
-			b.sendMessage(userID, "Неизвестная команда администратора. Доступные команды:\n/start - главное меню\n/create - создать новый тайник\n/stop - отменить создание тайника\n/help - справка")
+			b.reply(userID, "admin.unknown_command")
 		}
 		return
 	}
@@ -69,10 +122,10 @@ func (b *Bot) handleAdminMessage(message *tgbotapi.Message) {
 	session, err := b.DB.GetAdminSession(userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			b.sendMessage(userID, "Введите команду /create для создания нового тайника.")
+			b.reply(userID, "admin.no_session")
 			return
 		}
-		log.Printf("Ошибка получения админской сессии: %v", err)
+		slog.Error("Ошибка получения админской сессии", "user_id", userID, "error", err)
 		return
 	}
 
@@ -83,6 +136,33 @@ func (b *Bot) handleAdminMessage(message *tgbotapi.Message) {
 		b.handleLocationInput(userID, message)
 	case "waiting_media":
 		b.handleMediaInput(userID, message)
+	case "edit_code":
+		b.handleEditCodeWordInput(userID, session, message.Text)
+	case "edit_location":
+		b.handleEditLocationInput(userID, session, message)
+	case "edit_media":
+		b.handleEditMediaInput(userID, session, message)
+	case "trail_code":
+		b.handleTrailCodeInput(userID, message.Text)
+	case "trail_location":
+		b.handleTrailLocationInput(userID, session, message)
+	case "trail_media":
+		b.handleTrailMediaInput(userID, session, message)
+	case "trail_clue":
+		b.handleTrailClueInput(userID, session, message.Text)
+	case "waiting_radius", "waiting_difficulty", "waiting_terrain", "waiting_navigation", "waiting_public",
+		"waiting_description", "edit_radius", "edit_difficulty", "edit_terrain", "edit_navigation", "edit_public", "edit_description":
+		b.handleCacheDetailInput(userID, session, message.Text)
+	case "puzzle_kind":
+		b.handlePuzzleKindInput(userID, session, message.Text)
+	case "puzzle_question":
+		b.handlePuzzleQuestionInput(userID, session, message.Text)
+	case "puzzle_answer":
+		b.handlePuzzleAnswerInput(userID, session, message.Text)
+	case "puzzle_attempts":
+		b.handlePuzzleAttemptsInput(userID, session, message.Text)
+	case "waiting_import":
+		b.handleImportDocument(userID, message)
 	}
 }
 
@@ -95,26 +175,25 @@ func (b *Bot) handleCreateCommand(userID int64) {
 
 	err := b.DB.CreateOrUpdateAdminSession(session)
 	if err != nil {
-		log.Printf("Ошибка создания админской сессии: %v", err)
-		b.sendMessage(userID, "Произошла ошибка. Попробуйте еще раз.")
+		slog.Error("Ошибка создания админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
 		return
 	}
 
-	b.sendMessage(userID, "🔑 Придумайте кодовое слово для нового тайника:")
+	b.reply(userID, "create.ask_code_word")
 }
 
 // Обработчик ввода кодового слова
 func (b *Bot) handleCodeWordInput(userID int64, codeWord string) {
 	codeWord = strings.TrimSpace(codeWord)
 	if len(codeWord) < 3 {
-		b.sendMessage(userID, "Кодовое слово должно содержать минимум 3 символа. Попробуйте еще раз:")
+		b.reply(userID, "create.code_word_too_short")
 		return
 	}
 
 	// Проверяем, не существует ли уже такое кодовое слово
-	_, err := b.DB.GetCacheByCodeWord(codeWord)
-	if err == nil {
-		b.sendMessage(userID, "Кодовое слово уже существует! Придумайте другое:")
+	if b.isCodeWordTaken(codeWord) {
+		b.reply(userID, "create.code_word_taken")
 		return
 	}
 
@@ -125,30 +204,43 @@ func (b *Bot) handleCodeWordInput(userID int64, codeWord string) {
 		CodeWord: codeWord,
 	}
 
-	err = b.DB.CreateOrUpdateAdminSession(session)
+	lang := b.userLanguage(userID)
+
+	err := b.DB.CreateOrUpdateAdminSession(session)
 	if err != nil {
-		log.Printf("Ошибка обновления админской сессии: %v", err)
-		b.sendMessage(userID, "Произошла ошибка. Попробуйте еще раз.")
+		slog.Error("Ошибка обновления админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.sendMessage(userID, translate(lang, "error.generic"))
 		return
 	}
 
-	msg := tgbotapi.NewMessage(userID, "📍 Отлично! Теперь отправьте геолокацию места, где будет спрятан тайник.\n\n💡 Для создания тайника подходит обычная геопозиция (не трансляция).")
+	msg := tgbotapi.NewMessage(userID, translate(lang, "create.ask_location"))
 
 	keyboard := tgbotapi.NewReplyKeyboard(
 		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButtonLocation("📍 Отправить геолокацию"),
+			tgbotapi.NewKeyboardButtonLocation(translate(lang, "button.send_location")),
 		),
 	)
 	keyboard.OneTimeKeyboard = true
 	msg.ReplyMarkup = keyboard
 
-	b.API.Send(msg)
+	b.Outbox.Send(msg)
+}
+
+// isCodeWordTaken проверяет, занято ли кодовое слово тайником или маршрутом
+func (b *Bot) isCodeWordTaken(codeWord string) bool {
+	if _, err := b.DB.GetCacheByCodeWord(codeWord); err == nil {
+		return true
+	}
+	if _, err := b.DB.GetTrailByCodeWord(codeWord); err == nil {
+		return true
+	}
+	return false
 }
 
 // Обработчик ввода геолокации
 func (b *Bot) handleLocationInput(userID int64, message *tgbotapi.Message) {
 	if message.Location == nil {
-		b.sendMessage(userID, "Пожалуйста, отправьте геолокацию, используя кнопку ниже.")
+		b.reply(userID, "create.location_required")
 		return
 	}
 
@@ -158,8 +250,8 @@ func (b *Bot) handleLocationInput(userID int64, message *tgbotapi.Message) {
 	// Обновляем сессию
 	session, err := b.DB.GetAdminSession(userID)
 	if err != nil {
-		log.Printf("Ошибка получения админской сессии: %v", err)
-		b.sendMessage(userID, "Произошла ошибка. Попробуйте команду /create заново.")
+		slog.Error("Ошибка получения админской сессии", "user_id", userID, "error", err)
+		b.reply(userID, "create.restart")
 		return
 	}
 
@@ -169,48 +261,30 @@ func (b *Bot) handleLocationInput(userID int64, message *tgbotapi.Message) {
 
 	err = b.DB.CreateOrUpdateAdminSession(session)
 	if err != nil {
-		log.Printf("Ошибка обновления админской сессии: %v", err)
-		b.sendMessage(userID, "Произошла ошибка. Попробуйте еще раз.")
+		slog.Error("Ошибка обновления админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
 		return
 	}
 
 	// Убираем клавиатуру
-	msg := tgbotapi.NewMessage(userID, "📷🎥 Теперь отправьте фотографию, видео или видео-заметку этого места:")
+	msg := tgbotapi.NewMessage(userID, b.text(userID, "create.ask_media"))
 	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
-	b.API.Send(msg)
+	b.Outbox.Send(msg)
 }
 
 // Обработчик ввода медиафайла (фото или видео)
 func (b *Bot) handleMediaInput(userID int64, message *tgbotapi.Message) {
-	var fileID string
-	var fileType string
-	var mediaType string
-
-	// Проверяем тип медиафайла
-	if message.Photo != nil && len(message.Photo) > 0 {
-		// Получаем файл с наибольшим разрешением
-		photo := message.Photo[len(message.Photo)-1]
-		fileID = photo.FileID
-		fileType = "photo"
-		mediaType = "фотографию"
-	} else if message.Video != nil {
-		fileID = message.Video.FileID
-		fileType = "video"
-		mediaType = "видео"
-	} else if message.VideoNote != nil {
-		fileID = message.VideoNote.FileID
-		fileType = "video_note"
-		mediaType = "видео-заметку"
-	} else {
-		b.sendMessage(userID, "Пожалуйста, отправьте фотографию, видео или видео-заметку.")
+	fileID, fileType, ok := extractMedia(message)
+	if !ok {
+		b.reply(userID, "create.media_required")
 		return
 	}
 
 	// Получаем сессию
 	session, err := b.DB.GetAdminSession(userID)
 	if err != nil {
-		log.Printf("Ошибка получения админской сессии: %v", err)
-		b.sendMessage(userID, "Произошла ошибка. Попробуйте команду /create заново.")
+		slog.Error("Ошибка получения админской сессии", "user_id", userID, "error", err)
+		b.reply(userID, "create.restart")
 		return
 	}
 
@@ -226,18 +300,34 @@ func (b *Bot) handleMediaInput(userID int64, message *tgbotapi.Message) {
 
 	err = b.DB.CreateCache(cache)
 	if err != nil {
-		log.Printf("Ошибка создания кэша: %v", err)
-		b.sendMessage(userID, "Ошибка при создании кэша. Попробуйте еще раз.")
+		slog.Error("Ошибка создания кэша", "user_id", userID, "error", err)
+		b.reply(userID, "create.failed")
 		return
 	}
+	slog.Info("Тайник создан", "user_id", userID, "cache_id", cache.ID, "lat", cache.Latitude, "lon", cache.Longitude)
 
-	// Удаляем сессию
-	b.DB.DeleteAdminSession(userID)
+	lang := b.userLanguage(userID)
+	b.sendMessage(userID, translate(lang, "create.done",
+		cache.CodeWord, cache.Latitude, cache.Longitude, mediaTypeAccusative(lang, fileType)))
 
-	successMsg := fmt.Sprintf("✅ Тайник успешно создан!\n\n🔑 Кодовое слово: %s\n📍 Координаты: %.6f, %.6f\n📱 Медиафайл: %s\n\nТеперь пользователи могут найти этот тайник, введя кодовое слово.",
-		cache.CodeWord, cache.Latitude, cache.Longitude, mediaType)
+	// Необязательные шаги: радиус, сложность, описание и вопрос на месте тайника
+	b.askCacheDetail(userID, cache.CodeWord, "waiting_", cacheDetailFields[0])
+}
 
-	b.sendMessage(userID, successMsg)
+// extractMedia извлекает file_id и тип медиафайла из сообщения
+func extractMedia(message *tgbotapi.Message) (fileID, fileType string, ok bool) {
+	if len(message.Photo) > 0 {
+		// Получаем файл с наибольшим разрешением
+		photo := message.Photo[len(message.Photo)-1]
+		return photo.FileID, "photo", true
+	}
+	if message.Video != nil {
+		return message.Video.FileID, "video", true
+	}
+	if message.VideoNote != nil {
+		return message.VideoNote.FileID, "video_note", true
+	}
+	return "", "", false
 }
 
 // Обработчик сообщений пользователей
@@ -247,11 +337,25 @@ func (b *Bot) handleUserMessage(message *tgbotapi.Message) {
 	if message.IsCommand() {
 		switch message.Command() {
 		case "start":
-			b.sendMessage(userID, "🗺️ Добро пожаловать в GeoCaching Bot!\n\n🔍 Введите кодовое слово для поиска тайника:\n\n💡 Совет: кодовое слово должно содержать минимум 3 символа")
+			b.reply(userID, "user.welcome")
 		case "stop":
 			b.handleStopCommand(userID)
+		case "history":
+			b.handleHistoryCommand(userID)
+		case "top":
+			b.handleTopCommand(userID, message.CommandArguments())
+		case "me":
+			b.handleMeCommand(userID)
+		case "resume":
+			b.handleResumeCommand(userID)
+		case "lang":
+			b.handleLanguageCommand(userID, message.CommandArguments())
+		case "settings":
+			b.handleSettingsCommand(userID)
+		case "nearby":
+			b.handleNearbyCommand(userID)
 		default:
-			b.sendMessage(userID, "🤔 Неизвестная команда.\n\nВведите кодовое слово для поиска тайника или /stop для остановки поиска.")
+			b.reply(userID, "user.unknown_command")
 		}
 		return
 	}
@@ -259,18 +363,26 @@ func (b *Bot) handleUserMessage(message *tgbotapi.Message) {
 	// Проверяем, есть ли активная пользовательская сессия
 	session, err := b.DB.GetUserSession(userID)
 	if err != nil && err != sql.ErrNoRows {
-		log.Printf("Ошибка получения пользовательской сессии: %v", err)
+		slog.Error("Ошибка получения пользовательской сессии", "user_id", userID, "error", err)
 		return
 	}
 
 	// Если есть активная сессия, обрабатываем обновления геолокации
 	if session != nil {
+		// Пользователь уже на месте и отвечает на вопрос тайника
+		if b.handlePuzzleGate(userID, session, message) {
+			return
+		}
 		b.handleLocationUpdate(userID, message)
 		return
 	}
 
-	// Если нет активной сессии, но получили геолокацию - просто игнорируем
+	// Без активной сессии обычная геопозиция - запрос тайников поблизости,
+	// а обновления завершенной трансляции игнорируем
 	if message.Location != nil {
+		if message.Location.LivePeriod == 0 && message.EditDate == 0 {
+			b.handleNearbyLocation(userID, message.Location)
+		}
 		return
 	}
 
@@ -282,27 +394,53 @@ func (b *Bot) handleUserMessage(message *tgbotapi.Message) {
 func (b *Bot) handleCacheSearch(userID int64, codeWord string) {
 	// Проверяем, что получили текстовое сообщение
 	if codeWord == "" {
-		b.sendMessage(userID, "🗺️ Добро пожаловать в GeoCaching Bot!\n\n🔍 Введите кодовое слово для поиска тайника:\n\n💡 Совет: кодовое слово должно содержать минимум 3 символа")
+		b.reply(userID, "user.welcome")
 		return
 	}
 
 	codeWord = strings.TrimSpace(codeWord)
 	if len(codeWord) < 3 {
-		b.sendMessage(userID, "Кодовое слово должно содержать минимум 3 символа.")
+		b.reply(userID, "search.too_short")
 		return
 	}
 
 	// Ищем тайник в базе данных
 	cache, err := b.DB.GetCacheByCodeWord(codeWord)
+	if err == nil {
+		// Тайники-этапы маршрутов нельзя искать напрямую
+		if _, stageErr := b.DB.GetTrailStageByCacheID(cache.ID); stageErr == nil {
+			cache, err = nil, sql.ErrNoRows
+		}
+	}
+	if err == sql.ErrNoRows {
+		// Кодовое слово может принадлежать маршруту
+		trail, trailErr := b.DB.GetTrailByCodeWord(codeWord)
+		if trailErr == nil && trail.IsPublished {
+			searchesTotal.WithLabelValues("trail").Inc()
+			b.startTrail(userID, trail)
+			return
+		}
+		if trailErr != nil && trailErr != sql.ErrNoRows {
+			err = trailErr
+		}
+	}
 	if err != nil {
 		if err == sql.ErrNoRows {
-			b.sendMessage(userID, "🔍 Тайник с таким кодовым словом не найден.\n\nПроверьте правильность написания и попробуйте еще раз.")
+			searchesTotal.WithLabelValues("miss").Inc()
+			b.reply(userID, "search.not_found")
 		} else {
-			log.Printf("Ошибка поиска кэша: %v", err)
-			b.sendMessage(userID, "Произошла ошибка при поиске. Попробуйте еще раз.")
+			slog.Error("Ошибка поиска кэша", "user_id", userID, "error", err)
+			b.reply(userID, "search.failed")
 		}
 		return
 	}
+	searchesTotal.WithLabelValues("hit").Inc()
+
+	b.DB.ClearPuzzleAwaiting(userID)
+
+	// Новый поиск заменяет прежний: его навигация больше не нужна
+	b.Outbox.CancelEdit(userID)
+	b.Tracker.Reset(userID)
 
 	// Создаем пользовательскую сессию
 	userSession := &UserSession{
@@ -313,188 +451,235 @@ func (b *Bot) handleCacheSearch(userID int64, codeWord string) {
 
 	err = b.DB.CreateOrUpdateUserSession(userSession)
 	if err != nil {
-		log.Printf("Ошибка создания пользовательской сессии: %v", err)
-		b.sendMessage(userID, "Произошла ошибка. Попробуйте еще раз.")
+		slog.Error("Ошибка создания пользовательской сессии", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
 		return
 	}
 
 	// Запрашиваем доступ к live-геолокации
-	instruction := fmt.Sprintf(`🎯 Тайник найден: %s
+	lang := b.userLanguage(userID)
+	b.sendMessage(userID, translate(lang, "search.found",
+		cache.CodeWord, formatCacheDetails(lang, cache, b.Config.TargetDistanceMeters)))
+}
 
-📍 Для начала поиска включите трансляцию геопозиции`,
-		cache.CodeWord)
+// navigate обрабатывает очередную сглаженную точку трансляции: добавляет пройденное расстояние к сессии
+// и возвращает текст навигационного сообщения либо признак того, что игрок дошел до тайника.
+// Используется и ботом, и симулятором треков.
+func (b *Bot) navigate(lang string, settings DisplaySettings, session *UserSession, cache *Cache, fix LocationFix) (string, bool) {
+	// Пройденное расстояние считаем от последней сохраненной в сессии точки
+	if session.LastMessageID != 0 {
+		session.DistanceWalked += calculateDistance(session.LastLatitude, session.LastLongitude, fix.Lat, fix.Lon) * 1000
+	}
+
+	distance := calculateDistance(fix.Lat, fix.Lon, cache.Latitude, cache.Longitude) * 1000
+	if b.Tracker.Arrived(session.UserID, cache.ID, distance, cache.TargetRadius(b.Config.TargetDistanceMeters), fix.Accuracy) {
+		return "", true
+	}
+
+	switch cache.Navigation() {
+	case NavigationDistance:
+		return translate(lang, "nav.distance_only", formatDistance(lang, settings.Units, distance)), false
+	case NavigationHotCold:
+		// Сравниваем с расстоянием от предыдущей точки, сохраненной в сессии
+		previous := -1.0
+		if session.LastMessageID != 0 {
+			previous = calculateDistance(session.LastLatitude, session.LastLongitude, cache.Latitude, cache.Longitude) * 1000
+		}
+		return formatHotColdMessage(lang, previous, distance), false
+	}
 
-	b.sendMessage(userID, instruction)
+	// Формируем сообщение с направлением
+	return translate(lang, "nav.header", formatDirectionMessage(lang, settings, fix.Lat, fix.Lon, cache.Latitude, cache.Longitude, fix.Heading)), false
 }
 
 // Обработчик обновлений геолокации
 func (b *Bot) handleLocationUpdate(userID int64, message *tgbotapi.Message) {
 	if message.Location == nil {
-		b.sendMessage(userID, "Пожалуйста, отправьте геолокацию для продолжения поиска.\n\nИспользуйте /stop для остановки поиска.")
+		b.reply(userID, "nav.location_required")
 		return
 	}
 
 	// Проверяем, что это live-геолокация (трансляция), а не статичная точка
 	if message.Location.LivePeriod == 0 {
-		instruction := `❌ Получена статичная геопозиция!
-
-📍 Для навигации нужна ТРАНСЛЯЦИЯ геопозиции:
-
-1️⃣ Нажмите на скрепку 📎 в поле ввода
-2️⃣ Выберите "Геопозиция" 🗺️  
-3️⃣ Выберите "Транслировать геопозицию" ⏱️
-4️⃣ Установите время и нажмите "Поделиться"
-
-⚠️ Используйте именно ТРАНСЛЯЦИЮ, а не обычную геопозицию!`
-
-		b.sendMessage(userID, instruction)
+		b.reply(userID, "nav.static_location")
 		return
 	}
 
 	// Получаем сессию пользователя
 	session, err := b.DB.GetUserSession(userID)
 	if err != nil {
-		log.Printf("Ошибка получения пользовательской сессии: %v", err)
+		slog.Error("Ошибка получения пользовательской сессии", "user_id", userID, "error", err)
 		return
 	}
 
 	// Получаем данные кэша по ID из сессии
-	query := `SELECT id, code_word, latitude, longitude, file_id, file_type, created_at, created_by 
-			  FROM caches WHERE id = ?`
-
-	cache := &Cache{}
-	err = b.DB.db.QueryRow(query, session.CacheID).Scan(
-		&cache.ID, &cache.CodeWord, &cache.Latitude, &cache.Longitude,
-		&cache.FileID, &cache.FileType, &cache.CreatedAt, &cache.CreatedBy,
-	)
-
+	cache, err := b.DB.GetCacheByID(session.CacheID)
 	if err != nil {
-		log.Printf("Ошибка получения кэша: %v", err)
+		slog.Error("Ошибка получения кэша", "user_id", userID, "cache_id", session.CacheID, "error", err)
 		return
 	}
 
-	userLat := float64(message.Location.Latitude)
-	userLon := float64(message.Location.Longitude)
+	// Отбрасываем неточные точки и сглаживаем скачки GPS
+	fix, ok := b.Tracker.Add(userID, cache.ID, LocationFix{
+		Lat:      message.Location.Latitude,
+		Lon:      message.Location.Longitude,
+		Accuracy: message.Location.HorizontalAccuracy,
+		Heading:  message.Location.Heading,
+	})
+	if !ok {
+		locationFixesDiscarded.Inc()
+		slog.Debug("Неточная геопозиция отброшена", "user_id", userID, "accuracy", message.Location.HorizontalAccuracy)
+
+		// Трансляция жива, просто точность пока плохая
+		b.DB.TouchUserSession(userID)
+		if session.LastMessageID == 0 && b.Tracker.WarnPoorAccuracy(userID, cache.ID) {
+			b.reply(userID, "nav.poor_accuracy", int(message.Location.HorizontalAccuracy))
+		}
+		return
+	}
+
+	// Ищем признаки поддельной геопозиции по сырой точке, до сглаживания
+	now := time.Now()
+	b.checkLocationSpoofing(session, cache, message, now)
+	session.LastFixAt = now
+
+	directionMsg, reached := b.navigate(b.userLanguage(userID), b.displaySettings(userID), session, cache, fix)
 
 	// Проверяем, достиг ли пользователь цели
-	if isTargetReached(userLat, userLon, cache.Latitude, cache.Longitude, b.Config.TargetDistanceMeters) {
-		b.handleTargetReached(userID, cache)
+	if reached {
+		session.LastLatitude = fix.Lat
+		session.LastLongitude = fix.Lon
+		b.DB.UpdateUserSessionPosition(session)
+		b.Tracker.Reset(userID)
+
+		if b.Config.BlockSuspiciousFinds && b.isSessionSuspicious(session) {
+			b.refuseFind(userID, cache)
+			return
+		}
+
+		// Устаревшая навигация больше не нужна
+		b.Outbox.CancelEdit(userID)
+		b.handleArrival(userID, cache)
 		return
 	}
 
-	// Формируем сообщение с направлением
-	directionMsg := fmt.Sprintf("🧭 Направление к тайнику:\n\n%s",
-		formatDirectionMessage(userLat, userLon, cache.Latitude, cache.Longitude))
-
 	// Если это первое сообщение, отправляем новое
 	if session.LastMessageID == 0 {
 		// Отправляем сообщение с направлением (без ReplyMarkup для совместимости с редактированием)
 		msg := tgbotapi.NewMessage(userID, directionMsg)
 		msg.ParseMode = "Markdown"
-		sentMsg, err := b.API.Send(msg)
+		sentMsg, err := b.Outbox.Send(msg)
 		if err != nil {
-			log.Printf("Ошибка отправки сообщения: %v", err)
+			slog.Error("Ошибка отправки сообщения", "user_id", userID, "cache_id", cache.ID, "error", err)
 			return
 		}
 
 		// Обновляем сессию
-		session.LastLatitude = userLat
-		session.LastLongitude = userLon
+		session.LastLatitude = fix.Lat
+		session.LastLongitude = fix.Lon
 		session.LastMessageID = sentMsg.MessageID
 		session.LastMessageText = directionMsg
 		b.DB.CreateOrUpdateUserSession(session)
 	} else {
 		// Проверяем, изменился ли текст сообщения
 		if session.LastMessageText == directionMsg {
-			// Текст не изменился, пропускаем обновление
+			// Текст не изменился, правку не отправляем, но запоминаем точку: от нее считаются
+			// пройденное расстояние и скорость следующего перемещения
+			session.LastLatitude = fix.Lat
+			session.LastLongitude = fix.Lon
+			b.DB.UpdateUserSessionPosition(session)
 			return
 		}
 
-		// Пытаемся отредактировать существующее сообщение
-		edit := tgbotapi.NewEditMessageText(userID, session.LastMessageID, directionMsg)
-		edit.ParseMode = "Markdown"
-		_, err := b.API.Send(edit)
-
-		if err != nil {
-			// Если редактирование не удалось, отправляем новое сообщение
-			log.Printf("Не удалось отредактировать сообщение: %v. Отправляем новое.", err)
-
-			newMsg := tgbotapi.NewMessage(userID, directionMsg)
-			newMsg.ParseMode = "Markdown"
-			sentMsg, sendErr := b.API.Send(newMsg)
-
-			if sendErr != nil {
-				log.Printf("Ошибка отправки нового сообщения: %v", sendErr)
-				return
+		// Правка уйдет не чаще UPDATE_INTERVAL_SECONDS, промежуточные тексты заменяются более свежими
+		b.Outbox.QueueEdit(userID, session.LastMessageID, directionMsg, "Markdown", func(messageID int) {
+			if err := b.DB.SetUserSessionMessageID(userID, cache.ID, messageID); err != nil {
+				slog.Error("Ошибка обновления пользовательской сессии", "user_id", userID, "cache_id", cache.ID, "error", err)
 			}
+		})
 
-			// Обновляем ID последнего сообщения
-			session.LastMessageID = sentMsg.MessageID
-		}
-
-		// Обновляем сессию
-		session.LastLatitude = userLat
-		session.LastLongitude = userLon
+		// Обновляем сессию. ID сообщения не перезаписываем: если правка не удастся,
+		// очередь отправит сообщение заново и сама сохранит новый ID
+		session.LastLatitude = fix.Lat
+		session.LastLongitude = fix.Lon
 		session.LastMessageText = directionMsg
-		b.DB.CreateOrUpdateUserSession(session)
+		b.DB.UpdateUserSessionPosition(session)
 	}
 }
 
 // Обработчик достижения цели
 func (b *Bot) handleTargetReached(userID int64, cache *Cache) {
+	b.recordFind(userID, cache)
+
+	// Этап маршрута: показываем подсказку и переходим к следующему этапу
+	stage, err := b.DB.GetTrailStageByCacheID(cache.ID)
+	if err == nil {
+		b.handleStageReached(userID, cache, stage)
+		return
+	}
+	if err != sql.ErrNoRows {
+		slog.Error("Ошибка получения этапа маршрута", "user_id", userID, "cache_id", cache.ID, "error", err)
+	}
+
 	// Деактивируем сессию
-	b.DB.DeactivateUserSession(userID)
+	b.endUserSession(userID)
+
+	lang := b.userLanguage(userID)
+
+	// Тайник без медиафайла (например, импортированный из файла)
+	if cache.FileID == "" {
+		msg := tgbotapi.NewMessage(userID, translate(lang, "found.no_media", cache.CodeWord))
+		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+		b.Outbox.Send(msg)
+		return
+	}
 
 	// Определяем тип медиафайла по сохраненному типу
 	var mediaTypeText string
 	switch cache.FileType {
-	case "video_note":
-		mediaTypeText = "🎥 Вот видео-заметка места:"
-	case "video":
-		mediaTypeText = "🎥 Вот видео места:"
+	case "video_note", "video":
+		mediaTypeText = translate(lang, "found.here_is_"+cache.FileType)
 	case "photo":
 		fallthrough
 	default:
-		mediaTypeText = "📷 Вот фотография места:"
+		mediaTypeText = translate(lang, "found.here_is_photo")
 	}
 
 	// Отправляем поздравительное сообщение
-	congratsMsg := fmt.Sprintf("🎉 Поздравляем! Вы нашли тайник: %s\n\n%s\n\n💡 Вы можете остановить передачу геолокации и начать поиск нового тайника!", cache.CodeWord, mediaTypeText)
-
-	msg := tgbotapi.NewMessage(userID, congratsMsg)
+	msg := tgbotapi.NewMessage(userID, translate(lang, "found.congrats", cache.CodeWord, mediaTypeText))
 	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
-	b.API.Send(msg)
+	b.Outbox.Send(msg)
 
 	// Отправляем медиафайл используя file_id
-	caption := "🏆 Поиск завершен! Введите новое кодовое слово для следующего тайника."
+	caption := translate(lang, "found.caption")
 
 	switch cache.FileType {
 	case "video_note":
 		videoNoteMsg := tgbotapi.NewVideoNote(userID, 0, tgbotapi.FileID(cache.FileID))
-		_, err := b.API.Send(videoNoteMsg)
+		_, err := b.Outbox.Send(videoNoteMsg)
 		if err != nil {
-			log.Printf("Ошибка отправки видео-заметки: %v", err)
-			b.sendMessage(userID, "К сожалению, не удалось загрузить видео-заметку места.")
+			slog.Error("Ошибка отправки видео-заметки", "user_id", userID, "cache_id", cache.ID, "error", err)
+			b.sendMessage(userID, translate(lang, "found.video_note_failed"))
 		}
 		// Отправляем текст отдельно, так как видео-заметки не поддерживают подписи
 		b.sendMessage(userID, caption)
 	case "video":
 		videoMsg := tgbotapi.NewVideo(userID, tgbotapi.FileID(cache.FileID))
 		videoMsg.Caption = caption
-		_, err := b.API.Send(videoMsg)
+		_, err := b.Outbox.Send(videoMsg)
 		if err != nil {
-			log.Printf("Ошибка отправки видео: %v", err)
-			b.sendMessage(userID, "К сожалению, не удалось загрузить видео места.")
+			slog.Error("Ошибка отправки видео", "user_id", userID, "cache_id", cache.ID, "error", err)
+			b.sendMessage(userID, translate(lang, "found.video_failed"))
 		}
 	case "photo":
 		fallthrough
 	default:
 		photoMsg := tgbotapi.NewPhoto(userID, tgbotapi.FileID(cache.FileID))
 		photoMsg.Caption = caption
-		_, err := b.API.Send(photoMsg)
+		_, err := b.Outbox.Send(photoMsg)
 		if err != nil {
-			log.Printf("Ошибка отправки фотографии: %v", err)
-			b.sendMessage(userID, "К сожалению, не удалось загрузить фотографию места.")
+			slog.Error("Ошибка отправки фотографии", "user_id", userID, "cache_id", cache.ID, "error", err)
+			b.sendMessage(userID, translate(lang, "found.photo_failed"))
 		}
 	}
 }
@@ -502,50 +687,61 @@ func (b *Bot) handleTargetReached(userID int64, cache *Cache) {
 // Обработчик команды /stop
 func (b *Bot) handleStopCommand(userID int64) {
 	// Деактивируем пользовательскую сессию
-	err := b.DB.DeactivateUserSession(userID)
+	err := b.endUserSession(userID)
 	if err != nil && err != sql.ErrNoRows {
-		log.Printf("Ошибка деактивации сессии: %v", err)
+		slog.Error("Ошибка деактивации сессии", "user_id", userID, "error", err)
 	}
+	b.DB.ClearPuzzleAwaiting(userID)
 
-	msg := tgbotapi.NewMessage(userID, "🛑 Поиск тайника остановлен.\n\nВведите новое кодовое слово для начала поиска.")
+	msg := tgbotapi.NewMessage(userID, b.text(userID, "stop.user"))
 	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
-	b.API.Send(msg)
+	b.Outbox.Send(msg)
 }
 
 // sendAdminWelcome отправляет приветствие администратору
 func (b *Bot) sendAdminWelcome(userID int64) {
-	welcomeMsg := `👑 Добро пожаловать, администратор!
-
-📋 **Доступные команды:**
-• /start или /help - показать это меню
-• /create - создать новый тайник
-• /stop - отменить создание/поиск тайника
-
-🎯 **Доступные режимы:**
-
-🔧 **Режим администратора:**
-Используйте команды выше для управления тайниками
-
-🔍 **Режим тестирования:**
-• Введите кодовое слово для поиска тайника
-• Полная навигация как у обычных пользователей
-• Поддержка фото, видео и видео-заметок
-
-💡 Переключение между режимами происходит автоматически!`
-
-	msg := tgbotapi.NewMessage(userID, welcomeMsg)
+	msg := tgbotapi.NewMessage(userID, b.text(userID, "admin.welcome"))
 	msg.ParseMode = "Markdown"
-	b.API.Send(msg)
+	b.Outbox.Send(msg)
 }
 
 // handleAdminStopCommand обрабатывает команду /stop для администратора
 func (b *Bot) handleAdminStopCommand(userID int64) {
 	// Проверяем, есть ли активная админская сессия
-	_, err := b.DB.GetAdminSession(userID)
+	lang := b.userLanguage(userID)
+	adminSession, err := b.DB.GetAdminSession(userID)
 	if err == nil {
 		// Есть активная админская сессия - удаляем её
 		b.DB.DeleteAdminSession(userID)
-		b.sendMessage(userID, "🛑 Создание тайника отменено.\n\nВы можете:\n• /create - создать новый тайник\n• Ввести кодовое слово для поиска тайника")
+		if strings.HasPrefix(adminSession.Step, "trail_") {
+			b.cancelDraftTrail(adminSession)
+			msg := tgbotapi.NewMessage(userID, translate(lang, "stop.trail"))
+			msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+			b.Outbox.Send(msg)
+			return
+		}
+		if isCacheDetailStep(adminSession.Step) {
+			msg := tgbotapi.NewMessage(userID, translate(lang, "stop.details"))
+			msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+			b.Outbox.Send(msg)
+			return
+		}
+		if strings.HasPrefix(adminSession.Step, "puzzle_") {
+			b.cancelDraftPuzzle(adminSession)
+			msg := tgbotapi.NewMessage(userID, translate(lang, "stop.puzzle"))
+			msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+			b.Outbox.Send(msg)
+			return
+		}
+		if adminSession.Step == "waiting_import" {
+			b.sendMessage(userID, translate(lang, "stop.import"))
+			return
+		}
+		if strings.HasPrefix(adminSession.Step, "edit_") {
+			b.sendMessage(userID, translate(lang, "stop.edit"))
+			return
+		}
+		b.sendMessage(userID, translate(lang, "stop.create"))
 		return
 	}
 
@@ -553,15 +749,16 @@ func (b *Bot) handleAdminStopCommand(userID int64) {
 	_, err = b.DB.GetUserSession(userID)
 	if err == nil {
 		// Есть активная пользовательская сессия - деактивируем её
-		b.DB.DeactivateUserSession(userID)
-		msg := tgbotapi.NewMessage(userID, "🛑 Поиск тайника остановлен.\n\nВы можете:\n• /create - создать новый тайник\n• Ввести кодовое слово для поиска тайника")
+		b.endUserSession(userID)
+		b.DB.ClearPuzzleAwaiting(userID)
+		msg := tgbotapi.NewMessage(userID, translate(lang, "stop.admin_search"))
 		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
-		b.API.Send(msg)
+		b.Outbox.Send(msg)
 		return
 	}
 
 	// Никаких активных сессий нет
-	b.sendMessage(userID, "ℹ️ Нет активных процессов для остановки.\n\nВы можете:\n• /create - создать новый тайник\n• Ввести кодовое слово для поиска тайника")
+	b.sendMessage(userID, translate(lang, "stop.nothing"))
 }
 
 // isAdmin проверяет, является ли пользователь администратором
@@ -577,8 +774,8 @@ func (b *Bot) isAdmin(userID int64) bool {
 // Вспомогательная функция для отправки текстовых сообщений
 func (b *Bot) sendMessage(chatID int64, text string) {
 	msg := tgbotapi.NewMessage(chatID, text)
-	_, err := b.API.Send(msg)
+	_, err := b.Outbox.Send(msg)
 	if err != nil {
-		log.Printf("Ошибка отправки сообщения: %v", err)
+		slog.Error("Ошибка отправки сообщения", "user_id", chatID, "error", err)
 	}
 }
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Языки интерфейса бота
+const (
+	LangRu = "ru"
+	LangEn = "en"
+)
+
+// Язык по умолчанию: для пользователей, чей клиент Telegram не сообщил язык
+const defaultLanguage = LangRu
+
+// Языки, для которых есть каталог сообщений, в порядке показа в /lang
+var languages = []string{LangRu, LangEn}
+
+// Названия языков в кнопках /lang - на самом языке, чтобы их можно было найти в любом интерфейсе
+var languageNames = map[string]string{
+	LangRu: "🇷🇺 Русский",
+	LangEn: "🇬🇧 English",
+}
+
+// Аргумент /lang, возвращающий выбор языка по настройкам клиента Telegram
+const languageAuto = "auto"
+
+// Каталоги сообщений: ключ сообщения -> шаблон для fmt.Sprintf.
+// Формы множественного числа разделяются символом «|» (см. translatePlural).
+var catalogs = map[string]map[string]string{
+	LangRu: messagesRu,
+	LangEn: messagesEn,
+}
+
+// isSupportedLanguage проверяет, есть ли каталог для языка
+func isSupportedLanguage(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// resolveLanguage выбирает язык интерфейса: выбранный командой /lang,
+// иначе язык клиента Telegram (ru-RU -> ru), иначе английский или язык по умолчанию
+func resolveLanguage(chosen, languageCode string) string {
+	if isSupportedLanguage(chosen) {
+		return chosen
+	}
+
+	code := strings.ToLower(languageCode)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if isSupportedLanguage(code) {
+		return code
+	}
+	if code != "" {
+		return LangEn
+	}
+	return defaultLanguage
+}
+
+// lookupMessage возвращает шаблон сообщения; если перевода нет - русский вариант, если нет и его - сам ключ
+func lookupMessage(lang, key string) string {
+	if text, ok := catalogs[lang][key]; ok {
+		return text
+	}
+	if text, ok := catalogs[defaultLanguage][key]; ok {
+		return text
+	}
+	slog.Warn("Нет сообщения в каталоге", "lang", lang, "key", key)
+	return key
+}
+
+// translate возвращает сообщение key на языке lang, подставляя args
+func translate(lang, key string, args ...interface{}) string {
+	text := lookupMessage(lang, key)
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// translatePlural возвращает форму сообщения key, согласованную с числом n.
+// Формы в каталоге разделяются «|»: для русского - одна|несколько|много («%d тайник|%d тайника|%d тайников»),
+// для английского - одна|много. В шаблон первым аргументом подставляется n, затем args.
+func translatePlural(lang, key string, n int, args ...interface{}) string {
+	forms := strings.Split(lookupMessage(lang, key), "|")
+	form := forms[pluralForm(lang, n)%len(forms)]
+	return fmt.Sprintf(form, append([]interface{}{n}, args...)...)
+}
+
+// pluralForm возвращает номер формы множественного числа для n
+func pluralForm(lang string, n int) int {
+	if n < 0 {
+		n = -n
+	}
+
+	switch lang {
+	case LangRu:
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		default:
+			return 2
+		}
+	default:
+		if n == 1 {
+			return 0
+		}
+		return 1
+	}
+}
+
+// userLanguage возвращает язык интерфейса пользователя
+func (b *Bot) userLanguage(userID int64) string {
+	user, err := b.DB.GetUser(userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.Error("Ошибка получения языка пользователя", "user_id", userID, "error", err)
+		}
+		return defaultLanguage
+	}
+	return resolveLanguage(user.Language, user.LanguageCode)
+}
+
+// text возвращает сообщение key на языке пользователя
+func (b *Bot) text(userID int64, key string, args ...interface{}) string {
+	return translate(b.userLanguage(userID), key, args...)
+}
+
+// reply отправляет пользователю сообщение key на его языке
+func (b *Bot) reply(userID int64, key string, args ...interface{}) {
+	b.sendMessage(userID, b.text(userID, key, args...))
+}
+
+// Обработчик команды /lang [ru|en|auto]
+func (b *Bot) handleLanguageCommand(userID int64, args string) {
+	if choice := strings.TrimSpace(args); choice != "" {
+		b.setUserLanguage(userID, choice)
+		return
+	}
+
+	lang := b.userLanguage(userID)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, code := range languages {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(languageNames[code], "lang:"+code),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(translate(lang, "lang.button_auto"), "lang:"+languageAuto),
+	))
+
+	msg := tgbotapi.NewMessage(userID, translate(lang, "lang.prompt", languageNames[lang]))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.Outbox.Send(msg)
+}
+
+// Обработчик выбора языка кнопкой
+func (b *Bot) handleLanguageCallback(query *tgbotapi.CallbackQuery, arg string) {
+	b.setUserLanguage(query.From.ID, arg)
+}
+
+// setUserLanguage сохраняет выбор языка и подтверждает его уже на новом языке
+func (b *Bot) setUserLanguage(userID int64, choice string) {
+	choice = strings.ToLower(choice)
+
+	language := choice
+	if choice == languageAuto {
+		language = ""
+	} else if !isSupportedLanguage(choice) {
+		b.reply(userID, "lang.unknown", strings.Join(languages, ", "))
+		return
+	}
+
+	if err := b.DB.SetUserLanguage(userID, language); err != nil {
+		slog.Error("Ошибка сохранения языка", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	lang := b.userLanguage(userID)
+	if language == "" {
+		b.reply(userID, "lang.changed_auto", languageNames[lang])
+		return
+	}
+	b.reply(userID, "lang.changed", languageNames[lang])
+}
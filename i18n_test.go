@@ -0,0 +1,135 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Спецификаторы fmt в шаблоне без учета флагов ширины и точности
+var formatVerb = regexp.MustCompile(`%[-+# 0-9.]*([a-zA-Z%])`)
+
+func formatVerbs(template string) []string {
+	var verbs []string
+	for _, match := range formatVerb.FindAllStringSubmatch(template, -1) {
+		if match[1] != "%" {
+			verbs = append(verbs, match[1])
+		}
+	}
+	return verbs
+}
+
+func TestCatalogsHaveSameKeysAndVerbs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		if lang == defaultLanguage {
+			continue
+		}
+
+		for key, base := range catalogs[defaultLanguage] {
+			text, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: нет перевода для %q", lang, key)
+				continue
+			}
+
+			// У форм множественного числа сравниваем первую форму: их количество в языках разное
+			want := strings.Join(formatVerbs(strings.Split(base, "|")[0]), " ")
+			for _, form := range strings.Split(text, "|") {
+				if got := strings.Join(formatVerbs(form), " "); got != want {
+					t.Errorf("%s: %q: спецификаторы %q, в русском каталоге %q", lang, key, got, want)
+				}
+			}
+		}
+
+		var extra []string
+		for key := range catalog {
+			if _, ok := catalogs[defaultLanguage][key]; !ok {
+				extra = append(extra, key)
+			}
+		}
+		sort.Strings(extra)
+		if len(extra) > 0 {
+			t.Errorf("%s: ключи, которых нет в русском каталоге: %v", lang, extra)
+		}
+	}
+}
+
+func TestTranslatePlural(t *testing.T) {
+	tests := []struct {
+		lang string
+		n    int
+		want string
+	}{
+		{LangRu, 1, "1 тайник"},
+		{LangRu, 2, "2 тайника"},
+		{LangRu, 5, "5 тайников"},
+		{LangRu, 11, "11 тайников"},
+		{LangRu, 12, "12 тайников"},
+		{LangRu, 21, "21 тайник"},
+		{LangRu, 22, "22 тайника"},
+		{LangRu, 112, "112 тайников"},
+		{LangRu, 0, "0 тайников"},
+		{LangEn, 1, "1 cache"},
+		{LangEn, 2, "2 caches"},
+		{LangEn, 0, "0 caches"},
+	}
+
+	for _, tt := range tests {
+		if got := translatePlural(tt.lang, "count.caches", tt.n); got != tt.want {
+			t.Errorf("translatePlural(%s, %d) = %q, ожидалось %q", tt.lang, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestResolveLanguage(t *testing.T) {
+	tests := []struct {
+		chosen, languageCode, want string
+	}{
+		{"", "", LangRu},
+		{"", "ru", LangRu},
+		{"", "en-US", LangEn},
+		{"", "de", LangEn},
+		{"", "RU_ru", LangRu},
+		{LangRu, "en", LangRu},
+		{LangEn, "ru", LangEn},
+		{"xx", "ru", LangRu},
+	}
+
+	for _, tt := range tests {
+		if got := resolveLanguage(tt.chosen, tt.languageCode); got != tt.want {
+			t.Errorf("resolveLanguage(%q, %q) = %q, ожидалось %q", tt.chosen, tt.languageCode, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateFallsBackToDefaultLanguage(t *testing.T) {
+	if got := translate("xx", "unit.meters", 5); got != "5 м" {
+		t.Errorf("translate для неизвестного языка = %q, ожидалось русское сообщение", got)
+	}
+	if got := translate(LangEn, "no.such.key"); got != "no.such.key" {
+		t.Errorf("translate для неизвестного ключа = %q, ожидался сам ключ", got)
+	}
+}
+
+func TestE2ELanguageFromTelegramAndOverride(t *testing.T) {
+	h := newE2EHarness(t)
+
+	message := h.newMessage(e2ePlayerID)
+	message.From.LanguageCode = "en"
+	message.Text = "/start"
+	message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(message.Text)}}
+	h.api.PushUpdate(tgbotapi.Update{Message: message})
+	h.expect(t, "sendMessage", e2ePlayerID, "Enter a code word")
+
+	h.sendText(e2ePlayerID, "/lang ru")
+	h.expect(t, "sendMessage", e2ePlayerID, "Язык интерфейса: 🇷🇺 Русский")
+
+	h.sendText(e2ePlayerID, "несуществующий")
+	h.expect(t, "sendMessage", e2ePlayerID, "не найден")
+
+	h.sendText(e2ePlayerID, "/lang auto")
+	h.expect(t, "sendMessage", e2ePlayerID, "English")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Ключи, под которыми в журнал пишутся координаты пользователей.
+// Без LOG_LEVEL=debug они округляются, чтобы по журналу нельзя было восстановить точный маршрут игрока.
+var coordinateKeys = map[string]bool{
+	"lat": true,
+	"lon": true,
+}
+
+// Точность координат в журнале вне режима отладки: два знака после запятой - около километра
+const redactedCoordinatePrecision = 100
+
+// setupLogging настраивает slog по LOG_LEVEL (debug, info, warn, error) и LOG_FORMAT (text, json)
+// и возвращает true, если включен режим отладки
+func setupLogging() bool {
+	level := slog.LevelInfo
+	levelValue := os.Getenv("LOG_LEVEL")
+	invalidLevel := levelValue != "" && level.UnmarshalText([]byte(levelValue)) != nil
+	if invalidLevel {
+		level = slog.LevelInfo
+	}
+	debug := level <= slog.LevelDebug
+
+	handler := newLogHandler(os.Stderr, getEnvString("LOG_FORMAT", "text"), level, debug, collectSecrets())
+	slog.SetDefault(slog.New(handler))
+
+	if invalidLevel {
+		slog.Warn("Некорректный LOG_LEVEL, используется info", "value", levelValue)
+	}
+	return debug
+}
+
+// newLogHandler создает обработчик журнала, который скрывает секреты и (без отладки) точные координаты
+func newLogHandler(w io.Writer, format string, level slog.Level, debug bool, secrets []string) slog.Handler {
+	r := &redactor{secrets: secrets, debug: debug}
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: r.replaceAttr}
+
+	if strings.EqualFold(format, "json") {
+		return slog.NewJSONHandler(w, options)
+	}
+	return slog.NewTextHandler(w, options)
+}
+
+// collectSecrets возвращает значения, которые не должны попадать в журнал
+func collectSecrets() []string {
+	var secrets []string
+	for _, key := range []string{"BOT_TOKEN", "WEBHOOK_SECRET_TOKEN"} {
+		if value := os.Getenv(key); value != "" {
+			secrets = append(secrets, value)
+		}
+	}
+
+	// Пароль может оказаться в тексте ошибки подключения к PostgreSQL
+	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
+		if u, err := url.Parse(databaseURL); err == nil && u.User != nil {
+			if password, ok := u.User.Password(); ok && password != "" {
+				secrets = append(secrets, password)
+			}
+		}
+	}
+	return secrets
+}
+
+// redactor переписывает атрибуты записи журнала перед выводом
+type redactor struct {
+	secrets []string
+	debug   bool
+}
+
+func (r *redactor) replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if coordinateKeys[a.Key] && !r.debug {
+		if value, ok := a.Value.Any().(float64); ok {
+			return slog.Float64(a.Key, math.Round(value*redactedCoordinatePrecision)/redactedCoordinatePrecision)
+		}
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, r.redact(a.Value.String()))
+	case slog.KindAny:
+		// Ошибки HTTP-клиента содержат URL запроса вместе с токеном бота
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, r.redact(err.Error()))
+		}
+	}
+	return a
+}
+
+func (r *redactor) redact(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, "[скрыто]")
+	}
+	return s
+}
+
+// fatal записывает ошибку в журнал и завершает процесс
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// telegramLogger направляет журнал библиотеки Bot API в slog
+type telegramLogger struct {
+	level slog.Level
+}
+
+func (l telegramLogger) Println(v ...interface{}) {
+	slog.Log(context.Background(), l.level, strings.TrimSpace(fmt.Sprintln(v...)), "component", "telegram-bot-api")
+}
+
+func (l telegramLogger) Printf(format string, v ...interface{}) {
+	slog.Log(context.Background(), l.level, strings.TrimSpace(fmt.Sprintf(format, v...)), "component", "telegram-bot-api")
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
@@ -12,7 +17,9 @@ import (
 
 type Bot struct {
 	API      *tgbotapi.BotAPI
-	DB       *Database
+	Outbox   *Outbox
+	Tracker  *LocationTracker
+	DB       Store
 	AdminIDs []int64
 	Config   *Config
 }
@@ -21,76 +28,187 @@ type Config struct {
 	TargetDistanceMeters      float64
 	UpdateIntervalSeconds     int
 	LiveLocationDurationHours int
+	MessagesPerSecond         int
+	Workers                   int
+	WorkerQueueSize           int
+	MaxLocationAccuracyMeters float64
+	LocationSmoothingWindow   int
+	ArrivalConfirmations      int
+	MaxPlausibleSpeedKmh      float64
+	NearbyRadiusMeters        float64
+	BlockSuspiciousFinds      bool
+	Webhook                   WebhookConfig
+	MetricsListen             string
 }
 
 func main() {
 	// Загружаем переменные окружения (опционально для локальной разработки)
-	err := godotenv.Load()
-	if err != nil {
-		log.Printf("Предупреждение: .env файл не найден (%v), используем переменные окружения", err)
+	envErr := godotenv.Load()
+	debug := setupLogging()
+	if envErr != nil {
+		slog.Warn(".env файл не найден, используем переменные окружения", "error", envErr)
+	}
+
+	// Подкоманды командной строки работают без токена бота
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			if err := runExportCommand(os.Args[2:]); err != nil {
+				fatal("Ошибка выгрузки тайников", "error", err)
+			}
+			return
+		case "simulate":
+			if err := runSimulateCommand(os.Args[2:]); err != nil {
+				fatal("Ошибка симуляции", "error", err)
+			}
+			return
+		case "migrate":
+			if err := runMigrateCommand(os.Args[2:]); err != nil {
+				fatal("Ошибка миграции схемы", "error", err)
+			}
+			return
+		}
 	}
 
 	// Получаем токен бота
 	botToken := os.Getenv("BOT_TOKEN")
 	if botToken == "" {
-		log.Fatal("BOT_TOKEN не установлен в .env файле")
+		fatal("BOT_TOKEN не установлен в .env файле")
 	}
 
 	// Получаем ID администраторов (поддерживаем как старый ADMIN_ID, так и новый ADMIN_IDS)
 	adminIDs, err := parseAdminIDs()
 	if err != nil {
-		log.Fatal("Ошибка парсинга ID администраторов: ", err)
+		fatal("Ошибка парсинга ID администраторов", "error", err)
 	}
 	if len(adminIDs) == 0 {
-		log.Fatal("Не указан ни один ID администратора. Установите ADMIN_ID или ADMIN_IDS в .env файле")
+		fatal("Не указан ни один ID администратора. Установите ADMIN_ID или ADMIN_IDS в .env файле")
 	}
 
 	// Создаем конфигурацию
-	config := &Config{
-		TargetDistanceMeters:      getEnvFloat("TARGET_DISTANCE_METERS", 200),
-		UpdateIntervalSeconds:     getEnvInt("UPDATE_INTERVAL_SECONDS", 5),
-		LiveLocationDurationHours: getEnvInt("LIVE_LOCATION_DURATION_HOURS", 1),
-	}
+	config := loadConfig()
 
 	// Инициализируем бота
-	bot, err := tgbotapi.NewBotAPI(botToken)
+	// Журнал библиотеки Bot API идет через slog; сырые запросы и ответы пишутся только в режиме отладки
+	if debug {
+		tgbotapi.SetLogger(telegramLogger{level: slog.LevelDebug})
+	} else {
+		tgbotapi.SetLogger(telegramLogger{level: slog.LevelWarn})
+	}
+
+	// Запросы к Bot API проходят через клиент, собирающий метрики.
+	// TELEGRAM_API_ENDPOINT позволяет направить бота на локальный Bot API сервер или тестовую заглушку.
+	apiEndpoint := getEnvString("TELEGRAM_API_ENDPOINT", tgbotapi.APIEndpoint)
+	bot, err := tgbotapi.NewBotAPIWithClient(botToken, apiEndpoint, newInstrumentedClient(&http.Client{}))
 	if err != nil {
-		log.Fatal("Ошибка создания бота: ", err)
+		fatal("Ошибка создания бота", "error", err)
 	}
 
-	bot.Debug = true
-	log.Printf("Авторизован как %s", bot.Self.UserName)
+	bot.Debug = debug
+	slog.Info("Авторизован в Telegram", "username", bot.Self.UserName)
 
 	// Инициализируем базу данных
-	databasePath := os.Getenv("DATABASE_PATH")
-	if databasePath == "" {
-		databasePath = "geocaching.db"
-	}
-
-	db, err := NewDatabase(databasePath)
+	db, err := OpenStore()
 	if err != nil {
-		log.Fatal("Ошибка инициализации базы данных: ", err)
+		fatal("Ошибка инициализации базы данных", "error", err)
 	}
 	defer db.Close()
 
 	// Создаем экземпляр бота
-	geocachingBot := &Bot{
-		API:      bot,
+	geocachingBot := NewBot(bot, db, adminIDs, config)
+
+	// Служебный HTTP-сервер с метриками и проверками здоровья
+	var health *HealthServer
+	if config.MetricsListen != "" {
+		registerSessionGauge(db)
+		health = NewHealthServer(db, !config.Webhook.Enabled())
+		go func() {
+			if err := health.ListenAndServe(config.MetricsListen); err != nil {
+				fatal("Ошибка сервера метрик", "error", err)
+			}
+		}()
+	}
+
+	// Приостанавливаем поиск, если трансляция геопозиции давно не обновлялась
+	go geocachingBot.runSessionJanitor()
+
+	// Обновления одного пользователя обрабатываются по порядку, разных пользователей - параллельно
+	dispatcher := NewDispatcher(config.Workers, config.WorkerQueueSize, geocachingBot.handleUpdate)
+
+	slog.Info("Бот запущен", "admins", len(adminIDs), "webhook", config.Webhook.Enabled())
+	if health != nil {
+		health.SetReady(true)
+	}
+
+	// SIGINT/SIGTERM останавливают прием обновлений, после чего бот дорабатывает уже принятые
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if config.Webhook.Enabled() {
+		if err := geocachingBot.runWebhook(ctx, config.Webhook, dispatcher); err != nil {
+			fatal("Ошибка сервера вебхука", "error", err)
+		}
+	} else {
+		go func() {
+			<-ctx.Done()
+			bot.StopReceivingUpdates()
+		}()
+		geocachingBot.runPolling(dispatcher)
+	}
+
+	slog.Info("Остановка бота")
+	if health != nil {
+		health.SetReady(false)
+	}
+	dispatcher.Stop()
+	geocachingBot.Outbox.Close()
+}
+
+// NewBot создает бота поверх клиента Bot API и хранилища
+func NewBot(api *tgbotapi.BotAPI, db Store, adminIDs []int64, config *Config) *Bot {
+	return &Bot{
+		API:      api,
+		Outbox:   NewOutbox(api, config.MessagesPerSecond, time.Duration(config.UpdateIntervalSeconds)*time.Second),
+		Tracker:  NewLocationTracker(config),
 		DB:       db,
 		AdminIDs: adminIDs,
 		Config:   config,
 	}
+}
+
+// runPolling получает обновления через long polling, пока не будет вызван API.StopReceivingUpdates
+func (b *Bot) runPolling(dispatcher *Dispatcher) {
+	// Снимаем вебхук, если он остался от предыдущего запуска
+	if _, err := b.API.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		slog.Error("Ошибка удаления вебхука", "error", err)
+	}
 
-	// Запускаем обработку обновлений
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
-	updates := bot.GetUpdatesChan(u)
-
-	log.Printf("Бот запущен с %d администратором(ами)...", len(adminIDs))
-
+	updates := b.API.GetUpdatesChan(u)
 	for update := range updates {
-		go geocachingBot.handleUpdate(update)
+		dispatcher.Dispatch(update)
+	}
+}
+
+// loadConfig читает настройки бота из переменных окружения
+func loadConfig() *Config {
+	return &Config{
+		TargetDistanceMeters:      getEnvFloat("TARGET_DISTANCE_METERS", 200),
+		UpdateIntervalSeconds:     getEnvInt("UPDATE_INTERVAL_SECONDS", 5),
+		LiveLocationDurationHours: getEnvInt("LIVE_LOCATION_DURATION_HOURS", 1),
+		MessagesPerSecond:         getEnvInt("MESSAGES_PER_SECOND", 25),
+		Workers:                   getEnvInt("WORKERS", 8),
+		WorkerQueueSize:           getEnvInt("WORKER_QUEUE_SIZE", 100),
+		MaxLocationAccuracyMeters: getEnvFloat("MAX_LOCATION_ACCURACY_METERS", 100),
+		LocationSmoothingWindow:   getEnvInt("LOCATION_SMOOTHING_WINDOW", 3),
+		ArrivalConfirmations:      getEnvInt("ARRIVAL_CONFIRMATIONS", 2),
+		MaxPlausibleSpeedKmh:      getEnvFloat("MAX_PLAUSIBLE_SPEED_KMH", 150),
+		NearbyRadiusMeters:        getEnvFloat("NEARBY_RADIUS_METERS", 5000),
+		BlockSuspiciousFinds:      getEnvString("BLOCK_SUSPICIOUS_FINDS", "") == "true",
+		Webhook:                   loadWebhookConfig(),
+		MetricsListen:             os.Getenv("METRICS_LISTEN"),
 	}
 }
 
@@ -131,6 +249,11 @@ func parseAdminIDs() ([]int64, error) {
 	return adminIDs, nil
 }
 
+// getDatabasePath возвращает путь к файлу базы данных
+func getDatabasePath() string {
+	return getEnvString("DATABASE_PATH", "geocaching.db")
+}
+
 func getEnvString(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,525 @@
+package main
+
+// Каталог сообщений на английском языке. Используется для клиентов Telegram
+// с любым языком, кроме русского, и по команде /lang en.
+var messagesEn = map[string]string{
+	// Общее
+	"error.generic":     "Something went wrong. Please try again.",
+	"format.datetime":   "2006-01-02 15:04",
+	"button.prev":       "◀️ Back",
+	"button.next":       "Next ▶️",
+	"media.send_failed": "Sorry, the media file could not be loaded.",
+
+	// Единицы измерения
+	"unit.meters":        "%d m",
+	"unit.km":            "%.1f km",
+	"unit.feet":          "%d ft",
+	"unit.miles":         "%.1f mi",
+	"unit.seconds":       "%d sec",
+	"unit.minutes":       "%d min",
+	"unit.hours_minutes": "%d h %02d min",
+
+	// Навигация
+	"direction.north":           "North",
+	"direction.northeast":       "Northeast",
+	"direction.east":            "East",
+	"direction.southeast":       "Southeast",
+	"direction.south":           "South",
+	"direction.southwest":       "Southwest",
+	"direction.west":            "West",
+	"direction.northwest":       "Northwest",
+	"direction.north-northeast": "North-northeast",
+	"direction.east-northeast":  "East-northeast",
+	"direction.east-southeast":  "East-southeast",
+	"direction.south-southeast": "South-southeast",
+	"direction.south-southwest": "South-southwest",
+	"direction.west-southwest":  "West-southwest",
+	"direction.west-northwest":  "West-northwest",
+	"direction.north-northwest": "North-northwest",
+	"nav.message_compact":       "%s *%s* %s\n%s📏 Distance: *%s*",
+	"nav.message": ` ═══ NAVIGATION ═══
+%s
+
+   %s *%s* %s
+%s
+📏 Distance: *%s*
+
+═══════════════════`,
+
+	// История находок
+	"history.empty":         "📭 You haven't found any caches yet.\n\n🔍 Enter a code word to start searching!",
+	"history.header":        "🏆 Your finds (%d), page %d of %d:\n\n",
+	"history.cache_deleted": "cache deleted",
+	"history.media_hint":    "\nTap a cache to see its media file again.",
+	"history.media_deleted": "The cache was deleted, its media file is no longer available.",
+	"history.media_caption": "🔑 %s - found %s",
+
+	// Рейтинг и статистика
+	"top.period_day":  "OF THE DAY",
+	"top.period_week": "OF THE WEEK",
+	"top.period_all":  "OF ALL TIME",
+	"top.player":      "Player %d",
+	"top.button_day":  "Day",
+	"top.button_week": "Week",
+	"top.button_all":  "All time",
+	"top.header":      " ═══ TOP %s ═══\n\n",
+	"top.empty":       "📭 Nobody has found a cache in this period yet.\n",
+	"count.caches":    "%d cache|%d caches",
+	"count.players":   "%d player|%d players",
+	"me.message": ` ═══ MY STATS ═══
+
+🏆 Found: *%s*
+⚡ Fastest find: *%s*
+👣 Total distance: *%s*
+📊 Leaderboard rank: *%d* of %s
+
+═══════════════════`,
+
+	// Приостановка и возобновление поиска
+	"session.paused": `⏸ The search is paused: your live location has not updated for %s.
+
+▶️ /resume - continue searching for the same cache
+🔍 Or enter a new code word`,
+	"resume.already_active": "🧭 The search is already running. Turn on live location sharing to get hints.\n\n/stop - stop searching",
+	"resume.nothing":        "ℹ️ There is no paused search.\n\n🔍 Enter a code word to search for a cache.",
+	"resume.cache_deleted":  "😔 The cache you were looking for has been deleted.\n\n🔍 Enter a new code word to search.",
+	"resume.resumed":        "▶️ Search resumed: %s\n\n📍 Turn live location sharing back on to continue.",
+	"trail.target_name":     "%s, stage %d of %d",
+
+	// Параметры тайника
+	"details.not_set":   "not set",
+	"details.summary":   "🎯 Detection radius: %s\n🧩 Difficulty: %s\n⛰ Terrain: %s",
+	"details.skip_hint": "\n\nSend «-» to skip.",
+	"details.reset_hint": `
+
+Send «-» to reset the value.
+/stop - cancel editing`,
+	"details.ask_radius":          "🎯 Enter the detection radius in meters (%d-%d). For example, 30 for a park or 150 for a forest with poor GPS.\n\nDefault: %s.",
+	"details.ask_difficulty":      "🧩 Rate the search difficulty from 1 to 5:",
+	"details.ask_terrain":         "⛰ Rate the terrain difficulty from 1 to 5:",
+	"details.ask_description":     "📝 Write a cache description that players will see before they start searching.",
+	"details.invalid_radius":      "The radius must be a number from %d to %d meters. Please try again:",
+	"details.invalid_rating":      "Please choose a rating from 1 to 5 using the buttons below.",
+	"details.invalid_description": "Please send the description as text or «-».",
+	"details.updated":             "✅ The settings of cache «%s» have been updated.\n\n%s",
+	"cache.save_failed":           "Failed to save the cache. Please try again.",
+
+	// Медиафайлы
+	"media.none":                  "not attached",
+	"media.photo":                 "photo",
+	"media.video":                 "video",
+	"media.video_note":            "video note",
+	"media.accusative_photo":      "a photo",
+	"media.accusative_video":      "a video",
+	"media.accusative_video_note": "a video note",
+
+	// Каталог тайников администратора
+	"catalog.cache_not_found":     "Cache not found. It may have been deleted already.",
+	"catalog.code_word_required":  "Specify a code word: /%s <code word>",
+	"catalog.code_word_not_found": "🔍 No cache with this code word was found.",
+	"catalog.trail_stage":         "🔑 %s is a trail stage. Stages cannot be edited or deleted apart from their trail.",
+	"list.empty":                  "📭 There are no caches yet.\n\nCreate the first one with /create",
+	"list.header":                 "📋 Caches (%d), page %d of %d:\n\n",
+	"list.hint":                   "\nTap a cache to open its card.",
+	"card.media_failed":           "⚠️ Failed to load the cache media file.",
+	"card.message": `🗂 Cache #%d
+
+🔑 Code word: %s
+📍 Coordinates: %.6f, %.6f
+📱 Media file: %s
+👤 Creator: %d
+📅 Created: %s
+🏆 Times found: %d
+
+%s`,
+	"delete.confirm":          "🗑 Delete cache «%s»?\n\n⚠️ Active searches for this cache will be stopped. This cannot be undone.",
+	"delete.button_yes":       "✅ Yes, delete",
+	"delete.button_no":        "❌ Cancel",
+	"delete.failed":           "Failed to delete the cache. Please try again.",
+	"delete.done":             "🗑 Cache «%s» deleted.",
+	"delete.cancelled":        "Deletion cancelled.",
+	"edit.prompt":             "✏️ What would you like to change in cache «%s»?",
+	"edit.button_code":        "✏️ Code word",
+	"edit.button_location":    "📍 Location",
+	"edit.button_media":       "🖼 Media file",
+	"edit.button_puzzle":      "❓ Question",
+	"edit.button_radius":      "🎯 Radius",
+	"edit.button_description": "📝 Description",
+	"edit.button_difficulty":  "🧩 Difficulty",
+	"edit.button_terrain":     "⛰ Terrain",
+	"edit.button_delete":      "🗑 Delete",
+	"edit.ask_code":           "🔑 Enter a new code word for cache «%s»:",
+	"edit.ask_location":       "📍 Send the new location of the cache.",
+	"edit.ask_media":          "📷🎥 Send a new photo, video or video note of the place:",
+	"edit.cancel_hint":        "\n\n/stop - cancel editing",
+	"edit.cache_missing":      "Cache not found. It may have been deleted. Editing cancelled.",
+	"edit.code_changed":       "✅ Code word changed: %s → %s",
+	"edit.location_changed":   "✅ Cache «%s» moved to %.6f, %.6f",
+	"edit.media_changed":      "✅ The media file of cache «%s» was replaced with %s.",
+
+	// Создание тайника
+	"button.send_location":       "📍 Send location",
+	"create.ask_code_word":       "🔑 Come up with a code word for the new cache:",
+	"create.code_word_too_short": "The code word must be at least 3 characters long. Please try again:",
+	"create.code_word_taken":     "This code word already exists! Come up with another one:",
+	"create.ask_location":        "📍 Great! Now send the location where the cache will be hidden.\n\n💡 A regular location (not live sharing) is fine for creating a cache.",
+	"create.location_required":   "Please send a location using the button below.",
+	"create.restart":             "Something went wrong. Please run /create again.",
+	"create.ask_media":           "📷🎥 Now send a photo, video or video note of this place:",
+	"create.media_required":      "Please send a photo, video or video note.",
+	"create.failed":              "Failed to create the cache. Please try again.",
+	"create.done": `✅ Cache created!
+
+🔑 Code word: %s
+📍 Coordinates: %.6f, %.6f
+📱 Media file: %s
+
+Players can now find this cache by entering the code word.
+
+A few optional settings remain.`,
+
+	// Поиск и навигация
+	"user.welcome": `🗺️ Welcome to GeoCaching Bot!
+
+🔍 Enter a code word to search for a cache:
+
+🗺 Don't know a code word? /nearby shows public caches near you.
+
+💡 Tip: the code word must be at least 3 characters long`,
+	"user.unknown_command": `🤔 Unknown command.
+
+Enter a code word to search for a cache or use the commands:
+/nearby - caches nearby
+/resume - continue a paused search
+/history - my finds
+/top - leaderboard
+/me - my stats
+/lang - interface language
+/settings - navigation settings
+/stop - stop searching`,
+	"search.too_short": "The code word must be at least 3 characters long.",
+	"search.not_found": "🔍 No cache with this code word was found.\n\nCheck the spelling and try again.",
+	"search.failed":    "Something went wrong while searching. Please try again.",
+	"search.found": `🎯 Cache found: %s
+
+%s
+
+📍 Turn on live location sharing to start searching`,
+	"nav.header":            "🧭 Direction to the cache:\n\n%s",
+	"nav.poor_accuracy":     "📡 Your location is not accurate enough yet (±%d m). Navigation will start as soon as your phone refines it - try moving to an open area.",
+	"nav.location_required": "Please send your location to continue searching.\n\nUse /stop to stop searching.",
+	"nav.static_location": `❌ Received a static location!
+
+📍 Navigation needs LIVE location sharing:
+
+1️⃣ Tap the paperclip 📎 in the input field
+2️⃣ Choose "Location" 🗺️
+3️⃣ Choose "Share My Live Location" ⏱️
+4️⃣ Pick a duration and tap "Share"
+
+⚠️ Use LIVE location sharing, not a regular location!`,
+	"found.no_media":           "🎉 Congratulations! You found the cache: %s\n\n🏆 Search complete! Enter a new code word for the next cache.",
+	"found.here_is_photo":      "📷 Here is a photo of the place:",
+	"found.here_is_video":      "🎥 Here is a video of the place:",
+	"found.here_is_video_note": "🎥 Here is a video note of the place:",
+	"found.congrats": `🎉 Congratulations! You found the cache: %s
+
+%s
+
+💡 You can stop sharing your location and start searching for a new cache!`,
+	"found.caption":           "🏆 Search complete! Enter a new code word for the next cache.",
+	"found.photo_failed":      "Sorry, the photo of the place could not be loaded.",
+	"found.video_failed":      "Sorry, the video of the place could not be loaded.",
+	"found.video_note_failed": "Sorry, the video note of the place could not be loaded.",
+
+	// Остановка и команды администратора
+	"stop.user": "🛑 Search stopped.\n\nEnter a new code word to start searching.",
+	"stop.trail": `🛑 Trail creation cancelled.
+
+You can:
+• /create_trail - create a new trail
+• /create - create a new cache`,
+	"stop.details": "🛑 Settings were interrupted. The cache is saved; settings and the question can be changed later with /edit.",
+	"stop.puzzle":  "🛑 Question setup cancelled. The cache is saved; a question can be added later with /edit.",
+	"stop.import":  "🛑 Cache import cancelled.",
+	"stop.edit": `🛑 Cache editing cancelled.
+
+You can:
+• /list - open the cache list
+• /create - create a new cache`,
+	"stop.create": `🛑 Cache creation cancelled.
+
+You can:
+• /create - create a new cache
+• Enter a code word to search for a cache`,
+	"stop.admin_search": `🛑 Search stopped.
+
+You can:
+• /create - create a new cache
+• Enter a code word to search for a cache`,
+	"stop.nothing": `ℹ️ Nothing to stop.
+
+You can:
+• /create - create a new cache
+• Enter a code word to search for a cache`,
+	"admin.no_session": "Use /create to create a new cache.",
+	"admin.unknown_command": `Unknown admin command. Available commands:
+/start - main menu
+/create - create a new cache
+/create_trail - create a multi-stage trail
+/list - cache list
+/cache <code> - cache card
+/edit <code> - edit a cache
+/delete <code> - delete a cache
+/export - export caches to a file
+/import - import caches from a file
+/history - my finds
+/top - leaderboard
+/me - my stats
+/suspicious - suspicious locations
+/nearby - caches nearby
+/resume - continue a paused search
+/lang - interface language
+/settings - navigation settings
+/stop - cancel the current operation
+/help - help`,
+	"admin.welcome": `👑 Welcome, administrator!
+
+📋 **Available commands:**
+• /start or /help - show this menu
+• /create - create a new cache
+• /create_trail - create a multi-stage trail (/done - finish)
+• /list - list all caches
+• /cache <code> - cache card
+• /edit <code> - change the code word, location, media file or question
+• /delete <code> - delete a cache
+• /export [gpx|geojson|csv] - export caches to a file
+• /import - import caches from GPX, GeoJSON or CSV
+• /history - your finds
+• /top [day|week|all] - leaderboard
+• /me - your stats
+• /suspicious [player ID] - suspicious player locations
+• /nearby - public caches nearby
+• /resume - continue a paused search
+• /lang [ru|en|auto] - interface language
+• /settings - units, bearing and navigation view
+• /stop - cancel cache creation/editing/search
+
+🎯 **Available modes:**
+
+🔧 **Admin mode:**
+Use the commands above to manage caches
+
+🔍 **Test mode:**
+• Enter a code word to search for a cache
+• Full navigation, just like regular players
+• Photos, videos and video notes are supported
+
+💡 Modes switch automatically!`,
+
+	// Маршруты
+	"count.stages":             "%d stage|%d stages",
+	"trail.ask_code_word":      "🧭 Creating a multi-stage trail.\n\n🔑 Come up with a code word for the trail:",
+	"trail.code_word_hash":     "A trail code word cannot contain the # character. Come up with another one:",
+	"trail.create_failed":      "Failed to create the trail. Please try again.",
+	"trail.ask_location":       "📍 Stage %d: send the location of the point.",
+	"trail.done_hint":          "\n\n✅ /done - finish creating the trail",
+	"trail.draft_missing":      "Trail not found. Start again with /create_trail.",
+	"trail.location_required":  "Please send the stage location or /done to finish the trail.",
+	"trail.ask_media":          "📷🎥 Send a photo, video or video note of this stage:",
+	"trail.stage_failed":       "Failed to create the stage. Please try again.",
+	"trail.ask_clue":           "💡 Write a hint the player will get at stage %d (for example, where to look for the next point).\n\nSend «-» if no hint is needed.",
+	"trail.clue_required":      "Please send the hint text or «-».",
+	"trail.stage_saved":        "✅ Stage %d saved.",
+	"trail.no_draft":           "ℹ️ No trail is being created. Use /create_trail.",
+	"trail.finish_stage_first": "Finish the current trail stage first.",
+	"trail.too_few_stages":     "A trail needs at least %d stage. Add the next stage:|A trail needs at least %d stages. Add the next stage:",
+	"trail.published": `✅ Trail created!
+
+🔑 Code word: %s
+🧭 The trail has %s
+
+Players can start the trail by entering the code word.`,
+	"trail.resumed": `🧭 Continuing the trail: %s
+
+🎯 Stage %d of %d
+
+📍 Turn on live location sharing to continue searching`,
+	"trail.started": `🧭 Trail found: %s
+
+🎯 The trail has %s. Starting with the first one!
+
+📍 Turn on live location sharing to start searching`,
+	"trail.stage_reached": "🎉 Stage %d of %d of trail «%s» completed!",
+	"trail.media_failed":  "Sorry, the stage media file could not be loaded.",
+	"trail.completed":     "🏆 Trail «%s» completed! Time: %s\n\nEnter a new code word for the next search.",
+	"trail.next_stage":    "➡️ Next stage: %d of %d. Keep sharing your live location - navigation will update automatically.",
+
+	// Вопросы тайников
+	"puzzle.button_none":       "🚫 No question",
+	"puzzle.button_text":       "✍️ Text answer",
+	"puzzle.button_choice":     "🔘 Multiple choice",
+	"puzzle.button_number":     "🔢 Number",
+	"puzzle.ask_kind":          "❓ Add a question that must be answered at the cache location to get the media file?\n\nChoose the question type:",
+	"puzzle.none_saved":        "✅ Cache «%s» will open right on arrival, without a question.",
+	"puzzle.kind_required":     "Please choose the question type using the buttons below.",
+	"puzzle.ask_question":      "✍️ Write the question text:",
+	"puzzle.restart":           "Something went wrong. Start the question setup again with /edit.",
+	"puzzle.question_required": "Please write the question text.",
+	"puzzle.ask_answer_choice": `🔘 Send the answer options, one per line. Mark the correct option with an asterisk at the start, for example:
+
+Red
+*Blue
+Green`,
+	"puzzle.ask_answer_number":  "🔢 Send the correct answer as a number. You can add an allowed tolerance after a space, for example: 42 0.5",
+	"puzzle.ask_answer_text":    "✍️ Send the correct answer. If several answers are accepted, separate them with «|», for example: oak|oak tree",
+	"puzzle.answer_required":    "Please send the correct answer.",
+	"puzzle.several_correct":    "Only one option can be correct. Please try again:",
+	"puzzle.too_few_options":    "At least two options are needed, and one of them must be marked with an asterisk. Please try again:",
+	"puzzle.invalid_number":     "Could not read the number. Example: 42 or 42 0.5",
+	"puzzle.invalid_tolerance":  "Could not read the tolerance. Example: 42 0.5",
+	"puzzle.ask_attempts":       "🔁 How many attempts should a player get? Send a number, or 0 for unlimited attempts.",
+	"puzzle.invalid_attempts":   "Please send a whole number (0 - unlimited).",
+	"puzzle.attempts_unlimited": "unlimited",
+	"puzzle.saved": `✅ The question for cache «%s» is saved.
+
+❓ %s
+🔁 Attempts: %s`,
+	"puzzle.locked":               "🔒 You are at the spot, but you have used up all attempts to answer this cache's question.\n\nEnter another code word for a new search.",
+	"puzzle.question":             "📍 You are at the spot! Answer the question to open the cache:\n\n❓ %s",
+	"puzzle.attempts_left":        "%d attempt left|%d attempts left",
+	"puzzle.answer_hint":          "\n\n✍️ Send your answer as a message.",
+	"puzzle.text_answer_required": "✍️ Send your answer to the cache question as text, or /stop to stop searching.",
+	"puzzle.correct":              "✅ Correct!",
+	"puzzle.out_of_attempts":      "❌ Wrong. No attempts left - the cache stays locked.\n\nEnter another code word for a new search.",
+	"puzzle.wrong":                "❌ Wrong.",
+	"puzzle.try_again":            "Please try again.",
+
+	// Выгрузка и загрузка тайников
+	"export.choose_format": "📤 Choose the export format:",
+	"export.empty":         "📭 There are no caches yet, nothing to export.",
+	"export.caption":       "📤 Exported: %s",
+	"export.send_failed":   "Failed to send the file. Please try again.",
+	"import.prompt": `📥 Send a file with caches as a document: .gpx, .geojson or .csv.
+
+For CSV the first line is the header:
+code_word,latitude,longitude,file_id,file_type,radius_meters,difficulty,terrain,description,navigation_mode,is_public
+
+Only code_word, latitude and longitude are required. The media file can be given as file_id or attached later with /edit.
+
+/stop - cancel the import`,
+	"import.document_required":  "Please send the file as a document (.gpx, .geojson or .csv) or /stop to cancel.",
+	"import.unsupported_format": "Unsupported file format. Send a .gpx, .geojson or .csv file.",
+	"import.too_large":          "The file is too large. The maximum size is %d MB.",
+	"import.download_failed":    "Failed to download the file. Please try again.",
+	"import.parse_failed":       "❌ Failed to parse the file: %s\n\nFix the file and send it again, or /stop to cancel.",
+	"import.no_rows":            "The file contains no caches. Send another file or /stop to cancel.",
+	"import.report":             "📥 Import finished: added %d of %d.\n\n",
+	"import.more_rows": `… and %d more row
+|… and %d more rows
+`,
+	"import.list_hint": "\n/list - open the cache list",
+
+	// Строки файла импорта и причины ошибок в них
+	"import.ref.point":                    "point %d",
+	"import.ref.feature":                  "feature %d",
+	"import.ref.line":                     "line %d",
+	"import.error.code_word_short":        "code word is shorter than 3 characters",
+	"import.error.latitude":               "invalid latitude %v",
+	"import.error.longitude":              "invalid longitude %v",
+	"import.error.no_coordinates":         "coordinates are missing",
+	"import.error.file_type":              "unknown media type %q",
+	"import.error.radius":                 "radius must be between %d and %d m",
+	"import.error.difficulty":             "difficulty and terrain must be between 1 and 5",
+	"import.error.navigation_mode":        "unknown navigation mode %q",
+	"import.error.invalid_gpx":            "invalid GPX: %v",
+	"import.error.gpx_version":            "unsupported GPX version %q, 1.0 or 1.1 is expected",
+	"import.error.invalid_geojson":        "invalid GeoJSON: %v",
+	"import.error.not_feature_collection": "a GeoJSON FeatureCollection is expected",
+	"import.error.not_point":              "geometry must be a Point",
+	"import.error.invalid_csv":            "invalid CSV: %v",
+	"import.error.missing_column":         "the CSV header has no %s column",
+	"import.error.invalid_record":         "invalid CSV row: %v",
+	"import.error.latitude_value":         "invalid latitude %q",
+	"import.error.longitude_value":        "invalid longitude %q",
+	"import.error.radius_value":           "invalid radius %q",
+	"import.error.difficulty_value":       "invalid difficulty %q",
+	"import.error.terrain_value":          "invalid terrain %q",
+	"import.error.public_value":           "invalid is_public value %q",
+	"import.error.duplicate":              "code word already exists",
+	"import.error.save_failed":            "failed to save, please try again",
+
+	// Язык интерфейса
+	"lang.prompt":       "🌐 Interface language: %s\n\nChoose a language:",
+	"lang.button_auto":  "⚙️ Same as Telegram",
+	"lang.unknown":      "Unknown language. Available: %s or auto.",
+	"lang.changed":      "✅ Interface language: %s",
+	"lang.changed_auto": "✅ The interface language will follow your Telegram settings. Currently: %s",
+
+	// Настройки отображения
+	"settings.prompt":         "⚙️ Navigation settings\n\nTap a setting to change it. New settings apply to the next navigation message.",
+	"settings.on":             "on",
+	"settings.off":            "off",
+	"settings.units_metric":   "meters",
+	"settings.units_imperial": "feet and miles",
+	"settings.button_units":   "📏 Units: %s",
+	"settings.button_bearing": "🧭 Bearing in degrees: %s",
+	"settings.button_points":  "🎯 Direction precision: %d points",
+	"settings.button_compact": "🗜 Compact mode: %s",
+
+	// Указания поворота
+	"turn.ahead":        "⬆️ Straight ahead",
+	"turn.slight_right": "↗️ Bear right",
+	"turn.right":        "➡️ Turn right",
+	"turn.sharp_right":  "↘️ Sharp right",
+	"turn.around":       "🔄 Turn around",
+	"turn.sharp_left":   "↙️ Sharp left",
+	"turn.left":         "⬅️ Turn left",
+	"turn.slight_left":  "↖️ Bear left",
+
+	// Подозрительные геопозиции
+	"anticheat.find_refused":  "🚫 The find was not counted: your location during the search looked unreliable.\n\nIf this is a mistake, please contact the organizer.",
+	"suspicious.invalid_user": "❌ Specify a numeric player ID, for example: /suspicious 123456789",
+	"suspicious.empty":        "✅ No suspicious events.",
+	"suspicious.header":       "🚨 Suspicious events (%d):\n\n",
+	"suspicious.jump":         "⚡ Jump of %s at %d km/h",
+	"suspicious.start_inside": "🎯 Search started %s from the cache",
+	"suspicious.forwarded":    "↪️ Forwarded location",
+	"suspicious.user_hint":    "\n/suspicious <player ID> - events of one player",
+
+	// Режимы навигации
+	"navigation.mode_compass":  "🧭 Compass and distance",
+	"navigation.mode_distance": "📏 Distance only",
+	"navigation.mode_hotcold":  "🔥 Hotter-colder",
+	"details.navigation":       "\n🧭 Navigation: %s",
+	"details.ask_navigation": `🧭 Choose how players navigate to the cache:
+
+• Compass and distance - direction and distance to the cache
+• Distance only - no direction
+• Hotter-colder - only «warmer» or «colder» compared with the previous location
+
+Default: compass and distance.`,
+	"details.invalid_navigation": "Please choose a navigation mode using the buttons below.",
+	"edit.button_navigation":     "🧭 Navigation",
+	"nav.distance_only":          "📏 Distance to the cache: *%s*\n\nNo direction hints here - search by distance!",
+	"nav.hotcold_start":          "🌡 The search has begun! Start moving and I'll tell you whether you're getting warmer or colder.",
+	"nav.hotcold_warmer":         "🔥 Warmer!",
+	"nav.hotcold_colder":         "❄️ Colder!",
+	"nav.hotcold_same":           "😐 Neither warmer nor colder.",
+
+	// Тайники поблизости
+	"details.public":           "\n👁 Listed in /nearby",
+	"details.ask_public":       "👁 Show this cache to nearby players in /nearby? A public cache can be found without knowing the code word.\n\nDefault: no.",
+	"details.public_yes":       "👁 Yes, show it",
+	"details.public_no":        "🙈 No",
+	"details.invalid_public":   "Please choose an answer using the buttons below.",
+	"edit.button_public":       "👁 Visibility",
+	"nearby.ask_location":      "📍 Send your location and I'll show public caches within %s.",
+	"nearby.search_active":     "You are already searching for a cache. Finish the search or stop it with /stop to see nearby caches.",
+	"nearby.empty":             "🔍 There are no public caches within %s.\n\nIf you know a code word, just enter it.",
+	"nearby.header":            "🗺 Caches nearby:\n\n",
+	"nearby.footer":            "\nTap a cache to start searching.",
+	"nearby.button_search":     "🔍 %s",
+	"nearby.hotcold":           "🌡 somewhere within %s",
+	"nearby.cache_unavailable": "This cache is no longer available. Send /nearby to refresh the list.",
+}
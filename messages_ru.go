@@ -0,0 +1,526 @@
+package main
+
+// Каталог сообщений на русском языке. Русский - язык по умолчанию:
+// если в другом каталоге нет ключа, используется сообщение отсюда.
+var messagesRu = map[string]string{
+	// Общее
+	"error.generic":     "Произошла ошибка. Попробуйте еще раз.",
+	"format.datetime":   "02.01.2006 15:04",
+	"button.prev":       "◀️ Назад",
+	"button.next":       "Вперед ▶️",
+	"media.send_failed": "К сожалению, не удалось загрузить медиафайл.",
+
+	// Единицы измерения
+	"unit.meters":        "%d м",
+	"unit.km":            "%.1f км",
+	"unit.feet":          "%d фут|%d фута|%d футов",
+	"unit.miles":         "%.1f мили",
+	"unit.seconds":       "%d сек",
+	"unit.minutes":       "%d мин",
+	"unit.hours_minutes": "%d ч %02d мин",
+
+	// Навигация
+	"direction.north":           "Север",
+	"direction.northeast":       "Северо-восток",
+	"direction.east":            "Восток",
+	"direction.southeast":       "Юго-восток",
+	"direction.south":           "Юг",
+	"direction.southwest":       "Юго-запад",
+	"direction.west":            "Запад",
+	"direction.northwest":       "Северо-запад",
+	"direction.north-northeast": "Север-северо-восток",
+	"direction.east-northeast":  "Восток-северо-восток",
+	"direction.east-southeast":  "Восток-юго-восток",
+	"direction.south-southeast": "Юг-юго-восток",
+	"direction.south-southwest": "Юг-юго-запад",
+	"direction.west-southwest":  "Запад-юго-запад",
+	"direction.west-northwest":  "Запад-северо-запад",
+	"direction.north-northwest": "Север-северо-запад",
+	"nav.message_compact":       "%s *%s* %s\n%s📏 Расстояние: *%s*",
+	"nav.message": ` ═══ НАВИГАЦИЯ ═══
+%s
+
+   %s *%s* %s
+%s
+📏 Расстояние: *%s*
+
+═══════════════════`,
+
+	// История находок
+	"history.empty":         "📭 Вы пока не нашли ни одного тайника.\n\n🔍 Введите кодовое слово, чтобы начать поиск!",
+	"history.header":        "🏆 Ваши находки (%d), страница %d из %d:\n\n",
+	"history.cache_deleted": "тайник удален",
+	"history.media_hint":    "\nНажмите на тайник, чтобы снова посмотреть медиафайл.",
+	"history.media_deleted": "Тайник удален, медиафайл больше недоступен.",
+	"history.media_caption": "🔑 %s - найден %s",
+
+	// Рейтинг и статистика
+	"top.period_day":  "ЗА СУТКИ",
+	"top.period_week": "ЗА НЕДЕЛЮ",
+	"top.period_all":  "ЗА ВСЕ ВРЕМЯ",
+	"top.player":      "Игрок %d",
+	"top.button_day":  "Сутки",
+	"top.button_week": "Неделя",
+	"top.button_all":  "Все время",
+	"top.header":      " ═══ ТОП %s ═══\n\n",
+	"top.empty":       "📭 За этот период тайники еще никто не нашел.\n",
+	"count.caches":    "%d тайник|%d тайника|%d тайников",
+	"count.players":   "%d игрока|%d игроков|%d игроков",
+	"me.message": ` ═══ МОЯ СТАТИСТИКА ═══
+
+🏆 Найдено: *%s*
+⚡ Самая быстрая находка: *%s*
+👣 Пройдено всего: *%s*
+📊 Место в рейтинге: *%d* из %s
+
+═══════════════════`,
+
+	// Приостановка и возобновление поиска
+	"session.paused": `⏸ Поиск тайника приостановлен: трансляция геопозиции не обновлялась %s.
+
+▶️ /resume - продолжить поиск того же тайника
+🔍 Или введите новое кодовое слово`,
+	"resume.already_active": "🧭 Поиск уже идет. Включите трансляцию геопозиции, чтобы получать подсказки.\n\n/stop - остановить поиск",
+	"resume.nothing":        "ℹ️ Нет приостановленного поиска.\n\n🔍 Введите кодовое слово для поиска тайника.",
+	"resume.cache_deleted":  "😔 Тайник, который вы искали, был удален.\n\n🔍 Введите новое кодовое слово для поиска.",
+	"resume.resumed":        "▶️ Поиск возобновлен: %s\n\n📍 Снова включите трансляцию геопозиции, чтобы продолжить.",
+	"trail.target_name":     "%s, этап %d из %d",
+
+	// Параметры тайника
+	"details.not_set":   "не указана",
+	"details.summary":   "🎯 Радиус обнаружения: %s\n🧩 Сложность: %s\n⛰ Местность: %s",
+	"details.skip_hint": "\n\nОтправьте «-», чтобы пропустить.",
+	"details.reset_hint": `
+
+Отправьте «-», чтобы сбросить значение.
+/stop - отменить редактирование`,
+	"details.ask_radius":          "🎯 Укажите радиус обнаружения в метрах (%d-%d). Например, 30 для парка или 150 для леса с плохим GPS.\n\nПо умолчанию: %s.",
+	"details.ask_difficulty":      "🧩 Оцените сложность поиска от 1 до 5:",
+	"details.ask_terrain":         "⛰ Оцените сложность местности от 1 до 5:",
+	"details.ask_description":     "📝 Напишите описание тайника, которое игроки увидят перед началом поиска.",
+	"details.invalid_radius":      "Радиус должен быть числом от %d до %d метров. Попробуйте еще раз:",
+	"details.invalid_rating":      "Пожалуйста, выберите оценку от 1 до 5 кнопкой ниже.",
+	"details.invalid_description": "Пожалуйста, отправьте описание текстом или «-».",
+	"details.updated":             "✅ Параметры тайника «%s» обновлены.\n\n%s",
+	"cache.save_failed":           "Ошибка при сохранении тайника. Попробуйте еще раз.",
+
+	// Медиафайлы
+	"media.none":                  "не прикреплен",
+	"media.photo":                 "фотография",
+	"media.video":                 "видео",
+	"media.video_note":            "видео-заметка",
+	"media.accusative_photo":      "фотографию",
+	"media.accusative_video":      "видео",
+	"media.accusative_video_note": "видео-заметку",
+
+	// Каталог тайников администратора
+	"catalog.cache_not_found":     "Тайник не найден. Возможно, он уже удален.",
+	"catalog.code_word_required":  "Укажите кодовое слово: /%s <кодовое слово>",
+	"catalog.code_word_not_found": "🔍 Тайник с таким кодовым словом не найден.",
+	"catalog.trail_stage":         "🔑 %s - этап маршрута. Этапы не изменяются и не удаляются отдельно от маршрута.",
+	"list.empty":                  "📭 Тайников пока нет.\n\nСоздайте первый командой /create",
+	"list.header":                 "📋 Тайники (%d), страница %d из %d:\n\n",
+	"list.hint":                   "\nНажмите на тайник, чтобы открыть его карточку.",
+	"card.media_failed":           "⚠️ Не удалось загрузить медиафайл тайника.",
+	"card.message": `🗂 Тайник #%d
+
+🔑 Кодовое слово: %s
+📍 Координаты: %.6f, %.6f
+📱 Медиафайл: %s
+👤 Создатель: %d
+📅 Создан: %s
+🏆 Найден раз: %d
+
+%s`,
+	"delete.confirm":          "🗑 Удалить тайник «%s»?\n\n⚠️ Активные поиски этого тайника будут прекращены. Действие необратимо.",
+	"delete.button_yes":       "✅ Да, удалить",
+	"delete.button_no":        "❌ Отмена",
+	"delete.failed":           "Ошибка при удалении тайника. Попробуйте еще раз.",
+	"delete.done":             "🗑 Тайник «%s» удален.",
+	"delete.cancelled":        "Удаление отменено.",
+	"edit.prompt":             "✏️ Что изменить в тайнике «%s»?",
+	"edit.button_code":        "✏️ Кодовое слово",
+	"edit.button_location":    "📍 Точка",
+	"edit.button_media":       "🖼 Медиафайл",
+	"edit.button_puzzle":      "❓ Вопрос",
+	"edit.button_radius":      "🎯 Радиус",
+	"edit.button_description": "📝 Описание",
+	"edit.button_difficulty":  "🧩 Сложность",
+	"edit.button_terrain":     "⛰ Местность",
+	"edit.button_delete":      "🗑 Удалить",
+	"edit.ask_code":           "🔑 Введите новое кодовое слово для тайника «%s»:",
+	"edit.ask_location":       "📍 Отправьте новую геолокацию тайника.",
+	"edit.ask_media":          "📷🎥 Отправьте новую фотографию, видео или видео-заметку места:",
+	"edit.cancel_hint":        "\n\n/stop - отменить редактирование",
+	"edit.cache_missing":      "Тайник не найден. Возможно, он был удален. Редактирование отменено.",
+	"edit.code_changed":       "✅ Кодовое слово изменено: %s → %s",
+	"edit.location_changed":   "✅ Точка тайника «%s» перенесена: %.6f, %.6f",
+	"edit.media_changed":      "✅ Медиафайл тайника «%s» заменен на %s.",
+
+	// Создание тайника
+	"button.send_location":       "📍 Отправить геолокацию",
+	"create.ask_code_word":       "🔑 Придумайте кодовое слово для нового тайника:",
+	"create.code_word_too_short": "Кодовое слово должно содержать минимум 3 символа. Попробуйте еще раз:",
+	"create.code_word_taken":     "Кодовое слово уже существует! Придумайте другое:",
+	"create.ask_location":        "📍 Отлично! Теперь отправьте геолокацию места, где будет спрятан тайник.\n\n💡 Для создания тайника подходит обычная геопозиция (не трансляция).",
+	"create.location_required":   "Пожалуйста, отправьте геолокацию, используя кнопку ниже.",
+	"create.restart":             "Произошла ошибка. Попробуйте команду /create заново.",
+	"create.ask_media":           "📷🎥 Теперь отправьте фотографию, видео или видео-заметку этого места:",
+	"create.media_required":      "Пожалуйста, отправьте фотографию, видео или видео-заметку.",
+	"create.failed":              "Ошибка при создании кэша. Попробуйте еще раз.",
+	"create.done": `✅ Тайник успешно создан!
+
+🔑 Кодовое слово: %s
+📍 Координаты: %.6f, %.6f
+📱 Медиафайл: %s
+
+Теперь пользователи могут найти этот тайник, введя кодовое слово.
+
+Осталось несколько необязательных параметров.`,
+
+	// Поиск и навигация
+	"user.welcome": `🗺️ Добро пожаловать в GeoCaching Bot!
+
+🔍 Введите кодовое слово для поиска тайника:
+
+🗺 Не знаете кодового слова? /nearby покажет публичные тайники рядом с вами.
+
+💡 Совет: кодовое слово должно содержать минимум 3 символа`,
+	"user.unknown_command": `🤔 Неизвестная команда.
+
+Введите кодовое слово для поиска тайника или воспользуйтесь командами:
+/nearby - тайники поблизости
+/resume - продолжить приостановленный поиск
+/history - мои находки
+/top - рейтинг игроков
+/me - моя статистика
+/lang - язык интерфейса
+/settings - настройки навигации
+/stop - остановить поиск`,
+	"search.too_short": "Кодовое слово должно содержать минимум 3 символа.",
+	"search.not_found": "🔍 Тайник с таким кодовым словом не найден.\n\nПроверьте правильность написания и попробуйте еще раз.",
+	"search.failed":    "Произошла ошибка при поиске. Попробуйте еще раз.",
+	"search.found": `🎯 Тайник найден: %s
+
+%s
+
+📍 Для начала поиска включите трансляцию геопозиции`,
+	"nav.header":            "🧭 Направление к тайнику:\n\n%s",
+	"nav.poor_accuracy":     "📡 Геопозиция пока слишком неточная (±%d м). Навигация начнется, как только телефон уточнит местоположение - выйдите на открытое место.",
+	"nav.location_required": "Пожалуйста, отправьте геолокацию для продолжения поиска.\n\nИспользуйте /stop для остановки поиска.",
+	"nav.static_location": `❌ Получена статичная геопозиция!
+
+📍 Для навигации нужна ТРАНСЛЯЦИЯ геопозиции:
+
+1️⃣ Нажмите на скрепку 📎 в поле ввода
+2️⃣ Выберите "Геопозиция" 🗺️
+3️⃣ Выберите "Транслировать геопозицию" ⏱️
+4️⃣ Установите время и нажмите "Поделиться"
+
+⚠️ Используйте именно ТРАНСЛЯЦИЮ, а не обычную геопозицию!`,
+	"found.no_media":           "🎉 Поздравляем! Вы нашли тайник: %s\n\n🏆 Поиск завершен! Введите новое кодовое слово для следующего тайника.",
+	"found.here_is_photo":      "📷 Вот фотография места:",
+	"found.here_is_video":      "🎥 Вот видео места:",
+	"found.here_is_video_note": "🎥 Вот видео-заметка места:",
+	"found.congrats": `🎉 Поздравляем! Вы нашли тайник: %s
+
+%s
+
+💡 Вы можете остановить передачу геолокации и начать поиск нового тайника!`,
+	"found.caption":           "🏆 Поиск завершен! Введите новое кодовое слово для следующего тайника.",
+	"found.photo_failed":      "К сожалению, не удалось загрузить фотографию места.",
+	"found.video_failed":      "К сожалению, не удалось загрузить видео места.",
+	"found.video_note_failed": "К сожалению, не удалось загрузить видео-заметку места.",
+
+	// Остановка и команды администратора
+	"stop.user": "🛑 Поиск тайника остановлен.\n\nВведите новое кодовое слово для начала поиска.",
+	"stop.trail": `🛑 Создание маршрута отменено.
+
+Вы можете:
+• /create_trail - создать новый маршрут
+• /create - создать новый тайник`,
+	"stop.details": "🛑 Настройка параметров прервана. Тайник сохранен; параметры и вопрос можно изменить позже через /edit.",
+	"stop.puzzle":  "🛑 Настройка вопроса отменена. Тайник сохранен; вопрос можно добавить позже через /edit.",
+	"stop.import":  "🛑 Импорт тайников отменен.",
+	"stop.edit": `🛑 Редактирование тайника отменено.
+
+Вы можете:
+• /list - открыть список тайников
+• /create - создать новый тайник`,
+	"stop.create": `🛑 Создание тайника отменено.
+
+Вы можете:
+• /create - создать новый тайник
+• Ввести кодовое слово для поиска тайника`,
+	"stop.admin_search": `🛑 Поиск тайника остановлен.
+
+Вы можете:
+• /create - создать новый тайник
+• Ввести кодовое слово для поиска тайника`,
+	"stop.nothing": `ℹ️ Нет активных процессов для остановки.
+
+Вы можете:
+• /create - создать новый тайник
+• Ввести кодовое слово для поиска тайника`,
+	"admin.no_session": "Введите команду /create для создания нового тайника.",
+	"admin.unknown_command": `Неизвестная команда администратора. Доступные команды:
+/start - главное меню
+/create - создать новый тайник
+/create_trail - создать маршрут из нескольких этапов
+/list - список тайников
+/cache <код> - карточка тайника
+/edit <код> - изменить тайник
+/delete <код> - удалить тайник
+/export - выгрузить тайники в файл
+/import - загрузить тайники из файла
+/history - мои находки
+/top - рейтинг игроков
+/me - моя статистика
+/suspicious - подозрительные геопозиции
+/nearby - тайники поблизости
+/resume - продолжить приостановленный поиск
+/lang - язык интерфейса
+/settings - настройки навигации
+/stop - отменить текущую операцию
+/help - справка`,
+	"admin.welcome": `👑 Добро пожаловать, администратор!
+
+📋 **Доступные команды:**
+• /start или /help - показать это меню
+• /create - создать новый тайник
+• /create_trail - создать маршрут из нескольких этапов (/done - завершить)
+• /list - список всех тайников
+• /cache <код> - карточка тайника
+• /edit <код> - изменить кодовое слово, точку, медиафайл или вопрос
+• /delete <код> - удалить тайник
+• /export [gpx|geojson|csv] - выгрузить тайники в файл
+• /import - загрузить тайники из GPX, GeoJSON или CSV
+• /history - история ваших находок
+• /top [day|week|all] - рейтинг игроков
+• /me - ваша статистика
+• /suspicious [ID игрока] - подозрительные геопозиции игроков
+• /nearby - публичные тайники поблизости
+• /resume - продолжить приостановленный поиск
+• /lang [ru|en|auto] - язык интерфейса
+• /settings - единицы, азимут и вид навигации
+• /stop - отменить создание/редактирование/поиск тайника
+
+🎯 **Доступные режимы:**
+
+🔧 **Режим администратора:**
+Используйте команды выше для управления тайниками
+
+🔍 **Режим тестирования:**
+• Введите кодовое слово для поиска тайника
+• Полная навигация как у обычных пользователей
+• Поддержка фото, видео и видео-заметок
+
+💡 Переключение между режимами происходит автоматически!`,
+
+	// Маршруты
+	"count.stages":             "%d этап|%d этапа|%d этапов",
+	"trail.ask_code_word":      "🧭 Создание маршрута из нескольких этапов.\n\n🔑 Придумайте кодовое слово для маршрута:",
+	"trail.code_word_hash":     "Кодовое слово маршрута не может содержать символ #. Придумайте другое:",
+	"trail.create_failed":      "Ошибка при создании маршрута. Попробуйте еще раз.",
+	"trail.ask_location":       "📍 Этап %d: отправьте геолокацию точки.",
+	"trail.done_hint":          "\n\n✅ /done - завершить создание маршрута",
+	"trail.draft_missing":      "Маршрут не найден. Начните заново командой /create_trail.",
+	"trail.location_required":  "Пожалуйста, отправьте геолокацию этапа или /done для завершения маршрута.",
+	"trail.ask_media":          "📷🎥 Отправьте фотографию, видео или видео-заметку этого этапа:",
+	"trail.stage_failed":       "Ошибка при создании этапа. Попробуйте еще раз.",
+	"trail.ask_clue":           "💡 Напишите подсказку, которую игрок получит на этапе %d (например, где искать следующую точку).\n\nОтправьте «-», если подсказка не нужна.",
+	"trail.clue_required":      "Пожалуйста, отправьте текст подсказки или «-».",
+	"trail.stage_saved":        "✅ Этап %d сохранен.",
+	"trail.no_draft":           "ℹ️ Нет маршрута в процессе создания. Используйте /create_trail.",
+	"trail.finish_stage_first": "Сначала завершите текущий этап маршрута.",
+	"trail.too_few_stages":     "В маршруте должен быть минимум %d этап. Добавьте следующий этап:|В маршруте должно быть минимум %d этапа. Добавьте следующий этап:|В маршруте должно быть минимум %d этапов. Добавьте следующий этап:",
+	"trail.published": `✅ Маршрут успешно создан!
+
+🔑 Кодовое слово: %s
+🧭 В маршруте %s
+
+Игроки могут начать маршрут, введя кодовое слово.`,
+	"trail.resumed": `🧭 Продолжаем маршрут: %s
+
+🎯 Этап %d из %d
+
+📍 Для продолжения поиска включите трансляцию геопозиции`,
+	"trail.started": `🧭 Маршрут найден: %s
+
+🎯 В маршруте %s. Начинаем с первого!
+
+📍 Для начала поиска включите трансляцию геопозиции`,
+	"trail.stage_reached": "🎉 Этап %d из %d маршрута «%s» пройден!",
+	"trail.media_failed":  "К сожалению, не удалось загрузить медиафайл этапа.",
+	"trail.completed":     "🏆 Маршрут «%s» полностью пройден! Время прохождения: %s\n\nВведите новое кодовое слово для следующего поиска.",
+	"trail.next_stage":    "➡️ Следующий этап: %d из %d. Продолжайте транслировать геопозицию - навигация обновится автоматически.",
+
+	// Вопросы тайников
+	"puzzle.button_none":       "🚫 Без вопроса",
+	"puzzle.button_text":       "✍️ Текстовый ответ",
+	"puzzle.button_choice":     "🔘 Выбор варианта",
+	"puzzle.button_number":     "🔢 Число",
+	"puzzle.ask_kind":          "❓ Добавить вопрос, на который нужно ответить на месте тайника, чтобы получить медиафайл?\n\nВыберите тип вопроса:",
+	"puzzle.none_saved":        "✅ Тайник «%s» будет открываться сразу по прибытии, без вопроса.",
+	"puzzle.kind_required":     "Пожалуйста, выберите тип вопроса кнопкой ниже.",
+	"puzzle.ask_question":      "✍️ Напишите текст вопроса:",
+	"puzzle.restart":           "Произошла ошибка. Начните настройку вопроса заново через /edit.",
+	"puzzle.question_required": "Пожалуйста, напишите текст вопроса.",
+	"puzzle.ask_answer_choice": `🔘 Отправьте варианты ответа, каждый с новой строки. Отметьте верный вариант звездочкой в начале, например:
+
+Красный
+*Синий
+Зеленый`,
+	"puzzle.ask_answer_number":  "🔢 Отправьте верный ответ числом. Можно указать допустимую погрешность через пробел, например: 42 0.5",
+	"puzzle.ask_answer_text":    "✍️ Отправьте верный ответ. Если подходят несколько вариантов, перечислите их через «|», например: дуб|дубок",
+	"puzzle.answer_required":    "Пожалуйста, отправьте верный ответ.",
+	"puzzle.several_correct":    "Верным может быть только один вариант. Попробуйте еще раз:",
+	"puzzle.too_few_options":    "Нужно минимум два варианта, и один из них должен быть отмечен звездочкой. Попробуйте еще раз:",
+	"puzzle.invalid_number":     "Не удалось распознать число. Пример: 42 или 42 0.5",
+	"puzzle.invalid_tolerance":  "Не удалось распознать погрешность. Пример: 42 0.5",
+	"puzzle.ask_attempts":       "🔁 Сколько попыток дать игроку? Отправьте число или 0, если попытки не ограничены.",
+	"puzzle.invalid_attempts":   "Пожалуйста, отправьте целое число (0 - без ограничений).",
+	"puzzle.attempts_unlimited": "без ограничений",
+	"puzzle.saved": `✅ Вопрос для тайника «%s» сохранен.
+
+❓ %s
+🔁 Попыток: %s`,
+	"puzzle.locked":               "🔒 Вы на месте, но попытки ответить на вопрос этого тайника уже исчерпаны.\n\nВведите другое кодовое слово для нового поиска.",
+	"puzzle.question":             "📍 Вы на месте! Чтобы открыть тайник, ответьте на вопрос:\n\n❓ %s",
+	"puzzle.attempts_left":        "Осталась %d попытка|Осталось %d попытки|Осталось %d попыток",
+	"puzzle.answer_hint":          "\n\n✍️ Отправьте ответ сообщением.",
+	"puzzle.text_answer_required": "✍️ Отправьте ответ на вопрос тайника текстом или /stop для остановки поиска.",
+	"puzzle.correct":              "✅ Верно!",
+	"puzzle.out_of_attempts":      "❌ Неверно. Попытки исчерпаны - тайник остается закрытым.\n\nВведите другое кодовое слово для нового поиска.",
+	"puzzle.wrong":                "❌ Неверно.",
+	"puzzle.try_again":            "Попробуйте еще раз.",
+
+	// Выгрузка и загрузка тайников
+	"export.choose_format": "📤 Выберите формат выгрузки тайников:",
+	"export.empty":         "📭 Тайников пока нет, выгружать нечего.",
+	"export.caption":       "📤 Выгружено: %s",
+	"export.send_failed":   "Не удалось отправить файл. Попробуйте еще раз.",
+	"import.prompt": `📥 Отправьте файл с тайниками документом: .gpx, .geojson или .csv.
+
+Для CSV первая строка - заголовок:
+code_word,latitude,longitude,file_id,file_type,radius_meters,difficulty,terrain,description,navigation_mode,is_public
+
+Обязательны только code_word, latitude и longitude. Медиафайл можно указать через file_id или прикрепить позже через /edit.
+
+/stop - отменить импорт`,
+	"import.document_required":  "Пожалуйста, отправьте файл документом (.gpx, .geojson или .csv) или /stop для отмены.",
+	"import.unsupported_format": "Неподдерживаемый формат файла. Отправьте .gpx, .geojson или .csv.",
+	"import.too_large":          "Файл слишком большой. Максимальный размер - %d МБ.",
+	"import.download_failed":    "Не удалось загрузить файл. Попробуйте еще раз.",
+	"import.parse_failed":       "❌ Не удалось разобрать файл: %s\n\nИсправьте файл и отправьте его еще раз или /stop для отмены.",
+	"import.no_rows":            "В файле нет ни одного тайника. Отправьте другой файл или /stop для отмены.",
+	"import.report":             "📥 Импорт завершен: добавлено %d из %d.\n\n",
+	"import.more_rows": `… и еще %d строка
+|… и еще %d строки
+|… и еще %d строк
+`,
+	"import.list_hint": "\n/list - открыть список тайников",
+
+	// Строки файла импорта и причины ошибок в них
+	"import.ref.point":                    "точка %d",
+	"import.ref.feature":                  "объект %d",
+	"import.ref.line":                     "строка %d",
+	"import.error.code_word_short":        "кодовое слово короче 3 символов",
+	"import.error.latitude":               "некорректная широта %v",
+	"import.error.longitude":              "некорректная долгота %v",
+	"import.error.no_coordinates":         "не указаны координаты",
+	"import.error.file_type":              "неизвестный тип медиафайла %q",
+	"import.error.radius":                 "радиус должен быть от %d до %d м",
+	"import.error.difficulty":             "сложность и местность должны быть от 1 до 5",
+	"import.error.navigation_mode":        "неизвестный режим навигации %q",
+	"import.error.invalid_gpx":            "некорректный GPX: %v",
+	"import.error.gpx_version":            "неподдерживаемая версия GPX %q, ожидается 1.0 или 1.1",
+	"import.error.invalid_geojson":        "некорректный GeoJSON: %v",
+	"import.error.not_feature_collection": "ожидается GeoJSON FeatureCollection",
+	"import.error.not_point":              "геометрия должна быть точкой (Point)",
+	"import.error.invalid_csv":            "некорректный CSV: %v",
+	"import.error.missing_column":         "в заголовке CSV нет колонки %s",
+	"import.error.invalid_record":         "некорректная строка CSV: %v",
+	"import.error.latitude_value":         "некорректная широта %q",
+	"import.error.longitude_value":        "некорректная долгота %q",
+	"import.error.radius_value":           "некорректный радиус %q",
+	"import.error.difficulty_value":       "некорректная сложность %q",
+	"import.error.terrain_value":          "некорректная местность %q",
+	"import.error.public_value":           "некорректный признак публичности %q",
+	"import.error.duplicate":              "кодовое слово уже существует",
+	"import.error.save_failed":            "ошибка сохранения, попробуйте еще раз",
+
+	// Язык интерфейса
+	"lang.prompt":       "🌐 Язык интерфейса: %s\n\nВыберите язык:",
+	"lang.button_auto":  "⚙️ Как в Telegram",
+	"lang.unknown":      "Неизвестный язык. Доступны: %s или auto.",
+	"lang.changed":      "✅ Язык интерфейса: %s",
+	"lang.changed_auto": "✅ Язык интерфейса будет выбираться по настройкам Telegram. Сейчас: %s",
+
+	// Настройки отображения
+	"settings.prompt":         "⚙️ Настройки навигации\n\nНажмите на параметр, чтобы изменить его. Новые настройки применятся к следующему навигационному сообщению.",
+	"settings.on":             "вкл",
+	"settings.off":            "выкл",
+	"settings.units_metric":   "метры",
+	"settings.units_imperial": "футы и мили",
+	"settings.button_units":   "📏 Единицы: %s",
+	"settings.button_bearing": "🧭 Азимут в градусах: %s",
+	"settings.button_points":  "🎯 Точность направления: %d румбов",
+	"settings.button_compact": "🗜 Компактный режим: %s",
+
+	// Указания поворота
+	"turn.ahead":        "⬆️ Прямо",
+	"turn.slight_right": "↗️ Чуть правее",
+	"turn.right":        "➡️ Поверните направо",
+	"turn.sharp_right":  "↘️ Резко направо",
+	"turn.around":       "🔄 Развернитесь",
+	"turn.sharp_left":   "↙️ Резко налево",
+	"turn.left":         "⬅️ Поверните налево",
+	"turn.slight_left":  "↖️ Чуть левее",
+
+	// Подозрительные геопозиции
+	"anticheat.find_refused":  "🚫 Находка не засчитана: геопозиция во время поиска выглядела недостоверной.\n\nЕсли это ошибка, обратитесь к организатору.",
+	"suspicious.invalid_user": "❌ Укажите числовой ID игрока, например: /suspicious 123456789",
+	"suspicious.empty":        "✅ Подозрительных событий нет.",
+	"suspicious.header":       "🚨 Подозрительные события (%d):\n\n",
+	"suspicious.jump":         "⚡ Скачок на %s со скоростью %d км/ч",
+	"suspicious.start_inside": "🎯 Поиск начат в %s от тайника",
+	"suspicious.forwarded":    "↪️ Пересланная геопозиция",
+	"suspicious.user_hint":    "\n/suspicious <ID игрока> - события одного игрока",
+
+	// Режимы навигации
+	"navigation.mode_compass":  "🧭 Компас и расстояние",
+	"navigation.mode_distance": "📏 Только расстояние",
+	"navigation.mode_hotcold":  "🔥 Горячо-холодно",
+	"details.navigation":       "\n🧭 Навигация: %s",
+	"details.ask_navigation": `🧭 Выберите режим навигации к тайнику:
+
+• Компас и расстояние - направление и расстояние до тайника
+• Только расстояние - без направления
+• Горячо-холодно - только «теплее» или «холоднее» по сравнению с предыдущей точкой
+
+По умолчанию: компас и расстояние.`,
+	"details.invalid_navigation": "Пожалуйста, выберите режим навигации кнопкой ниже.",
+	"edit.button_navigation":     "🧭 Навигация",
+	"nav.distance_only":          "📏 До тайника: *%s*\n\nНаправление не подсказывается - ищите по расстоянию!",
+	"nav.hotcold_start":          "🌡 Поиск начался! Двигайтесь, а я подскажу, теплее или холоднее.",
+	"nav.hotcold_warmer":         "🔥 Теплее!",
+	"nav.hotcold_colder":         "❄️ Холоднее!",
+	"nav.hotcold_same":           "😐 Ни теплее, ни холоднее.",
+
+	// Тайники поблизости
+	"details.public":           "\n👁 Виден в /nearby",
+	"details.ask_public":       "👁 Показывать тайник игрокам поблизости в /nearby? Публичный тайник можно найти без кодового слова.\n\nПо умолчанию: нет.",
+	"details.public_yes":       "👁 Да, показывать",
+	"details.public_no":        "🙈 Нет",
+	"details.invalid_public":   "Пожалуйста, выберите ответ кнопкой ниже.",
+	"edit.button_public":       "👁 Видимость",
+	"nearby.ask_location":      "📍 Отправьте свою геолокацию - я покажу публичные тайники в радиусе %s.",
+	"nearby.search_active":     "Вы уже ищете тайник. Завершите поиск или остановите его командой /stop, чтобы посмотреть тайники поблизости.",
+	"nearby.empty":             "🔍 В радиусе %s нет публичных тайников.\n\nЕсли вы знаете кодовое слово, просто введите его.",
+	"nearby.header":            "🗺 Тайники поблизости:\n\n",
+	"nearby.footer":            "\nНажмите на тайник, чтобы начать поиск.",
+	"nearby.button_search":     "🔍 %s",
+	"nearby.hotcold":           "🌡 где-то в радиусе %s",
+	"nearby.cache_unavailable": "Этот тайник больше недоступен. Отправьте /nearby, чтобы обновить список.",
+}
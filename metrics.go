@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Метрики Prometheus
+var (
+	updatesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "geocaching_updates_total",
+		Help: "Полученные обновления Telegram по типам.",
+	}, []string{"type"})
+
+	searchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "geocaching_searches_total",
+		Help: "Поиски по кодовому слову: hit - тайник найден, trail - найден маршрут, miss - ничего не найдено.",
+	}, []string{"result"})
+
+	findsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "geocaching_finds_total",
+		Help: "Найденные тайники (включая этапы маршрутов).",
+	})
+
+	locationFixesDiscarded = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "geocaching_location_fixes_discarded_total",
+		Help: "Точки трансляции, отброшенные из-за плохой точности (MAX_LOCATION_ACCURACY_METERS).",
+	})
+
+	suspicionEventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "geocaching_suspicion_events_total",
+		Help: "Подозрительные события геопозиции: jump - невозможная скорость, forwarded - пересланная геопозиция, start_inside - поиск начат в радиусе тайника.",
+	}, []string{"kind"})
+
+	telegramErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "geocaching_telegram_api_errors_total",
+		Help: "Ошибки запросов к Telegram Bot API по методам.",
+	}, []string{"method"})
+
+	telegramDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "geocaching_telegram_api_duration_seconds",
+		Help:    "Длительность запросов к Telegram Bot API.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"method"})
+
+	dbDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "geocaching_db_query_duration_seconds",
+		Help:    "Длительность запросов к базе данных по типу операции.",
+		Buckets: []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 1},
+	}, []string{"operation"})
+)
+
+// registerSessionGauge публикует число активных сессий поиска; значение читается из базы при каждом сборе метрик
+func registerSessionGauge(store Store) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "geocaching_active_sessions",
+		Help: "Активные сессии поиска тайников.",
+	}, func() float64 {
+		count, err := store.CountActiveUserSessions()
+		if err != nil {
+			slog.Error("Ошибка подсчета активных сессий", "error", err)
+			return 0
+		}
+		return float64(count)
+	})
+}
+
+// updateType возвращает тип обновления для метрик
+func updateType(update tgbotapi.Update) string {
+	switch {
+	case update.Message != nil && update.Message.IsCommand():
+		return "command"
+	case update.Message != nil && update.Message.Location != nil:
+		return "location"
+	case update.Message != nil:
+		return "message"
+	case update.EditedMessage != nil && update.EditedMessage.Location != nil:
+		return "live_location"
+	case update.EditedMessage != nil:
+		return "edited_message"
+	case update.CallbackQuery != nil:
+		return "callback_query"
+	default:
+		return "other"
+	}
+}
+
+// observeDB записывает длительность запроса к базе; операция - первое слово SQL (SELECT, INSERT, ...)
+func observeDB(query string, started time.Time) {
+	operation := "OTHER"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	dbDuration.WithLabelValues(operation).Observe(time.Since(started).Seconds())
+}
+
+// lastGetUpdates - время последнего успешного getUpdates (Unix-наносекунды)
+var lastGetUpdates atomic.Int64
+
+// instrumentedClient - HTTP-клиент Bot API, который считает ошибки и длительность запросов по методам
+type instrumentedClient struct {
+	client tgbotapi.HTTPClient
+}
+
+func newInstrumentedClient(client tgbotapi.HTTPClient) *instrumentedClient {
+	return &instrumentedClient{client: client}
+}
+
+func (c *instrumentedClient) Do(req *http.Request) (*http.Response, error) {
+	// Путь запроса - /bot<токен>/<метод>: в метки попадает только метод
+	method := path.Base(req.URL.Path)
+
+	started := time.Now()
+	resp, err := c.client.Do(req)
+	telegramDuration.WithLabelValues(method).Observe(time.Since(started).Seconds())
+
+	if err != nil || resp.StatusCode >= 400 {
+		telegramErrorsTotal.WithLabelValues(method).Inc()
+	} else if method == "getUpdates" {
+		lastGetUpdates.Store(time.Now().UnixNano())
+	}
+
+	return resp, err
+}
+
+// HealthServer отдает /healthz, /readyz и /metrics
+type HealthServer struct {
+	store   Store
+	polling bool // В режиме long polling здоровье зависит и от свежести getUpdates
+
+	mu         sync.Mutex
+	ready      bool
+	readySince time.Time
+}
+
+// Сколько может пройти без успешного getUpdates, прежде чем бот считается нездоровым
+const getUpdatesStaleAfter = 3 * time.Minute
+
+func NewHealthServer(store Store, polling bool) *HealthServer {
+	return &HealthServer{store: store, polling: polling}
+}
+
+// SetReady отмечает, что бот закончил запуск и принимает обновления
+func (h *HealthServer) SetReady(ready bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ready = ready
+	h.readySince = time.Now()
+}
+
+func (h *HealthServer) isReady() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.ready
+}
+
+func (h *HealthServer) readyTime() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.readySince
+}
+
+// checkHealth возвращает список проблем; пустой список - все в порядке
+func (h *HealthServer) checkHealth() []string {
+	var problems []string
+
+	if err := h.store.Ping(); err != nil {
+		problems = append(problems, fmt.Sprintf("база данных недоступна: %v", err))
+	}
+
+	if h.polling && h.isReady() {
+		// Первый long poll может длиться до минуты: до него отсчитываем от момента запуска
+		last := h.readyTime()
+		if nanos := lastGetUpdates.Load(); nanos != 0 {
+			last = time.Unix(0, nanos)
+		}
+		if since := time.Since(last); since > getUpdatesStaleAfter {
+			problems = append(problems, fmt.Sprintf("нет успешного getUpdates уже %s", since.Round(time.Second)))
+		}
+	}
+
+	return problems
+}
+
+func (h *HealthServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if problems := h.checkHealth(); len(problems) > 0 {
+		http.Error(w, strings.Join(problems, "\n"), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+func (h *HealthServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if !h.isReady() {
+		http.Error(w, "бот запускается", http.StatusServiceUnavailable)
+		return
+	}
+	if err := h.store.Ping(); err != nil {
+		http.Error(w, fmt.Sprintf("база данных недоступна: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// Handler возвращает обработчик всех служебных эндпоинтов
+func (h *HealthServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", h.handleHealthz)
+	mux.HandleFunc("/readyz", h.handleReadyz)
+	mux.Handle("/metrics", promhttp.Handler())
+	return mux
+}
+
+// ListenAndServe запускает служебный HTTP-сервер
+func (h *HealthServer) ListenAndServe(addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           h.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	slog.Info("Метрики и проверки здоровья доступны", "addr", addr)
+	return server.ListenAndServe()
+}
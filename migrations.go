@@ -0,0 +1,314 @@
+package main
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Миграции схемы лежат в migrations/<диалект>/NNNN_название.sql и применяются по возрастанию номера.
+// Уже примененные миграции нельзя менять: изменения схемы оформляются новым файлом со следующим номером.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration - одна миграция схемы
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// MigrationState - миграция и момент ее применения (нулевое время - еще не применена)
+type MigrationState struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// Колонки, которые раньше добавлялись в существующие таблицы SQLite при запуске.
+// Базы, созданные до появления миграций, дополняются ими перед отметкой первой миграции.
+var legacyColumns = []struct{ table, column, definition string }{
+	{"caches", "find_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"user_sessions", "started_at", "DATETIME"},
+	{"user_sessions", "distance_walked", "REAL NOT NULL DEFAULT 0"},
+	{"caches", "radius_meters", "REAL NOT NULL DEFAULT 0"},
+	{"caches", "difficulty", "INTEGER NOT NULL DEFAULT 0"},
+	{"caches", "terrain", "INTEGER NOT NULL DEFAULT 0"},
+	{"caches", "description", "TEXT NOT NULL DEFAULT ''"},
+	{"user_sessions", "paused", "BOOLEAN NOT NULL DEFAULT FALSE"},
+}
+
+// dialect возвращает каталог миграций для базы
+func (d *Database) dialect() string {
+	if d.postgres {
+		return "postgres"
+	}
+	return "sqlite"
+}
+
+// loadMigrations читает встроенные миграции диалекта, отсортированные по номеру
+func loadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+		number, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("некорректное имя файла миграции: %s", entry.Name())
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("повторяющийся номер миграции %d: %s и %s", version, other, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func (d *Database) ensureMigrationsTable() error {
+	_, err := d.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	return err
+}
+
+// appliedMigrations возвращает время применения каждой отмеченной миграции
+func (d *Database) appliedMigrations() (map[int]time.Time, error) {
+	rows, err := d.query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// MigrationStatus возвращает все известные миграции с отметкой о применении
+func (d *Database) MigrationStatus() ([]MigrationState, error) {
+	migrations, err := loadMigrations(d.dialect())
+	if err != nil {
+		return nil, err
+	}
+	if err := d.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+	applied, err := d.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	for _, migration := range migrations {
+		states = append(states, MigrationState{Migration: migration, AppliedAt: applied[migration.Version]})
+	}
+	return states, nil
+}
+
+// Migrate применяет все непримененные миграции, каждую в отдельной транзакции.
+// Возвращает список примененных миграций.
+func (d *Database) Migrate() ([]Migration, error) {
+	migrations, err := loadMigrations(d.dialect())
+	if err != nil {
+		return nil, err
+	}
+
+	// База создана до появления миграций: таблицы есть, а журнала миграций еще нет
+	legacy := false
+	if !d.postgres {
+		hasCaches, err := d.sqliteTableExists("caches")
+		if err != nil {
+			return nil, err
+		}
+		hasJournal, err := d.sqliteTableExists("schema_migrations")
+		if err != nil {
+			return nil, err
+		}
+		legacy = hasCaches && !hasJournal
+	}
+
+	if err := d.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range migrations {
+		applied, err := d.applyMigration(migration, legacy && migration.Version == 1)
+		if err != nil {
+			return done, fmt.Errorf("миграция %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if applied {
+			slog.Info("Применена миграция", "version", migration.Version, "name", migration.Name)
+			done = append(done, migration)
+		}
+	}
+
+	return done, nil
+}
+
+// applyMigration применяет миграцию в транзакции, если она еще не отмечена в schema_migrations
+func (d *Database) applyMigration(migration Migration, patchLegacy bool) (bool, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// Несколько реплик могут запуститься одновременно: миграции в PostgreSQL применяются по очереди
+	if d.postgres {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('schema_migrations'))`); err != nil {
+			return false, err
+		}
+	}
+
+	var count int
+	if err := tx.QueryRow(d.rebind(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`), migration.Version).Scan(&count); err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(migration.SQL); err != nil {
+		return false, err
+	}
+
+	if patchLegacy {
+		for _, c := range legacyColumns {
+			if err := ensureColumn(tx, c.table, c.column, c.definition); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if _, err := tx.Exec(d.rebind(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`),
+		migration.Version, migration.Name, time.Now().UTC()); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (d *Database) sqliteTableExists(table string) (bool, error) {
+	var count int
+	err := d.queryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count)
+	return count > 0, err
+}
+
+// ensureColumn добавляет колонку в существующую таблицу SQLite, если её там ещё нет
+func ensureColumn(tx *sql.Tx, table, column, definition string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    bool
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// runMigrateCommand управляет схемой из командной строки:
+// geocaching-bot migrate status - показать примененные и ожидающие миграции
+// geocaching-bot migrate up     - применить ожидающие миграции
+func runMigrateCommand(args []string) error {
+	if len(args) != 1 || (args[0] != "status" && args[0] != "up") {
+		return errors.New("использование: migrate status|up")
+	}
+
+	db, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if args[0] == "up" {
+		applied, err := db.Migrate()
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Схема актуальна, новых миграций нет")
+		}
+		for _, migration := range applied {
+			fmt.Printf("Применена %04d_%s\n", migration.Version, migration.Name)
+		}
+		return nil
+	}
+
+	states, err := db.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, state := range states {
+		status := "ожидает"
+		if !state.AppliedAt.IsZero() {
+			status = "применена " + state.AppliedAt.Local().Format("02.01.2006 15:04")
+		} else {
+			pending++
+		}
+		fmt.Printf("%04d_%-30s %s\n", state.Version, state.Name, status)
+	}
+	fmt.Printf("\nОжидают применения: %d\n", pending)
+	return nil
+}
@@ -0,0 +1,103 @@
+-- Исходная схема PostgreSQL. ID пользователей Telegram не помещаются в INTEGER, поэтому BIGINT.
+
+CREATE TABLE IF NOT EXISTS caches (
+	id BIGSERIAL PRIMARY KEY,
+	code_word TEXT UNIQUE NOT NULL,
+	latitude DOUBLE PRECISION NOT NULL,
+	longitude DOUBLE PRECISION NOT NULL,
+	file_id TEXT NOT NULL,
+	file_type TEXT NOT NULL DEFAULT 'photo',
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	created_by BIGINT NOT NULL,
+	find_count INTEGER NOT NULL DEFAULT 0,
+	radius_meters DOUBLE PRECISION NOT NULL DEFAULT 0,
+	difficulty INTEGER NOT NULL DEFAULT 0,
+	terrain INTEGER NOT NULL DEFAULT 0,
+	description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+	user_id BIGINT PRIMARY KEY,
+	cache_id BIGINT NOT NULL REFERENCES caches (id),
+	last_latitude DOUBLE PRECISION,
+	last_longitude DOUBLE PRECISION,
+	last_message_id INTEGER,
+	last_message_text TEXT,
+	is_active BOOLEAN DEFAULT TRUE,
+	last_update TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	started_at TIMESTAMPTZ,
+	distance_walked DOUBLE PRECISION NOT NULL DEFAULT 0,
+	paused BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS admin_sessions (
+	user_id BIGINT PRIMARY KEY,
+	step TEXT NOT NULL,
+	code_word TEXT,
+	latitude DOUBLE PRECISION,
+	longitude DOUBLE PRECISION
+);
+
+CREATE TABLE IF NOT EXISTS trails (
+	id BIGSERIAL PRIMARY KEY,
+	code_word TEXT UNIQUE NOT NULL,
+	is_published BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	created_by BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS trail_stages (
+	trail_id BIGINT NOT NULL REFERENCES trails (id),
+	position INTEGER NOT NULL,
+	cache_id BIGINT UNIQUE NOT NULL REFERENCES caches (id),
+	clue TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (trail_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS trail_progress (
+	user_id BIGINT NOT NULL,
+	trail_id BIGINT NOT NULL REFERENCES trails (id),
+	stage_position INTEGER NOT NULL DEFAULT 0,
+	started_at TIMESTAMPTZ NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	completed_at TIMESTAMPTZ,
+	PRIMARY KEY (user_id, trail_id)
+);
+
+CREATE TABLE IF NOT EXISTS cache_puzzles (
+	cache_id BIGINT PRIMARY KEY REFERENCES caches (id),
+	kind TEXT NOT NULL,
+	question TEXT NOT NULL DEFAULT '',
+	answer TEXT NOT NULL DEFAULT '',
+	options TEXT NOT NULL DEFAULT '',
+	tolerance DOUBLE PRECISION NOT NULL DEFAULT 0,
+	max_attempts INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS puzzle_attempts (
+	user_id BIGINT NOT NULL,
+	cache_id BIGINT NOT NULL REFERENCES caches (id),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	awaiting BOOLEAN NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (user_id, cache_id)
+);
+
+CREATE TABLE IF NOT EXISTS finds (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	cache_id BIGINT NOT NULL,
+	found_at TIMESTAMPTZ NOT NULL,
+	duration_seconds BIGINT NOT NULL DEFAULT 0,
+	distance_meters DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_finds_user ON finds (user_id, found_at);
+
+CREATE INDEX IF NOT EXISTS idx_finds_found_at ON finds (found_at);
+
+CREATE TABLE IF NOT EXISTS users (
+	user_id BIGINT PRIMARY KEY,
+	username TEXT NOT NULL DEFAULT '',
+	first_name TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
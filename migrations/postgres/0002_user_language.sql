@@ -0,0 +1,5 @@
+-- Язык интерфейса: language_code - язык клиента Telegram,
+-- language - выбранный командой /lang (пусто - определять по language_code).
+
+ALTER TABLE users ADD COLUMN language_code TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN language TEXT NOT NULL DEFAULT '';
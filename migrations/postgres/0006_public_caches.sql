@@ -0,0 +1,5 @@
+-- Публичные тайники видны игрокам в /nearby без знания кодового слова.
+-- Индекс обслуживает запрос по ограничивающему прямоугольнику вокруг игрока.
+
+ALTER TABLE caches ADD COLUMN is_public BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX idx_caches_public_location ON caches (is_public, latitude, longitude);
@@ -0,0 +1,14 @@
+-- Пространственный индекс тайников: номер ячейки сетки 0.01° x 0.01°
+-- (ряд по широте * 36000 + столбец по долготе, см. gridCell в spatial.go).
+-- Поиск тайников рядом с точкой выбирает диапазоны ячеек по индексу вместо просмотра всей таблицы.
+
+ALTER TABLE caches ADD COLUMN grid_cell BIGINT NOT NULL DEFAULT 0;
+
+UPDATE caches SET grid_cell =
+	LEAST(GREATEST(FLOOR((latitude + 90) / 0.01), 0), 17999)::BIGINT * 36000 +
+	LEAST(GREATEST(FLOOR((longitude + 180) / 0.01), 0), 35999)::BIGINT;
+
+CREATE INDEX idx_caches_grid_cell ON caches (grid_cell);
+
+-- Индекс /nearby по координатам заменен сеткой
+DROP INDEX idx_caches_public_location;
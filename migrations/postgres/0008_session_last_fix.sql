@@ -0,0 +1,5 @@
+-- Время, когда в сессии была сохранена последняя принятая точка трансляции.
+-- От него считается скорость перемещения при проверке скачков: last_update
+-- обновляется и отброшенными неточными точками, а позиция при этом не меняется.
+
+ALTER TABLE user_sessions ADD COLUMN last_fix_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+-- Зависшие сессии выбираются по last_update прямо в SQL. TIMESTAMPTZ сравнивается
+-- независимо от часового пояса, поэтому в PostgreSQL достаточно индекса.
+
+CREATE INDEX idx_user_sessions_stale ON user_sessions (is_active, last_update);
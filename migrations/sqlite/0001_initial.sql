@@ -0,0 +1,109 @@
+-- Исходная схема. Для баз, созданных до появления миграций, недостающие
+-- колонки добавляются отдельно (см. legacyColumns в migrations.go).
+
+CREATE TABLE IF NOT EXISTS caches (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	code_word TEXT UNIQUE NOT NULL,
+	latitude REAL NOT NULL,
+	longitude REAL NOT NULL,
+	file_id TEXT NOT NULL,
+	file_type TEXT NOT NULL DEFAULT 'photo',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	created_by INTEGER NOT NULL,
+	find_count INTEGER NOT NULL DEFAULT 0,
+	radius_meters REAL NOT NULL DEFAULT 0,
+	difficulty INTEGER NOT NULL DEFAULT 0,
+	terrain INTEGER NOT NULL DEFAULT 0,
+	description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+	user_id INTEGER PRIMARY KEY,
+	cache_id INTEGER NOT NULL,
+	last_latitude REAL,
+	last_longitude REAL,
+	last_message_id INTEGER,
+	last_message_text TEXT,
+	is_active BOOLEAN DEFAULT TRUE,
+	last_update DATETIME DEFAULT CURRENT_TIMESTAMP,
+	started_at DATETIME,
+	distance_walked REAL NOT NULL DEFAULT 0,
+	paused BOOLEAN NOT NULL DEFAULT FALSE,
+	FOREIGN KEY (cache_id) REFERENCES caches (id)
+);
+
+CREATE TABLE IF NOT EXISTS admin_sessions (
+	user_id INTEGER PRIMARY KEY,
+	step TEXT NOT NULL,
+	code_word TEXT,
+	latitude REAL,
+	longitude REAL
+);
+
+CREATE TABLE IF NOT EXISTS trails (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	code_word TEXT UNIQUE NOT NULL,
+	is_published BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	created_by INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS trail_stages (
+	trail_id INTEGER NOT NULL,
+	position INTEGER NOT NULL,
+	cache_id INTEGER UNIQUE NOT NULL,
+	clue TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (trail_id, position),
+	FOREIGN KEY (trail_id) REFERENCES trails (id),
+	FOREIGN KEY (cache_id) REFERENCES caches (id)
+);
+
+CREATE TABLE IF NOT EXISTS trail_progress (
+	user_id INTEGER NOT NULL,
+	trail_id INTEGER NOT NULL,
+	stage_position INTEGER NOT NULL DEFAULT 0,
+	started_at DATETIME NOT NULL,
+	updated_at DATETIME NOT NULL,
+	completed_at DATETIME,
+	PRIMARY KEY (user_id, trail_id),
+	FOREIGN KEY (trail_id) REFERENCES trails (id)
+);
+
+CREATE TABLE IF NOT EXISTS cache_puzzles (
+	cache_id INTEGER PRIMARY KEY,
+	kind TEXT NOT NULL,
+	question TEXT NOT NULL DEFAULT '',
+	answer TEXT NOT NULL DEFAULT '',
+	options TEXT NOT NULL DEFAULT '',
+	tolerance REAL NOT NULL DEFAULT 0,
+	max_attempts INTEGER NOT NULL DEFAULT 0,
+	FOREIGN KEY (cache_id) REFERENCES caches (id)
+);
+
+CREATE TABLE IF NOT EXISTS puzzle_attempts (
+	user_id INTEGER NOT NULL,
+	cache_id INTEGER NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	awaiting BOOLEAN NOT NULL DEFAULT FALSE,
+	PRIMARY KEY (user_id, cache_id),
+	FOREIGN KEY (cache_id) REFERENCES caches (id)
+);
+
+CREATE TABLE IF NOT EXISTS finds (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	cache_id INTEGER NOT NULL,
+	found_at DATETIME NOT NULL,
+	duration_seconds INTEGER NOT NULL DEFAULT 0,
+	distance_meters REAL NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_finds_user ON finds (user_id, found_at);
+CREATE INDEX IF NOT EXISTS idx_finds_found_at ON finds (found_at);
+
+CREATE TABLE IF NOT EXISTS users (
+	user_id INTEGER PRIMARY KEY,
+	username TEXT NOT NULL DEFAULT '',
+	first_name TEXT NOT NULL DEFAULT '',
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,8 @@
+-- Настройки отображения навигации, выбираемые в /settings:
+-- units - metric или imperial, show_bearing - азимут в градусах,
+-- compass_points - 8 или 16 румбов, compact_navigation - без ASCII-компаса.
+
+ALTER TABLE users ADD COLUMN units TEXT NOT NULL DEFAULT 'metric';
+ALTER TABLE users ADD COLUMN show_bearing BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN compass_points INTEGER NOT NULL DEFAULT 8;
+ALTER TABLE users ADD COLUMN compact_navigation BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,19 @@
+-- Подозрительные события трансляции геопозиции для отчета /suspicious:
+-- jump - невозможная скорость между точками, forwarded - пересланная геопозиция,
+-- start_inside - поиск начат уже в радиусе обнаружения тайника.
+
+CREATE TABLE IF NOT EXISTS suspicion_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	cache_id INTEGER NOT NULL,
+	kind TEXT NOT NULL,
+	distance_meters REAL NOT NULL DEFAULT 0,
+	speed_kmh REAL NOT NULL DEFAULT 0,
+	latitude REAL NOT NULL,
+	longitude REAL NOT NULL,
+	created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_suspicion_events_user ON suspicion_events (user_id, cache_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_suspicion_events_created_at ON suspicion_events (created_at);
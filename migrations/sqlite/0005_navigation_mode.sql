@@ -0,0 +1,5 @@
+-- Режим навигации к тайнику, выбираемый при создании:
+-- compass - компас с направлением и расстоянием, distance - только расстояние,
+-- hotcold - только «теплее/холоднее» относительно предыдущей точки.
+
+ALTER TABLE caches ADD COLUMN navigation_mode TEXT NOT NULL DEFAULT 'compass';
@@ -0,0 +1,14 @@
+-- Пространственный индекс тайников: номер ячейки сетки 0.01° x 0.01°
+-- (ряд по широте * 36000 + столбец по долготе, см. gridCell в spatial.go).
+-- Поиск тайников рядом с точкой выбирает диапазоны ячеек по индексу вместо просмотра всей таблицы.
+
+ALTER TABLE caches ADD COLUMN grid_cell INTEGER NOT NULL DEFAULT 0;
+
+UPDATE caches SET grid_cell =
+	MIN(MAX(CAST((latitude + 90) / 0.01 AS INTEGER), 0), 17999) * 36000 +
+	MIN(MAX(CAST((longitude + 180) / 0.01 AS INTEGER), 0), 35999);
+
+CREATE INDEX idx_caches_grid_cell ON caches (grid_cell);
+
+-- Индекс /nearby по координатам заменен сеткой
+DROP INDEX idx_caches_public_location;
@@ -0,0 +1,7 @@
+-- Зависшие сессии выбираются по last_update прямо в SQL. Время в SQLite хранится строкой,
+-- и строки с разными часовыми поясами сравниваются неверно, поэтому last_update теперь пишется в UTC.
+-- Приводим к UTC значения, записанные раньше в локальном времени процесса.
+
+UPDATE user_sessions SET last_update = strftime('%Y-%m-%d %H:%M:%f+00:00', last_update) WHERE last_update IS NOT NULL;
+
+CREATE INDEX idx_user_sessions_stale ON user_sessions (is_active, last_update);
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// Схема, которую создавал createTables до появления миграций (вместе с колонками, добавленными через ensureColumn)
+const preMigrationSchema = `
+CREATE TABLE caches (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	code_word TEXT UNIQUE NOT NULL,
+	latitude REAL NOT NULL,
+	longitude REAL NOT NULL,
+	file_id TEXT NOT NULL,
+	file_type TEXT NOT NULL DEFAULT 'photo',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	created_by INTEGER NOT NULL,
+	find_count INTEGER NOT NULL DEFAULT 0,
+	radius_meters REAL NOT NULL DEFAULT 0,
+	difficulty INTEGER NOT NULL DEFAULT 0,
+	terrain INTEGER NOT NULL DEFAULT 0,
+	description TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE user_sessions (
+	user_id INTEGER PRIMARY KEY,
+	cache_id INTEGER NOT NULL,
+	last_latitude REAL,
+	last_longitude REAL,
+	last_message_id INTEGER,
+	last_message_text TEXT,
+	is_active BOOLEAN DEFAULT TRUE,
+	last_update DATETIME DEFAULT CURRENT_TIMESTAMP,
+	started_at DATETIME,
+	distance_walked REAL NOT NULL DEFAULT 0,
+	paused BOOLEAN NOT NULL DEFAULT FALSE,
+	FOREIGN KEY (cache_id) REFERENCES caches (id)
+);
+CREATE TABLE admin_sessions (
+	user_id INTEGER PRIMARY KEY,
+	step TEXT NOT NULL,
+	code_word TEXT,
+	latitude REAL,
+	longitude REAL
+);
+CREATE TABLE finds (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	cache_id INTEGER NOT NULL,
+	found_at DATETIME NOT NULL,
+	duration_seconds INTEGER NOT NULL DEFAULT 0,
+	distance_meters REAL NOT NULL DEFAULT 0
+);
+CREATE TABLE users (
+	user_id INTEGER PRIMARY KEY,
+	username TEXT NOT NULL DEFAULT '',
+	first_name TEXT NOT NULL DEFAULT '',
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO caches (code_word, latitude, longitude, file_id, file_type, created_by, find_count, radius_meters)
+VALUES ('старый', 55.75, 37.61, 'FILE', 'photo', 1, 3, 40);
+INSERT INTO user_sessions (user_id, cache_id, last_latitude, last_longitude, last_message_id, last_message_text)
+VALUES (7, 1, 55.7, 37.6, 10, 'текст');
+`
+
+// Самая первая схема: три таблицы без колонок, добавленных позже
+const initialSchema = `
+CREATE TABLE caches (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	code_word TEXT UNIQUE NOT NULL,
+	latitude REAL NOT NULL,
+	longitude REAL NOT NULL,
+	file_id TEXT NOT NULL,
+	file_type TEXT NOT NULL DEFAULT 'photo',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	created_by INTEGER NOT NULL
+);
+CREATE TABLE user_sessions (
+	user_id INTEGER PRIMARY KEY,
+	cache_id INTEGER NOT NULL,
+	last_latitude REAL,
+	last_longitude REAL,
+	last_message_id INTEGER,
+	last_message_text TEXT,
+	is_active BOOLEAN DEFAULT TRUE,
+	last_update DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (cache_id) REFERENCES caches (id)
+);
+CREATE TABLE admin_sessions (
+	user_id INTEGER PRIMARY KEY,
+	step TEXT NOT NULL,
+	code_word TEXT,
+	latitude REAL,
+	longitude REAL
+);
+INSERT INTO caches (code_word, latitude, longitude, file_id, file_type, created_by)
+VALUES ('старый', 55.75, 37.61, 'FILE', 'photo', 1);
+INSERT INTO user_sessions (user_id, cache_id, last_latitude, last_longitude, last_message_id, last_message_text)
+VALUES (7, 1, 55.7, 37.6, 10, 'текст');
+`
+
+// createLegacyDatabase создает файл SQLite со схемой, существовавшей до миграций
+func createLegacyDatabase(t *testing.T, schema string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(schema); err != nil {
+		t.Fatalf("создание старой схемы: %v", err)
+	}
+	return path
+}
+
+func assertFullyMigrated(t *testing.T, db *Database) {
+	t.Helper()
+
+	states, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) == 0 {
+		t.Fatal("нет ни одной миграции")
+	}
+	for _, state := range states {
+		if state.AppliedAt.IsZero() {
+			t.Errorf("миграция %04d_%s не применена", state.Version, state.Name)
+		}
+	}
+}
+
+func TestMigrateUpgradesPreMigrationDatabase(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		schema string
+	}{
+		{"схема перед появлением миграций", preMigrationSchema},
+		{"первая версия схемы", initialSchema},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := createLegacyDatabase(t, tt.schema)
+
+			db, err := NewDatabase(path)
+			if err != nil {
+				t.Fatalf("обновление базы: %v", err)
+			}
+			defer db.Close()
+
+			assertFullyMigrated(t, db)
+
+			// Старые данные на месте и читаются текущим кодом
+			cache, err := db.GetCacheByCodeWord("старый")
+			if err != nil {
+				t.Fatalf("чтение тайника: %v", err)
+			}
+			if cache.Latitude != 55.75 || cache.FileID != "FILE" {
+				t.Fatalf("данные тайника повреждены: %+v", cache)
+			}
+			session, err := db.GetUserSession(7)
+			if err != nil {
+				t.Fatalf("чтение сессии: %v", err)
+			}
+			if session.CacheID != cache.ID || session.LastMessageText != "текст" {
+				t.Fatalf("данные сессии повреждены: %+v", session)
+			}
+
+			// Ячейка сетки заполнена миграцией, и старый тайник находится поиском рядом с точкой
+			nearby, err := db.GetCachesWithinRadius(55.75, 37.61, 100, false)
+			if err != nil || len(nearby) != 1 || nearby[0].ID != cache.ID {
+				t.Fatalf("тайник не найден по сетке: %+v (ошибка %v)", nearby, err)
+			}
+
+			// Новые таблицы и колонки работают
+			cache.Description = "описание"
+			if err := db.UpdateCache(cache); err != nil {
+				t.Fatalf("обновление тайника: %v", err)
+			}
+			if err := db.CreateTrail(&Trail{CodeWord: "маршрут", CreatedBy: 1}); err != nil {
+				t.Fatalf("создание маршрута: %v", err)
+			}
+			if _, err := db.PauseUserSession(7); err != nil {
+				t.Fatalf("приостановка сессии: %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fresh.db")
+
+	db, err := NewDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFullyMigrated(t, db)
+	db.Close()
+
+	db, err = NewDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	applied, err := db.Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("повторный запуск применил миграции: %v", applied)
+	}
+}
+
+func TestMigrationsAreNumberedSequentially(t *testing.T) {
+	for _, dialect := range []string{"sqlite", "postgres"} {
+		migrations, err := loadMigrations(dialect)
+		if err != nil {
+			t.Fatalf("%s: %v", dialect, err)
+		}
+		for i, migration := range migrations {
+			if migration.Version != i+1 {
+				t.Fatalf("%s: ожидалась миграция %04d, найдена %04d_%s", dialect, i+1, migration.Version, migration.Name)
+			}
+		}
+	}
+
+	sqlite, _ := loadMigrations("sqlite")
+	postgres, _ := loadMigrations("postgres")
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("число миграций SQLite (%d) и PostgreSQL (%d) различается", len(sqlite), len(postgres))
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Сколько ближайших тайников показывает /nearby
+const nearbyResultsLimit = 5
+
+// NearbyCache - публичный тайник рядом с игроком
+type NearbyCache struct {
+	Cache     *Cache
+	Distance  int    // Расстояние в метрах
+	Direction string // Направление от игрока к тайнику (Direction*)
+}
+
+// findNearbyCaches возвращает до limit ближайших к точке публичных тайников в радиусе radiusMeters
+func findNearbyCaches(store Store, lat, lon, radiusMeters float64, limit int) ([]NearbyCache, error) {
+	caches, err := store.GetCachesWithinRadius(lat, lon, radiusMeters, true)
+	if err != nil {
+		return nil, err
+	}
+
+	nearby := make([]NearbyCache, 0, len(caches))
+	for _, cache := range caches {
+		nearby = append(nearby, NearbyCache{
+			Cache:     cache,
+			Distance:  calculateDistanceMeters(lat, lon, cache.Latitude, cache.Longitude),
+			Direction: calculateDirection(lat, lon, cache.Latitude, cache.Longitude),
+		})
+	}
+
+	sort.Slice(nearby, func(i, j int) bool { return nearby[i].Distance < nearby[j].Distance })
+	if len(nearby) > limit {
+		nearby = nearby[:limit]
+	}
+	return nearby, nil
+}
+
+// hasActiveSearch сообщает, ищет ли пользователь сейчас тайник
+func (b *Bot) hasActiveSearch(userID int64) bool {
+	_, err := b.DB.GetUserSession(userID)
+	if err != nil && err != sql.ErrNoRows {
+		slog.Error("Ошибка получения пользовательской сессии", "user_id", userID, "error", err)
+	}
+	return err == nil
+}
+
+// Обработчик команды /nearby: просит отправить геопозицию
+func (b *Bot) handleNearbyCommand(userID int64) {
+	if b.hasActiveSearch(userID) {
+		b.reply(userID, "nearby.search_active")
+		return
+	}
+
+	lang := b.userLanguage(userID)
+	msg := tgbotapi.NewMessage(userID, translate(lang, "nearby.ask_location",
+		formatDistance(lang, b.displaySettings(userID).Units, b.Config.NearbyRadiusMeters)))
+	keyboard := tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButtonLocation(translate(lang, "button.send_location")),
+		),
+	)
+	keyboard.OneTimeKeyboard = true
+	msg.ReplyMarkup = keyboard
+	b.Outbox.Send(msg)
+}
+
+// Обработчик геопозиции без активного поиска: присылает ближайшие публичные тайники
+func (b *Bot) handleNearbyLocation(userID int64, location *tgbotapi.Location) {
+	lang := b.userLanguage(userID)
+	units := b.displaySettings(userID).Units
+
+	nearby, err := findNearbyCaches(b.DB, location.Latitude, location.Longitude, b.Config.NearbyRadiusMeters, nearbyResultsLimit)
+	if err != nil {
+		slog.Error("Ошибка поиска тайников поблизости", "user_id", userID, "error", err)
+		b.sendMessage(userID, translate(lang, "error.generic"))
+		return
+	}
+
+	if len(nearby) == 0 {
+		msg := tgbotapi.NewMessage(userID, translate(lang, "nearby.empty", formatDistance(lang, units, b.Config.NearbyRadiusMeters)))
+		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+		b.Outbox.Send(msg)
+		return
+	}
+
+	// Тайники «горячо-холодно» идут в конце списка: их место среди остальных выдало бы расстояние
+	sort.SliceStable(nearby, func(i, j int) bool {
+		return nearby[i].Cache.Navigation() != NavigationHotCold && nearby[j].Cache.Navigation() == NavigationHotCold
+	})
+
+	var text strings.Builder
+	var rows [][]tgbotapi.InlineKeyboardButton
+	text.WriteString(translate(lang, "nearby.header"))
+	for i, item := range nearby {
+		// Список не должен подсказывать больше, чем навигация самого тайника
+		switch item.Cache.Navigation() {
+		case NavigationDistance:
+			fmt.Fprintf(&text, "%d. 🔑 %s - %s\n", i+1, item.Cache.CodeWord, formatDistance(lang, units, float64(item.Distance)))
+		case NavigationHotCold:
+			fmt.Fprintf(&text, "%d. 🔑 %s - %s\n", i+1, item.Cache.CodeWord,
+				translate(lang, "nearby.hotcold", formatDistance(lang, units, b.Config.NearbyRadiusMeters)))
+		default:
+			fmt.Fprintf(&text, "%d. 🔑 %s - %s %s %s\n", i+1, item.Cache.CodeWord, formatDistance(lang, units, float64(item.Distance)),
+				getDirectionArrow(item.Direction), directionName(lang, item.Direction))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(translate(lang, "nearby.button_search", item.Cache.CodeWord),
+				fmt.Sprintf("near:%d", item.Cache.ID)),
+		))
+	}
+	text.WriteString(translate(lang, "nearby.footer"))
+
+	msg := tgbotapi.NewMessage(userID, text.String())
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	b.Outbox.Send(msg)
+}
+
+// Обработчик кнопки тайника из /nearby: начинает поиск, как после ввода кодового слова
+func (b *Bot) handleNearbyCallback(userID int64, arg string) {
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return
+	}
+	if b.hasActiveSearch(userID) {
+		b.reply(userID, "nearby.search_active")
+		return
+	}
+
+	cache, err := b.DB.GetCacheByID(id)
+	if err != nil || !cache.IsPublic {
+		b.reply(userID, "nearby.cache_unavailable")
+		return
+	}
+	// Тайник могли добавить в маршрут уже после того, как список был показан
+	if _, err := b.DB.GetTrailStageByCacheID(cache.ID); err == nil {
+		b.reply(userID, "nearby.cache_unavailable")
+		return
+	}
+
+	b.handleCacheSearch(userID, cache.CodeWord)
+}
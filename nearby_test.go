@@ -0,0 +1,54 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFindNearbyCaches(t *testing.T) {
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "nearby.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, cache := range []*Cache{
+		{CodeWord: "рядом", Latitude: 55.7510, Longitude: 37.61, IsPublic: true},
+		{CodeWord: "дальше", Latitude: 55.7550, Longitude: 37.61, IsPublic: true},
+		{CodeWord: "скрытый", Latitude: 55.7505, Longitude: 37.61},
+		{CodeWord: "в углу", Latitude: 55.7585, Longitude: 37.6240, IsPublic: true}, // В прямоугольнике, но дальше 1 км
+		{CodeWord: "далеко", Latitude: 55.80, Longitude: 37.70, IsPublic: true},
+	} {
+		if err := db.CreateCache(cache); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Этап маршрута отдельно не ищется, даже если помечен публичным
+	trail := &Trail{CodeWord: "маршрут", CreatedBy: 1}
+	stage := &Cache{CodeWord: "этап", Latitude: 55.7501, Longitude: 37.61, IsPublic: true}
+	if err := db.CreateTrail(trail); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateCache(stage); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AddTrailStage(&TrailStage{TrailID: trail.ID, Position: 1, CacheID: stage.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	nearby, err := findNearbyCaches(db, 55.75, 37.61, 1000, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nearby) != 2 || nearby[0].Cache.CodeWord != "рядом" || nearby[1].Cache.CodeWord != "дальше" {
+		t.Fatalf("ожидались «рядом» и «дальше» по возрастанию расстояния, получено %+v", nearby)
+	}
+	if nearby[0].Direction != DirectionNorth || nearby[0].Distance < 100 || nearby[0].Distance > 120 {
+		t.Errorf("неверные направление или расстояние: %+v", nearby[0])
+	}
+
+	if nearby, _ := findNearbyCaches(db, 55.75, 37.61, 1000, 1); len(nearby) != 1 {
+		t.Errorf("лимит не применен: %d тайников", len(nearby))
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Сколько раз повторять запрос, на который Telegram ответил 429 Too Many Requests
+const maxSendAttempts = 3
+
+// Как часто планировщик проверяет отложенные правки навигационных сообщений
+const outboxTickInterval = 100 * time.Millisecond
+
+// pendingEdit - последняя еще не отправленная правка навигационного сообщения в чате
+type pendingEdit struct {
+	messageID int
+	text      string
+	parseMode string
+	// onResend вызывается, если сообщение пришлось отправить заново (старое нельзя отредактировать)
+	onResend func(messageID int)
+}
+
+// Outbox - планировщик исходящих сообщений.
+// Все запросы укладываются в общий лимит сообщений в секунду, а правки навигационных сообщений
+// объединяются: в каждый чат уходит не чаще одной правки за editInterval и только с самым свежим текстом.
+type Outbox struct {
+	api          *tgbotapi.BotAPI
+	tokens       chan struct{}
+	editInterval time.Duration
+
+	mu         sync.Mutex
+	pauseUntil time.Time // До этого момента Telegram просил ничего не отправлять (retry_after)
+	pending    map[int64]*pendingEdit
+	lastEdit   map[int64]time.Time
+	inFlight   map[int64]bool
+	closed     bool // После Close новые правки не принимаются
+
+	sending sync.WaitGroup // Правки, которые отправляются прямо сейчас
+	done    chan struct{}  // Закрывается, когда планировщик остановлен
+}
+
+// NewOutbox создает планировщик с бюджетом perSecond сообщений в секунду
+func NewOutbox(api *tgbotapi.BotAPI, perSecond int, editInterval time.Duration) *Outbox {
+	if perSecond <= 0 {
+		perSecond = 1
+	}
+
+	o := &Outbox{
+		api:          api,
+		tokens:       make(chan struct{}, perSecond),
+		editInterval: editInterval,
+		pending:      make(map[int64]*pendingEdit),
+		lastEdit:     make(map[int64]time.Time),
+		inFlight:     make(map[int64]bool),
+		done:         make(chan struct{}),
+	}
+	for i := 0; i < perSecond; i++ {
+		o.tokens <- struct{}{}
+	}
+
+	go o.refill(time.Second / time.Duration(perSecond))
+	go o.run()
+
+	return o
+}
+
+// refill пополняет бюджет сообщений с постоянной скоростью
+func (o *Outbox) refill(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-o.done:
+			return
+		}
+
+		select {
+		case o.tokens <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// wait блокируется, пока не появится место в бюджете и не истечет пауза после 429
+func (o *Outbox) wait() {
+	<-o.tokens
+
+	o.mu.Lock()
+	pause := time.Until(o.pauseUntil)
+	o.mu.Unlock()
+
+	if pause > 0 {
+		time.Sleep(pause)
+	}
+}
+
+// retryAfter возвращает время ожидания из ответа 429 (ноль - ошибка не связана с лимитами)
+func retryAfter(err error) time.Duration {
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) && tgErr.Code == 429 {
+		if tgErr.RetryAfter > 0 {
+			return time.Duration(tgErr.RetryAfter) * time.Second
+		}
+		return time.Second
+	}
+	return 0
+}
+
+// Send отправляет сообщение с учетом общего лимита и повторяет его после паузы retry_after
+func (o *Outbox) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	var (
+		msg tgbotapi.Message
+		err error
+	)
+
+	for attempt := 1; attempt <= maxSendAttempts; attempt++ {
+		o.wait()
+
+		msg, err = o.api.Send(c)
+		delay := retryAfter(err)
+		if delay == 0 {
+			return msg, err
+		}
+
+		slog.Warn("Telegram ограничил частоту запросов", "retry_after", delay, "attempt", attempt, "max_attempts", maxSendAttempts)
+		o.mu.Lock()
+		if until := time.Now().Add(delay); until.After(o.pauseUntil) {
+			o.pauseUntil = until
+		}
+		o.mu.Unlock()
+	}
+
+	return msg, err
+}
+
+// QueueEdit ставит в очередь правку навигационного сообщения.
+// Если в чате уже ждет правка, она заменяется новой: отправится только самый свежий текст.
+func (o *Outbox) QueueEdit(chatID int64, messageID int, text, parseMode string, onResend func(messageID int)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		slog.Warn("Правка сообщения отброшена: бот останавливается", "user_id", chatID)
+		return
+	}
+	o.pending[chatID] = &pendingEdit{
+		messageID: messageID,
+		text:      text,
+		parseMode: parseMode,
+		onResend:  onResend,
+	}
+}
+
+// CancelEdit отменяет ожидающую правку в чате (например, когда поиск завершен)
+func (o *Outbox) CancelEdit(chatID int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.pending, chatID)
+}
+
+// Close останавливает планировщик. Правки, которые уже отправляются, дожидаются ответа,
+// а ожидающие отправляются сразу, не дожидаясь интервала обновления: иначе игроки остались бы
+// с устаревшей навигацией. Вызывается после остановки обработки обновлений.
+func (o *Outbox) Close() {
+	o.mu.Lock()
+	if o.closed {
+		o.mu.Unlock()
+		return
+	}
+	o.closed = true
+	pending := o.pending
+	o.pending = make(map[int64]*pendingEdit)
+	o.mu.Unlock()
+
+	o.sending.Wait()
+
+	if len(pending) > 0 {
+		slog.Info("Отправка ожидающих правок перед остановкой", "count", len(pending))
+	}
+	for chatID, edit := range pending {
+		o.mu.Lock()
+		o.inFlight[chatID] = true
+		o.mu.Unlock()
+		o.sendEdit(chatID, edit)
+	}
+
+	close(o.done)
+}
+
+// run отправляет отложенные правки, как только для чата истекает интервал обновления
+func (o *Outbox) run() {
+	ticker := time.NewTicker(outboxTickInterval)
+	defer ticker.Stop()
+
+	for {
+		var now time.Time
+		select {
+		case now = <-ticker.C:
+		case <-o.done:
+			return
+		}
+
+		o.mu.Lock()
+		for chatID, edit := range o.pending {
+			if o.inFlight[chatID] || now.Sub(o.lastEdit[chatID]) < o.editInterval {
+				continue
+			}
+			delete(o.pending, chatID)
+			o.inFlight[chatID] = true
+			o.lastEdit[chatID] = now
+			o.sending.Add(1)
+			go func(chatID int64, edit *pendingEdit) {
+				defer o.sending.Done()
+				o.sendEdit(chatID, edit)
+			}(chatID, edit)
+		}
+
+		// Забываем чаты, в которые давно ничего не правили
+		for chatID, last := range o.lastEdit {
+			if !o.inFlight[chatID] && now.Sub(last) > o.editInterval+time.Minute {
+				delete(o.lastEdit, chatID)
+			}
+		}
+		o.mu.Unlock()
+	}
+}
+
+// sendEdit применяет правку; если сообщение больше нельзя отредактировать, отправляет его заново
+func (o *Outbox) sendEdit(chatID int64, edit *pendingEdit) {
+	defer func() {
+		o.mu.Lock()
+		delete(o.inFlight, chatID)
+		o.mu.Unlock()
+	}()
+
+	config := tgbotapi.NewEditMessageText(chatID, edit.messageID, edit.text)
+	config.ParseMode = edit.parseMode
+	_, err := o.Send(config)
+	if err == nil || isNotModified(err) {
+		return
+	}
+	if retryAfter(err) > 0 {
+		slog.Warn("Правка сообщения отброшена из-за ограничения частоты запросов", "user_id", chatID, "attempts", maxSendAttempts, "error", err)
+		return
+	}
+
+	slog.Warn("Не удалось отредактировать сообщение, отправляем новое", "user_id", chatID, "error", err)
+
+	msg := tgbotapi.NewMessage(chatID, edit.text)
+	msg.ParseMode = edit.parseMode
+	sent, err := o.Send(msg)
+	if err != nil {
+		slog.Error("Ошибка отправки нового сообщения", "user_id", chatID, "error", err)
+		return
+	}
+	if edit.onResend != nil {
+		edit.onResend(sent.MessageID)
+	}
+}
+
+// isNotModified сообщает, что текст сообщения совпадает с уже отправленным
+func isNotModified(err error) bool {
+	return strings.Contains(err.Error(), "message is not modified")
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// newOutboxTestAPI подключает клиент Bot API к поддельному серверу
+func newOutboxTestAPI(t *testing.T) (*fakeBotAPI, *tgbotapi.BotAPI) {
+	t.Helper()
+
+	api := newFakeBotAPI(t)
+	client, err := tgbotapi.NewBotAPIWithClient("123456:TEST", api.Endpoint(), api.server.Client())
+	if err != nil {
+		t.Fatalf("подключение к поддельному Bot API: %v", err)
+	}
+	return api, client
+}
+
+// countRequests возвращает число запросов method
+func countRequests(api *fakeBotAPI, method string) int {
+	count := 0
+	for _, request := range api.Requests() {
+		if request.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+func TestOutboxCloseFlushesPendingEdits(t *testing.T) {
+	api, client := newOutboxTestAPI(t)
+	outbox := NewOutbox(client, 100, time.Hour)
+
+	// Правка ждет истечения часового интервала обновления
+	outbox.mu.Lock()
+	outbox.lastEdit[7] = time.Now()
+	outbox.mu.Unlock()
+	outbox.QueueEdit(7, 10, "последняя навигация", "", nil)
+
+	outbox.Close()
+	if count := countRequests(api, "editMessageText"); count != 1 {
+		t.Fatalf("при остановке отправлено %d правок, ожидалась 1", count)
+	}
+	request, _ := api.WaitFor(0, "editMessageText", nil)
+	if request.Text() != "последняя навигация" || request.Params.Get("message_id") != "10" {
+		t.Errorf("отправлена не та правка: %v", request.Params)
+	}
+
+	// После остановки новые правки не принимаются, повторный Close ничего не делает
+	outbox.QueueEdit(7, 10, "после остановки", "", nil)
+	outbox.Close()
+	if count := countRequests(api, "editMessageText"); count != 1 {
+		t.Errorf("правка после остановки отправлена")
+	}
+}
+
+func TestOutboxCoalescesEdits(t *testing.T) {
+	api, client := newOutboxTestAPI(t)
+	outbox := NewOutbox(client, 100, 500*time.Millisecond)
+	defer outbox.Close()
+
+	// Три правки до ближайшего такта сливаются в одну с последним текстом
+	for _, text := range []string{"500 м", "450 м", "400 м"} {
+		outbox.QueueEdit(7, 10, text, "", nil)
+	}
+	outbox.QueueEdit(8, 20, "другой чат", "", nil)
+
+	request, next := api.WaitFor(0, "editMessageText", func(r fakeAPIRequest) bool { return r.ChatID() == 7 })
+	if request.Text() != "400 м" {
+		t.Errorf("отправлен устаревший текст %q", request.Text())
+	}
+	api.WaitFor(0, "editMessageText", func(r fakeAPIRequest) bool { return r.ChatID() == 8 })
+	sentAt := time.Now()
+
+	// Следующая правка в тот же чат ждет интервала обновления
+	outbox.QueueEdit(7, 10, "350 м", "", nil)
+	outbox.QueueEdit(7, 10, "300 м", "", nil)
+	request, _ = api.WaitFor(next, "editMessageText", func(r fakeAPIRequest) bool { return r.ChatID() == 7 })
+	if elapsed := time.Since(sentAt); elapsed < 300*time.Millisecond {
+		t.Errorf("правка отправлена через %v, раньше интервала обновления", elapsed)
+	}
+	if request.Text() != "300 м" {
+		t.Errorf("отправлен устаревший текст %q", request.Text())
+	}
+	if count := countRequests(api, "editMessageText"); count != 3 {
+		t.Errorf("отправлено %d правок, ожидалось 3", count)
+	}
+}
+
+func TestOutboxRetriesAfterTooManyRequests(t *testing.T) {
+	api, client := newOutboxTestAPI(t)
+	outbox := NewOutbox(client, 100, time.Second)
+	defer outbox.Close()
+
+	api.FailNext("sendMessage", fakeAPIFailure{Code: 429, Description: "Too Many Requests: retry after 1", RetryAfter: 1})
+
+	started := time.Now()
+	msg, err := outbox.Send(tgbotapi.NewMessage(7, "привет"))
+	if err != nil {
+		t.Fatalf("сообщение не отправлено после паузы: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < time.Second {
+		t.Errorf("повтор отправлен через %v, не дождавшись retry_after", elapsed)
+	}
+	if msg.MessageID == 0 || countRequests(api, "sendMessage") != 2 {
+		t.Errorf("ожидалась одна повторная отправка: %d запросов", countRequests(api, "sendMessage"))
+	}
+
+	// Пауза общая: сообщение в другой чат тоже ждет ее окончания
+	outbox.mu.Lock()
+	outbox.pauseUntil = time.Now().Add(300 * time.Millisecond)
+	outbox.mu.Unlock()
+	started = time.Now()
+	if _, err := outbox.Send(tgbotapi.NewMessage(8, "привет")); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(started); elapsed < 250*time.Millisecond {
+		t.Errorf("сообщение отправлено во время паузы через %v", elapsed)
+	}
+}
+
+func TestOutboxGivesUpAfterRepeatedTooManyRequests(t *testing.T) {
+	api, client := newOutboxTestAPI(t)
+	outbox := NewOutbox(client, 100, time.Hour)
+
+	for i := 0; i < maxSendAttempts; i++ {
+		api.FailNext("editMessageText", fakeAPIFailure{Code: 429, Description: "Too Many Requests", RetryAfter: 1})
+	}
+	resent := false
+	outbox.QueueEdit(7, 10, "400 м", "", func(int) { resent = true })
+	outbox.Close()
+
+	// Правка отброшена без отправки нового сообщения: оно тоже упало бы в лимит
+	if count := countRequests(api, "editMessageText"); count != maxSendAttempts {
+		t.Errorf("сделано %d попыток правки, ожидалось %d", count, maxSendAttempts)
+	}
+	if countRequests(api, "sendMessage") != 0 || resent {
+		t.Error("после 429 отправлено новое сообщение")
+	}
+}
+
+func TestOutboxResendsMessageThatCannotBeEdited(t *testing.T) {
+	api, client := newOutboxTestAPI(t)
+	outbox := NewOutbox(client, 100, time.Hour)
+
+	api.FailNext("editMessageText", fakeAPIFailure{Code: 400, Description: "Bad Request: message to edit not found"})
+	resentID := 0
+	outbox.QueueEdit(7, 10, "400 м", "", func(messageID int) { resentID = messageID })
+	outbox.Close()
+
+	request, _ := api.WaitFor(0, "sendMessage", nil)
+	if request.Text() != "400 м" || request.ChatID() != 7 {
+		t.Errorf("заново отправлено не то сообщение: %v", request.Params)
+	}
+	if resentID == 0 || resentID == 10 {
+		t.Errorf("onResend получил идентификатор %d", resentID)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"database/sql"
+
+	_ "github.com/lib/pq"
+)
+
+// NewPostgresDatabase подключается к PostgreSQL по DATABASE_URL
+// (например, postgres://bot:secret@db:5432/geocaching?sslmode=disable) и применяет миграции
+func NewPostgresDatabase(url string) (*Database, error) {
+	database, err := openPostgres(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := database.Migrate(); err != nil {
+		database.Close()
+		return nil, err
+	}
+
+	return database, nil
+}
+
+// openPostgres подключается к PostgreSQL без применения миграций
+func openPostgres(url string) (*Database, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Database{db: db, postgres: true}, nil
+}
@@ -0,0 +1,503 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Ключи подписей кнопок выбора типа вопроса в админском диалоге
+const (
+	puzzleButtonNone   = "puzzle.button_none"
+	puzzleButtonText   = "puzzle.button_text"
+	puzzleButtonChoice = "puzzle.button_choice"
+	puzzleButtonNumber = "puzzle.button_number"
+)
+
+// isButton проверяет, совпадает ли текст с подписью кнопки key на любом языке
+// (администратор мог сменить язык, пока клавиатура была открыта)
+func isButton(text, key string) bool {
+	for _, lang := range languages {
+		if text == translate(lang, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAnswer приводит ответ к виду для сравнения без учета регистра и лишних пробелов
+func normalizeAnswer(answer string) string {
+	answer = strings.ToLower(answer)
+	answer = strings.ReplaceAll(answer, "ё", "е")
+	return strings.Join(strings.Fields(answer), " ")
+}
+
+// parseNumber разбирает число, допуская запятую в качестве десятичного разделителя
+func parseNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", "."), 64)
+}
+
+// optionList возвращает варианты ответа вопроса с выбором
+func (p *CachePuzzle) optionList() []string {
+	if p.Options == "" {
+		return nil
+	}
+	return strings.Split(p.Options, "\n")
+}
+
+// Check проверяет ответ пользователя
+func (p *CachePuzzle) Check(answer string) bool {
+	switch p.Kind {
+	case PuzzleNumber:
+		expected, err := parseNumber(p.Answer)
+		if err != nil {
+			return false
+		}
+		value, err := parseNumber(answer)
+		if err != nil {
+			return false
+		}
+		return math.Abs(value-expected) <= p.Tolerance
+	case PuzzleChoice:
+		// Ответ приходит номером варианта с кнопки, но допускаем и текст варианта
+		if normalizeAnswer(answer) == normalizeAnswer(p.Answer) {
+			return true
+		}
+		index, err := strconv.Atoi(p.Answer)
+		options := p.optionList()
+		if err != nil || index < 1 || index > len(options) {
+			return false
+		}
+		return normalizeAnswer(answer) == normalizeAnswer(options[index-1])
+	default:
+		for _, variant := range strings.Split(p.Answer, "|") {
+			if normalizeAnswer(answer) == normalizeAnswer(variant) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// askPuzzleKind переводит администратора к шагу выбора вопроса для тайника
+func (b *Bot) askPuzzleKind(userID int64, cache *Cache) {
+	session := &AdminSession{
+		UserID:   userID,
+		Step:     "puzzle_kind",
+		CodeWord: cache.CodeWord,
+	}
+
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка обновления админской сессии", "user_id", userID, "cache_id", cache.ID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	lang := b.userLanguage(userID)
+	msg := tgbotapi.NewMessage(userID, translate(lang, "puzzle.ask_kind"))
+	keyboard := tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(translate(lang, puzzleButtonText)),
+			tgbotapi.NewKeyboardButton(translate(lang, puzzleButtonChoice)),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton(translate(lang, puzzleButtonNumber)),
+			tgbotapi.NewKeyboardButton(translate(lang, puzzleButtonNone)),
+		),
+	)
+	keyboard.OneTimeKeyboard = true
+	msg.ReplyMarkup = keyboard
+	b.Outbox.Send(msg)
+}
+
+// Обработчик выбора типа вопроса
+func (b *Bot) handlePuzzleKindInput(userID int64, session *AdminSession, text string) {
+	text = strings.TrimSpace(text)
+
+	var kind string
+	switch {
+	case isButton(text, puzzleButtonText):
+		kind = PuzzleText
+	case isButton(text, puzzleButtonChoice):
+		kind = PuzzleChoice
+	case isButton(text, puzzleButtonNumber):
+		kind = PuzzleNumber
+	case isButton(text, puzzleButtonNone):
+		cache := b.loadEditedCache(userID, session)
+		if cache == nil {
+			return
+		}
+		if err := b.DB.DeleteCachePuzzle(cache.ID); err != nil {
+			slog.Error("Ошибка удаления вопроса", "user_id", userID, "cache_id", cache.ID, "error", err)
+		}
+		b.DB.DeleteAdminSession(userID)
+		msg := tgbotapi.NewMessage(userID, b.text(userID, "puzzle.none_saved", cache.CodeWord))
+		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+		b.Outbox.Send(msg)
+		return
+	default:
+		b.reply(userID, "puzzle.kind_required")
+		return
+	}
+
+	cache := b.loadEditedCache(userID, session)
+	if cache == nil {
+		return
+	}
+
+	puzzle := &CachePuzzle{
+		CacheID: cache.ID,
+		Kind:    kind,
+	}
+	if err := b.DB.SaveCachePuzzle(puzzle); err != nil {
+		slog.Error("Ошибка сохранения вопроса", "user_id", userID, "cache_id", cache.ID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	session.Step = "puzzle_question"
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка обновления админской сессии", "user_id", userID, "cache_id", cache.ID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.text(userID, "puzzle.ask_question"))
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+	b.Outbox.Send(msg)
+}
+
+// loadDraftPuzzle загружает вопрос тайника, который настраивается в админской сессии
+func (b *Bot) loadDraftPuzzle(userID int64, session *AdminSession) (*Cache, *CachePuzzle) {
+	cache := b.loadEditedCache(userID, session)
+	if cache == nil {
+		return nil, nil
+	}
+
+	puzzle, err := b.DB.GetCachePuzzle(cache.ID)
+	if err != nil {
+		slog.Error("Ошибка получения вопроса", "user_id", userID, "error", err)
+		b.DB.DeleteAdminSession(userID)
+		b.reply(userID, "puzzle.restart")
+		return nil, nil
+	}
+	return cache, puzzle
+}
+
+// Обработчик ввода текста вопроса
+func (b *Bot) handlePuzzleQuestionInput(userID int64, session *AdminSession, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		b.reply(userID, "puzzle.question_required")
+		return
+	}
+
+	_, puzzle := b.loadDraftPuzzle(userID, session)
+	if puzzle == nil {
+		return
+	}
+
+	puzzle.Question = text
+	if err := b.DB.SaveCachePuzzle(puzzle); err != nil {
+		slog.Error("Ошибка сохранения вопроса", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	session.Step = "puzzle_answer"
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка обновления админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	switch puzzle.Kind {
+	case PuzzleChoice, PuzzleNumber:
+		b.reply(userID, "puzzle.ask_answer_"+puzzle.Kind)
+	default:
+		b.reply(userID, "puzzle.ask_answer_text")
+	}
+}
+
+// Обработчик ввода правильного ответа
+func (b *Bot) handlePuzzleAnswerInput(userID int64, session *AdminSession, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		b.reply(userID, "puzzle.answer_required")
+		return
+	}
+
+	_, puzzle := b.loadDraftPuzzle(userID, session)
+	if puzzle == nil {
+		return
+	}
+
+	switch puzzle.Kind {
+	case PuzzleChoice:
+		var options []string
+		correct := 0
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if strings.HasPrefix(line, "*") {
+				line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+				if correct != 0 {
+					b.reply(userID, "puzzle.several_correct")
+					return
+				}
+				correct = len(options) + 1
+			}
+			options = append(options, line)
+		}
+		if len(options) < 2 || correct == 0 {
+			b.reply(userID, "puzzle.too_few_options")
+			return
+		}
+		puzzle.Options = strings.Join(options, "\n")
+		puzzle.Answer = strconv.Itoa(correct)
+	case PuzzleNumber:
+		fields := strings.Fields(strings.ReplaceAll(text, "±", " "))
+		if len(fields) == 0 || len(fields) > 2 {
+			b.reply(userID, "puzzle.invalid_number")
+			return
+		}
+		value, err := parseNumber(fields[0])
+		if err != nil {
+			b.reply(userID, "puzzle.invalid_number")
+			return
+		}
+		tolerance := 0.0
+		if len(fields) == 2 {
+			tolerance, err = parseNumber(fields[1])
+			if err != nil || tolerance < 0 {
+				b.reply(userID, "puzzle.invalid_tolerance")
+				return
+			}
+		}
+		puzzle.Answer = strconv.FormatFloat(value, 'f', -1, 64)
+		puzzle.Tolerance = tolerance
+	default:
+		puzzle.Answer = text
+	}
+
+	if err := b.DB.SaveCachePuzzle(puzzle); err != nil {
+		slog.Error("Ошибка сохранения вопроса", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	session.Step = "puzzle_attempts"
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка обновления админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	b.reply(userID, "puzzle.ask_attempts")
+}
+
+// Обработчик ввода лимита попыток
+func (b *Bot) handlePuzzleAttemptsInput(userID int64, session *AdminSession, text string) {
+	maxAttempts, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || maxAttempts < 0 {
+		b.reply(userID, "puzzle.invalid_attempts")
+		return
+	}
+
+	cache, puzzle := b.loadDraftPuzzle(userID, session)
+	if puzzle == nil {
+		return
+	}
+
+	puzzle.MaxAttempts = maxAttempts
+	if err := b.DB.SaveCachePuzzle(puzzle); err != nil {
+		slog.Error("Ошибка сохранения вопроса", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	b.DB.DeleteAdminSession(userID)
+
+	lang := b.userLanguage(userID)
+	attemptsText := translate(lang, "puzzle.attempts_unlimited")
+	if maxAttempts > 0 {
+		attemptsText = strconv.Itoa(maxAttempts)
+	}
+	b.sendMessage(userID, translate(lang, "puzzle.saved", cache.CodeWord, puzzle.Question, attemptsText))
+}
+
+// cancelDraftPuzzle удаляет недонастроенный вопрос при отмене
+func (b *Bot) cancelDraftPuzzle(session *AdminSession) {
+	cache, err := b.DB.GetCacheByCodeWord(session.CodeWord)
+	if err != nil {
+		return
+	}
+	puzzle, err := b.DB.GetCachePuzzle(cache.ID)
+	if err != nil || puzzle.IsComplete() {
+		return
+	}
+	if err := b.DB.DeleteCachePuzzle(cache.ID); err != nil {
+		slog.Error("Ошибка удаления вопроса", "user_id", session.UserID, "cache_id", cache.ID, "error", err)
+	}
+}
+
+// handleArrival вызывается, когда пользователь дошел до тайника.
+// Если у тайника есть вопрос, медиафайл выдается только после верного ответа.
+func (b *Bot) handleArrival(userID int64, cache *Cache) {
+	puzzle, err := b.DB.GetCachePuzzle(cache.ID)
+	if err != nil || !puzzle.IsComplete() {
+		if err != nil && err != sql.ErrNoRows {
+			slog.Error("Ошибка получения вопроса", "user_id", userID, "cache_id", cache.ID, "error", err)
+		}
+		b.handleTargetReached(userID, cache)
+		return
+	}
+
+	attempt, err := b.DB.GetPuzzleAttempt(userID, cache.ID)
+	if err != nil {
+		attempt = &PuzzleAttempt{UserID: userID, CacheID: cache.ID}
+	}
+
+	if puzzle.MaxAttempts > 0 && attempt.Attempts >= puzzle.MaxAttempts {
+		b.endUserSession(userID)
+		msg := tgbotapi.NewMessage(userID, b.text(userID, "puzzle.locked"))
+		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+		b.Outbox.Send(msg)
+		return
+	}
+
+	attempt.Awaiting = true
+	if err := b.DB.SavePuzzleAttempt(attempt); err != nil {
+		slog.Error("Ошибка сохранения попытки", "user_id", userID, "cache_id", cache.ID, "error", err)
+		return
+	}
+
+	b.sendPuzzleQuestion(userID, puzzle, attempt)
+}
+
+// sendPuzzleQuestion отправляет вопрос тайника пользователю
+func (b *Bot) sendPuzzleQuestion(userID int64, puzzle *CachePuzzle, attempt *PuzzleAttempt) {
+	lang := b.userLanguage(userID)
+	text := translate(lang, "puzzle.question", puzzle.Question)
+	if puzzle.MaxAttempts > 0 {
+		text += "\n\n🔁 " + translatePlural(lang, "puzzle.attempts_left", puzzle.MaxAttempts-attempt.Attempts)
+	}
+
+	msg := tgbotapi.NewMessage(userID, text)
+	if puzzle.Kind == PuzzleChoice {
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for i, option := range puzzle.optionList() {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(option, fmt.Sprintf("ans:%d:%d", puzzle.CacheID, i+1)),
+			))
+		}
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	} else {
+		text += translate(lang, "puzzle.answer_hint")
+		msg.Text = text
+	}
+	b.Outbox.Send(msg)
+}
+
+// handlePuzzleGate обрабатывает сообщения пользователя, который ждет у тайника ответа на вопрос.
+// Возвращает true, если сообщение обработано.
+func (b *Bot) handlePuzzleGate(userID int64, session *UserSession, message *tgbotapi.Message) bool {
+	attempt, err := b.DB.GetPuzzleAttempt(userID, session.CacheID)
+	if err != nil || !attempt.Awaiting {
+		return false
+	}
+
+	// Обновления трансляции геопозиции, пока ждем ответ, игнорируем
+	if message.Location != nil {
+		return true
+	}
+
+	if strings.TrimSpace(message.Text) == "" {
+		b.reply(userID, "puzzle.text_answer_required")
+		return true
+	}
+
+	b.checkPuzzleAnswer(userID, session.CacheID, message.Text)
+	return true
+}
+
+// Обработчик ответа кнопкой на вопрос с выбором варианта
+func (b *Bot) handlePuzzleAnswerCallback(userID int64, arg string) {
+	cacheIDStr, answer, _ := strings.Cut(arg, ":")
+	cacheID, err := strconv.ParseInt(cacheIDStr, 10, 64)
+	if err != nil {
+		return
+	}
+
+	session, err := b.DB.GetUserSession(userID)
+	if err != nil || session.CacheID != cacheID {
+		return
+	}
+	attempt, err := b.DB.GetPuzzleAttempt(userID, cacheID)
+	if err != nil || !attempt.Awaiting {
+		return
+	}
+
+	b.checkPuzzleAnswer(userID, cacheID, answer)
+}
+
+// checkPuzzleAnswer проверяет ответ и либо открывает тайник, либо засчитывает неудачную попытку
+func (b *Bot) checkPuzzleAnswer(userID, cacheID int64, answer string) {
+	cache, err := b.DB.GetCacheByID(cacheID)
+	if err != nil {
+		slog.Error("Ошибка получения кэша", "user_id", userID, "cache_id", cacheID, "error", err)
+		return
+	}
+	puzzle, err := b.DB.GetCachePuzzle(cacheID)
+	if err != nil {
+		slog.Error("Ошибка получения вопроса", "user_id", userID, "cache_id", cacheID, "error", err)
+		return
+	}
+	attempt, err := b.DB.GetPuzzleAttempt(userID, cacheID)
+	if err != nil {
+		slog.Error("Ошибка получения попытки", "user_id", userID, "cache_id", cacheID, "error", err)
+		return
+	}
+
+	if puzzle.Check(answer) {
+		attempt.Awaiting = false
+		if err := b.DB.SavePuzzleAttempt(attempt); err != nil {
+			slog.Error("Ошибка сохранения попытки", "user_id", userID, "cache_id", cacheID, "error", err)
+		}
+		b.reply(userID, "puzzle.correct")
+		b.handleTargetReached(userID, cache)
+		return
+	}
+
+	attempt.Attempts++
+	if puzzle.MaxAttempts > 0 && attempt.Attempts >= puzzle.MaxAttempts {
+		attempt.Awaiting = false
+		if err := b.DB.SavePuzzleAttempt(attempt); err != nil {
+			slog.Error("Ошибка сохранения попытки", "user_id", userID, "cache_id", cacheID, "error", err)
+		}
+		b.endUserSession(userID)
+
+		msg := tgbotapi.NewMessage(userID, b.text(userID, "puzzle.out_of_attempts"))
+		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+		b.Outbox.Send(msg)
+		return
+	}
+
+	if err := b.DB.SavePuzzleAttempt(attempt); err != nil {
+		slog.Error("Ошибка сохранения попытки", "user_id", userID, "cache_id", cacheID, "error", err)
+	}
+
+	lang := b.userLanguage(userID)
+	if puzzle.MaxAttempts > 0 {
+		b.sendMessage(userID, translate(lang, "puzzle.wrong")+" "+translatePlural(lang, "puzzle.attempts_left", puzzle.MaxAttempts-attempt.Attempts))
+		return
+	}
+	b.sendMessage(userID, translate(lang, "puzzle.wrong")+" "+translate(lang, "puzzle.try_again"))
+}
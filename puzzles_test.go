@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestPuzzleCheck(t *testing.T) {
+	text := &CachePuzzle{Kind: PuzzleText, Answer: "Ёлка|ель"}
+	choice := &CachePuzzle{Kind: PuzzleChoice, Answer: "2", Options: "Дуб\nКлен\nБереза"}
+	number := &CachePuzzle{Kind: PuzzleNumber, Answer: "1812", Tolerance: 0.5}
+	fraction := &CachePuzzle{Kind: PuzzleNumber, Answer: "3,14"}
+
+	tests := []struct {
+		puzzle *CachePuzzle
+		answer string
+		want   bool
+	}{
+		{text, "елка", true},
+		{text, "  ЕЛЬ ", true},
+		{text, "ёлка новогодняя", false},
+		{text, "сосна", false},
+		{choice, "2", true},
+		{choice, "клен", true},
+		{choice, "1", false},
+		{choice, "Дуб", false},
+		{number, "1812", true},
+		{number, "1812,4", true},
+		{number, "1813", false},
+		{number, "тысяча", false},
+		{fraction, "3.14", true},
+		{fraction, "3.15", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.puzzle.Check(tt.answer); got != tt.want {
+			t.Errorf("Check(%q) для %s %q = %v, ожидалось %v", tt.answer, tt.puzzle.Kind, tt.puzzle.Answer, got, tt.want)
+		}
+	}
+
+	// Номер верного варианта вне списка не засчитывает ни один ответ
+	broken := &CachePuzzle{Kind: PuzzleChoice, Answer: "5", Options: "Дуб\nКлен"}
+	if broken.Check("1") || broken.Check("Клен") {
+		t.Error("ответ засчитан при некорректном номере варианта")
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Как часто проверяются зависшие сессии поиска
+const sessionJanitorInterval = time.Minute
+
+// sessionTimeout возвращает время без обновлений геопозиции, после которого поиск приостанавливается
+func (b *Bot) sessionTimeout() time.Duration {
+	return time.Duration(b.Config.LiveLocationDurationHours) * time.Hour
+}
+
+// runSessionJanitor периодически приостанавливает сессии, в которых давно не было геопозиции,
+// и забывает сглаживание трансляций, которые больше не обновляются
+func (b *Bot) runSessionJanitor() {
+	if b.sessionTimeout() <= 0 {
+		slog.Info("LIVE_LOCATION_DURATION_HOURS не задан, автоматическая приостановка поиска отключена")
+	}
+
+	ticker := time.NewTicker(sessionJanitorInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		b.Tracker.Prune(now)
+		if b.sessionTimeout() > 0 {
+			b.expireStaleSessions(now)
+		}
+	}
+}
+
+// endUserSession завершает поиск пользователя. Вместе с сессией отменяется ожидающая правка
+// навигационного сообщения - иначе очередь перепишет или заново отправит навигацию уже
+// после остановки - и забываются точки трансляции.
+func (b *Bot) endUserSession(userID int64) error {
+	b.Outbox.CancelEdit(userID)
+	b.Tracker.Reset(userID)
+	return b.DB.DeactivateUserSession(userID)
+}
+
+// expireStaleSessions приостанавливает зависшие сессии и уведомляет игроков
+func (b *Bot) expireStaleSessions(now time.Time) {
+	sessions, err := b.DB.GetStaleUserSessions(now.Add(-b.sessionTimeout()))
+	if err != nil {
+		slog.Error("Ошибка получения зависших сессий", "error", err)
+		return
+	}
+
+	for _, session := range sessions {
+		paused, err := b.DB.PauseUserSession(session.UserID)
+		if err != nil {
+			slog.Error("Ошибка приостановки сессии", "user_id", session.UserID, "error", err)
+			continue
+		}
+		if !paused {
+			// Игрок успел остановить поиск сам
+			continue
+		}
+		b.DB.ClearPuzzleAwaiting(session.UserID)
+		b.Outbox.CancelEdit(session.UserID)
+		b.Tracker.Reset(session.UserID)
+
+		slog.Info("Поиск приостановлен: давно нет геопозиции", "user_id", session.UserID, "cache_id", session.CacheID, "last_update", session.LastUpdate)
+
+		lang := b.userLanguage(session.UserID)
+		msg := tgbotapi.NewMessage(session.UserID, translate(lang, "session.paused", formatDuration(lang, b.sessionTimeout())))
+		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+		if _, err := b.Outbox.Send(msg); err != nil {
+			slog.Error("Ошибка отправки уведомления о приостановке", "user_id", session.UserID, "error", err)
+		}
+	}
+}
+
+// Обработчик команды /resume
+func (b *Bot) handleResumeCommand(userID int64) {
+	if _, err := b.DB.GetUserSession(userID); err == nil {
+		b.reply(userID, "resume.already_active")
+		return
+	}
+
+	session, err := b.DB.GetPausedUserSession(userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			b.reply(userID, "resume.nothing")
+			return
+		}
+		slog.Error("Ошибка получения приостановленной сессии", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	cache, err := b.DB.GetCacheByID(session.CacheID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			b.endUserSession(userID)
+			b.reply(userID, "resume.cache_deleted")
+			return
+		}
+		slog.Error("Ошибка получения кэша", "user_id", userID, "cache_id", session.CacheID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	if err := b.DB.ResumeUserSession(userID); err != nil {
+		slog.Error("Ошибка возобновления сессии", "user_id", userID, "cache_id", session.CacheID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	lang := b.userLanguage(userID)
+	b.sendMessage(userID, translate(lang, "resume.resumed", b.huntTargetName(lang, cache)))
+}
+
+// huntTargetName возвращает название цели поиска: кодовое слово тайника или маршрут с номером этапа
+func (b *Bot) huntTargetName(lang string, cache *Cache) string {
+	stage, err := b.DB.GetTrailStageByCacheID(cache.ID)
+	if err != nil {
+		return cache.CodeWord
+	}
+
+	trail, err := b.DB.GetTrailByID(stage.TrailID)
+	if err != nil {
+		return cache.CodeWord
+	}
+	stages, err := b.DB.GetTrailStages(trail.ID)
+	if err != nil {
+		return trail.CodeWord
+	}
+	return translate(lang, "trail.target_name", trail.CodeWord, stagePosition(stages, stage), len(stages))
+}
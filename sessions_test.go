@@ -0,0 +1,197 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSessionTestDB(t *testing.T) *Database {
+	t.Helper()
+
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestResentMessageIDStaysWithItsHunt(t *testing.T) {
+	db := newSessionTestDB(t)
+
+	// Игрок остановил поиск первого тайника и начал искать второй
+	if err := db.CreateOrUpdateUserSession(&UserSession{UserID: 7, CacheID: 1, LastMessageID: 10, IsActive: true, StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateOrUpdateUserSession(&UserSession{UserID: 7, CacheID: 2, IsActive: true, StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Запоздавшая повторная отправка навигации первого поиска не попадает во второй
+	if err := db.SetUserSessionMessageID(7, 1, 42); err != nil {
+		t.Fatal(err)
+	}
+	session, err := db.GetUserSession(7)
+	if err != nil || session.CacheID != 2 || session.LastMessageID != 0 {
+		t.Fatalf("ID сообщения старого поиска записан в новый: %+v (ошибка %v)", session, err)
+	}
+
+	if err := db.SetUserSessionMessageID(7, 2, 43); err != nil {
+		t.Fatal(err)
+	}
+	if session, err = db.GetUserSession(7); err != nil || session.LastMessageID != 43 {
+		t.Fatalf("ID сообщения текущего поиска не сохранен: %+v (ошибка %v)", session, err)
+	}
+}
+
+func TestEndUserSessionCancelsPendingEdit(t *testing.T) {
+	db := newSessionTestDB(t)
+	b := &Bot{DB: db, Outbox: NewOutbox(nil, 1, time.Hour), Tracker: NewLocationTracker(&Config{})}
+
+	if err := db.CreateOrUpdateUserSession(&UserSession{UserID: 7, CacheID: 1, LastMessageID: 10, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Правка ждет истечения интервала обновления, а игрок останавливает поиск
+	b.Outbox.mu.Lock()
+	b.Outbox.lastEdit[7] = time.Now()
+	b.Outbox.mu.Unlock()
+	b.Outbox.QueueEdit(7, 10, "навигация", "", nil)
+	b.Tracker.Add(7, 1, LocationFix{Lat: 55.75, Lon: 37.61})
+
+	if err := b.endUserSession(7); err != nil {
+		t.Fatal(err)
+	}
+
+	b.Outbox.mu.Lock()
+	_, pending := b.Outbox.pending[7]
+	b.Outbox.mu.Unlock()
+	if pending {
+		t.Error("правка навигации осталась в очереди после остановки поиска")
+	}
+	b.Tracker.mu.Lock()
+	_, tracked := b.Tracker.tracks[7]
+	b.Tracker.mu.Unlock()
+	if tracked {
+		t.Error("точки трансляции не забыты")
+	}
+	if _, err := db.GetUserSession(7); err == nil {
+		t.Error("сессия осталась активной")
+	}
+}
+
+func TestResentMessageIDSurvivesPositionUpdate(t *testing.T) {
+	db := newSessionTestDB(t)
+
+	// Обработчик геопозиции прочитал сессию с исходным сообщением
+	if err := db.CreateOrUpdateUserSession(&UserSession{UserID: 7, CacheID: 1, LastMessageID: 10, IsActive: true}); err != nil {
+		t.Fatal(err)
+	}
+	session, err := db.GetUserSession(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Тем временем очередь не смогла отредактировать сообщение и отправила новое
+	if err := db.SetUserSessionMessageID(7, 1, 42); err != nil {
+		t.Fatal(err)
+	}
+
+	// Обработчик сохраняет точку по устаревшей копии сессии
+	session.LastLatitude, session.LastLongitude = 55.76, 37.62
+	session.LastMessageText = "новый текст"
+	session.DistanceWalked = 120
+	session.LastFixAt = time.Now()
+	if err := db.UpdateUserSessionPosition(session); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := db.GetUserSession(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.LastMessageID != 42 {
+		t.Fatalf("ID повторно отправленного сообщения затерт: %d, ожидался 42", saved.LastMessageID)
+	}
+	if saved.LastLatitude != 55.76 || saved.LastMessageText != "новый текст" || saved.DistanceWalked != 120 || saved.LastFixAt.IsZero() {
+		t.Fatalf("точка сохранена неверно: %+v", saved)
+	}
+}
+
+func TestGetStaleUserSessions(t *testing.T) {
+	db := newSessionTestDB(t)
+
+	for userID := int64(1); userID <= 3; userID++ {
+		if err := db.CreateOrUpdateUserSession(&UserSession{UserID: userID, CacheID: 1, IsActive: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Второй и третий игроки давно не присылали геопозицию, третий уже остановил поиск
+	old := time.Now().Add(-2 * time.Hour).UTC()
+	if _, err := db.exec(`UPDATE user_sessions SET last_update = ? WHERE user_id IN (2, 3)`, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.DeactivateUserSession(3); err != nil {
+		t.Fatal(err)
+	}
+
+	stale, err := db.GetStaleUserSessions(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0].UserID != 2 || !stale[0].LastUpdate.Equal(old) {
+		t.Fatalf("ожидалась одна зависшая сессия игрока 2, получено %+v", stale)
+	}
+}
+
+func TestE2EStaleHuntIsPausedAndResumed(t *testing.T) {
+	h := newE2EHarness(t)
+	h.bot.Config.LiveLocationDurationHours = 1
+
+	cache := &Cache{CodeWord: "старый дуб", Latitude: e2eCacheLat, Longitude: e2eCacheLon, FileID: "PHOTO", FileType: "photo"}
+	if err := h.db.CreateCache(cache); err != nil {
+		t.Fatal(err)
+	}
+
+	h.sendText(e2ePlayerID, "старый дуб")
+	h.expect(t, "sendMessage", e2ePlayerID, "Тайник найден")
+	liveID := h.sendLocation(e2ePlayerID, 55.7600, 37.6173, 3600)
+	h.expect(t, "sendMessage", e2ePlayerID, "Направление к тайнику")
+	h.waitForNavigation(t, e2ePlayerID)
+
+	// Свежая сессия не приостанавливается
+	h.bot.expireStaleSessions(time.Now())
+	if _, err := h.db.GetUserSession(e2ePlayerID); err != nil {
+		t.Fatalf("активный поиск приостановлен: %v", err)
+	}
+
+	// Трансляция не обновлялась дольше LIVE_LOCATION_DURATION_HOURS
+	if _, err := h.db.exec(`UPDATE user_sessions SET last_update = ? WHERE user_id = ?`, time.Now().Add(-2*time.Hour).UTC(), e2ePlayerID); err != nil {
+		t.Fatal(err)
+	}
+	h.bot.expireStaleSessions(time.Now())
+	h.expect(t, "sendMessage", e2ePlayerID, "Поиск тайника приостановлен")
+	if _, err := h.db.GetPausedUserSession(e2ePlayerID); err != nil {
+		t.Fatalf("сессия не приостановлена: %v", err)
+	}
+
+	// Повторная проверка не шлет уведомление второй раз
+	h.bot.expireStaleSessions(time.Now())
+
+	h.sendText(e2ePlayerID, "/resume")
+	h.expect(t, "sendMessage", e2ePlayerID, "Поиск возобновлен: старый дуб")
+	h.sendText(e2ePlayerID, "/resume")
+	h.expect(t, "sendMessage", e2ePlayerID, "Поиск уже идет")
+
+	if count := strings.Count(h.api.describeRequests(0), "Поиск тайника приостановлен"); count != 1 {
+		t.Errorf("уведомление о приостановке отправлено %d раз", count)
+	}
+
+	h.moveTo(e2ePlayerID, liveID, e2eCacheLat, e2eCacheLon)
+	h.expect(t, "sendMessage", e2ePlayerID, "Вы нашли тайник: старый дуб")
+
+	h.sendText(e2ePlayerID, "/resume")
+	h.expect(t, "sendMessage", e2ePlayerID, "Нет приостановленного поиска")
+}
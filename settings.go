@@ -0,0 +1,108 @@
+package main
+
+import (
+	"database/sql"
+	"log/slog"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// displaySettings возвращает настройки отображения пользователя или настройки по умолчанию
+func (b *Bot) displaySettings(userID int64) DisplaySettings {
+	settings, err := b.DB.GetDisplaySettings(userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			slog.Error("Ошибка получения настроек отображения", "user_id", userID, "error", err)
+		}
+		return defaultDisplaySettings()
+	}
+	return *settings
+}
+
+// toggleDisplaySetting переключает параметр настроек по имени из callback-данных кнопки.
+// Возвращает false, если параметр неизвестен.
+func toggleDisplaySetting(settings *DisplaySettings, name string) bool {
+	switch name {
+	case "units":
+		if settings.Units == UnitsImperial {
+			settings.Units = UnitsMetric
+		} else {
+			settings.Units = UnitsImperial
+		}
+	case "bearing":
+		settings.ShowBearing = !settings.ShowBearing
+	case "points":
+		if settings.CompassPoints == 16 {
+			settings.CompassPoints = 8
+		} else {
+			settings.CompassPoints = 16
+		}
+	case "compact":
+		settings.Compact = !settings.Compact
+	default:
+		return false
+	}
+	return true
+}
+
+// settingsKeyboard возвращает кнопки /settings с текущими значениями параметров
+func settingsKeyboard(lang string, settings DisplaySettings) tgbotapi.InlineKeyboardMarkup {
+	onOff := func(on bool) string {
+		if on {
+			return translate(lang, "settings.on")
+		}
+		return translate(lang, "settings.off")
+	}
+
+	units := translate(lang, "settings.units_metric")
+	if settings.Units == UnitsImperial {
+		units = translate(lang, "settings.units_imperial")
+	}
+
+	button := func(key, name string, value interface{}) []tgbotapi.InlineKeyboardButton {
+		return tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(translate(lang, key, value), "settings:"+name),
+		)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		button("settings.button_units", "units", units),
+		button("settings.button_bearing", "bearing", onOff(settings.ShowBearing)),
+		button("settings.button_points", "points", settings.CompassPoints),
+		button("settings.button_compact", "compact", onOff(settings.Compact)),
+	)
+}
+
+// Обработчик команды /settings
+func (b *Bot) handleSettingsCommand(userID int64) {
+	lang := b.userLanguage(userID)
+
+	msg := tgbotapi.NewMessage(userID, translate(lang, "settings.prompt"))
+	msg.ReplyMarkup = settingsKeyboard(lang, b.displaySettings(userID))
+	b.Outbox.Send(msg)
+}
+
+// Обработчик нажатия на параметр в /settings: переключает его и обновляет кнопки
+func (b *Bot) handleSettingsCallback(query *tgbotapi.CallbackQuery, arg string) {
+	userID := query.From.ID
+
+	settings := b.displaySettings(userID)
+	if !toggleDisplaySetting(&settings, arg) {
+		return
+	}
+
+	if err := b.DB.SaveDisplaySettings(userID, &settings); err != nil {
+		slog.Error("Ошибка сохранения настроек отображения", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	if query.Message == nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID,
+		settingsKeyboard(b.userLanguage(userID), settings))
+	if _, err := b.Outbox.Send(edit); err != nil {
+		slog.Error("Ошибка обновления настроек", "user_id", userID, "error", err)
+	}
+}
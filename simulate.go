@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Скорость пешехода, если в треке нет времени точек и -speed не задан
+const defaultWalkingSpeed = 1.4
+
+// TrackPoint - точка записанного трека
+type TrackPoint struct {
+	Lat      float64
+	Lon      float64
+	Time     time.Time // Нулевое, если в файле нет времени
+	Accuracy float64   // Точность в метрах из CSV; 0 - неизвестна
+}
+
+// parseTrack разбирает трек в формате GPX (trk, rte или wpt) или CSV (lat, lon[, time, accuracy])
+func parseTrack(fileName string, data []byte) ([]TrackPoint, error) {
+	var (
+		points []TrackPoint
+		err    error
+	)
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".gpx":
+		points, err = decodeGPXTrack(data)
+	case ".csv":
+		points, err = decodeCSVTrack(data)
+	default:
+		return nil, fmt.Errorf("неизвестный формат трека %s: нужен .gpx или .csv", fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, errors.New("в треке нет ни одной точки")
+	}
+	return points, nil
+}
+
+// Трек GPX; пространство имен не указано, чтобы читать и GPX 1.0, и GPX 1.1
+type gpxTrackFile struct {
+	Tracks []struct {
+		Segments []struct {
+			Points []gpxTrackPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+	Routes []struct {
+		Points []gpxTrackPoint `xml:"rtept"`
+	} `xml:"rte"`
+	Waypoints []gpxTrackPoint `xml:"wpt"`
+}
+
+type gpxTrackPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Time string  `xml:"time"`
+}
+
+func decodeGPXTrack(data []byte) ([]TrackPoint, error) {
+	var file gpxTrackFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("некорректный GPX: %w", err)
+	}
+
+	// Берем точки трека, а если его нет - маршрута или путевые точки
+	var raw []gpxTrackPoint
+	for _, track := range file.Tracks {
+		for _, segment := range track.Segments {
+			raw = append(raw, segment.Points...)
+		}
+	}
+	if len(raw) == 0 {
+		for _, route := range file.Routes {
+			raw = append(raw, route.Points...)
+		}
+	}
+	if len(raw) == 0 {
+		raw = file.Waypoints
+	}
+
+	points := make([]TrackPoint, 0, len(raw))
+	for i, p := range raw {
+		point := TrackPoint{Lat: p.Lat, Lon: p.Lon}
+		if p.Time != "" {
+			t, err := time.Parse(time.RFC3339, strings.TrimSpace(p.Time))
+			if err != nil {
+				return nil, fmt.Errorf("точка %d: некорректное время %q", i+1, p.Time)
+			}
+			point.Time = t
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+func decodeCSVTrack(data []byte) ([]TrackPoint, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("некорректный CSV: %w", err)
+	}
+
+	column := func(names ...string) int {
+		for i, name := range header {
+			for _, want := range names {
+				if strings.EqualFold(strings.TrimSpace(name), want) {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	latColumn := column("lat", "latitude")
+	lonColumn := column("lon", "lng", "longitude")
+	timeColumn := column("time", "timestamp")
+	accuracyColumn := column("accuracy", "horizontal_accuracy")
+	if latColumn < 0 || lonColumn < 0 {
+		return nil, errors.New("в заголовке CSV нужны колонки lat и lon")
+	}
+
+	var points []TrackPoint
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: %w", line, err)
+		}
+
+		field := func(i int) string {
+			if i >= 0 && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		var point TrackPoint
+		if point.Lat, err = parseNumber(field(latColumn)); err != nil {
+			return nil, fmt.Errorf("строка %d: некорректная широта %q", line, field(latColumn))
+		}
+		if point.Lon, err = parseNumber(field(lonColumn)); err != nil {
+			return nil, fmt.Errorf("строка %d: некорректная долгота %q", line, field(lonColumn))
+		}
+		if value := field(timeColumn); value != "" {
+			if point.Time, err = time.Parse(time.RFC3339, value); err != nil {
+				return nil, fmt.Errorf("строка %d: некорректное время %q", line, value)
+			}
+		}
+		if value := field(accuracyColumn); value != "" {
+			if point.Accuracy, err = parseNumber(value); err != nil || point.Accuracy < 0 {
+				return nil, fmt.Errorf("строка %d: некорректная точность %q", line, value)
+			}
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// SimulatedFix - синтетическое обновление трансляции геопозиции
+type SimulatedFix struct {
+	Lat      float64
+	Lon      float64
+	Accuracy float64       // Точность ближайшей предыдущей точки трека
+	Elapsed  time.Duration // Время от начала трека
+}
+
+// resampleTrack превращает трек в обновления геопозиции через каждые interval.
+// При speed > 0 игрок движется по треку с этой скоростью (м/с), иначе - по времени точек трека.
+func resampleTrack(points []TrackPoint, speed float64, interval time.Duration) ([]SimulatedFix, error) {
+	if interval <= 0 {
+		return nil, errors.New("интервал обновлений должен быть положительным")
+	}
+
+	// Момент прохождения каждой точки трека
+	offsets := make([]time.Duration, len(points))
+	for i := 1; i < len(points); i++ {
+		if speed > 0 {
+			meters := calculateDistance(points[i-1].Lat, points[i-1].Lon, points[i].Lat, points[i].Lon) * 1000
+			offsets[i] = offsets[i-1] + time.Duration(meters/speed*float64(time.Second))
+			continue
+		}
+		if points[i].Time.IsZero() || points[0].Time.IsZero() {
+			return nil, errors.New("в треке нет времени точек, укажите скорость -speed")
+		}
+		offsets[i] = points[i].Time.Sub(points[0].Time)
+		if offsets[i] < offsets[i-1] {
+			return nil, fmt.Errorf("время точки %d раньше предыдущей", i+1)
+		}
+	}
+
+	var fixes []SimulatedFix
+	segment := 0
+	for elapsed := time.Duration(0); ; elapsed += interval {
+		if elapsed >= offsets[len(offsets)-1] {
+			last := points[len(points)-1]
+			fixes = append(fixes, SimulatedFix{Lat: last.Lat, Lon: last.Lon, Accuracy: last.Accuracy, Elapsed: offsets[len(offsets)-1]})
+			return fixes, nil
+		}
+
+		for offsets[segment+1] <= elapsed {
+			segment++
+		}
+		from, to := points[segment], points[segment+1]
+		fraction := float64(elapsed-offsets[segment]) / float64(offsets[segment+1]-offsets[segment])
+		fixes = append(fixes, SimulatedFix{
+			Lat:      from.Lat + (to.Lat-from.Lat)*fraction,
+			Lon:      from.Lon + (to.Lon-from.Lon)*fraction,
+			Accuracy: from.Accuracy,
+			Elapsed:  elapsed,
+		})
+	}
+}
+
+// simulateHunt прогоняет обновления через ту же логику навигации, что и бот, и печатает,
+// что увидел бы игрок: первое навигационное сообщение, его правки и момент находки.
+// Сообщения бота выводятся на языке lang с настройками отображения settings,
+// пояснения симулятора - на русском и в метрах.
+func (b *Bot) simulateHunt(w io.Writer, lang string, settings DisplaySettings, cache *Cache, fixes []SimulatedFix, replay float64) bool {
+	fmt.Fprintf(w, "Тайник «%s»: %.6f, %.6f, радиус обнаружения %d м\n\n",
+		cache.CodeWord, cache.Latitude, cache.Longitude, int(cache.TargetRadius(b.Config.TargetDistanceMeters)))
+
+	session := &UserSession{CacheID: cache.ID, IsActive: true}
+	for i, fix := range fixes {
+		if replay > 0 && i > 0 {
+			time.Sleep(time.Duration(float64(fix.Elapsed-fixes[i-1].Elapsed) / replay))
+		}
+
+		// Как и клиент Telegram, сообщаем направление движения (1-360), только если игрок сместился
+		heading := 0
+		if i > 0 && calculateDistance(fixes[i-1].Lat, fixes[i-1].Lon, fix.Lat, fix.Lon)*1000 >= 1 {
+			heading = int(math.Round(calculateBearing(fixes[i-1].Lat, fixes[i-1].Lon, fix.Lat, fix.Lon)))
+			if heading == 0 {
+				heading = 360
+			}
+		}
+
+		clock := formatClock(fix.Elapsed)
+		smoothed, ok := b.Tracker.Add(session.UserID, cache.ID, LocationFix{Lat: fix.Lat, Lon: fix.Lon, Accuracy: fix.Accuracy, Heading: heading})
+		if !ok {
+			fmt.Fprintf(w, "[%s] %.6f, %.6f - точка отброшена: точность %d м\n", clock, fix.Lat, fix.Lon, int(fix.Accuracy))
+			continue
+		}
+
+		text, reached := b.navigate(lang, settings, session, cache, smoothed)
+
+		if reached {
+			fmt.Fprintf(w, "[%s] %.6f, %.6f\n🎉 Тайник найден через %s, пройдено %s, до тайника %d м\n",
+				clock, fix.Lat, fix.Lon, formatDuration(LangRu, fix.Elapsed), formatDistance(LangRu, UnitsMetric, session.DistanceWalked),
+				calculateDistanceMeters(smoothed.Lat, smoothed.Lon, cache.Latitude, cache.Longitude))
+			return true
+		}
+
+		action := "правка сообщения"
+		switch {
+		case session.LastMessageID == 0:
+			action = "новое сообщение"
+		case text == session.LastMessageText:
+			action = "без изменений"
+		}
+		fmt.Fprintf(w, "[%s] %.6f, %.6f - %s\n", clock, fix.Lat, fix.Lon, action)
+		if action != "без изменений" {
+			fmt.Fprintf(w, "%s\n\n", text)
+		}
+
+		session.LastMessageID = 1
+		session.LastMessageText = text
+		session.LastLatitude = smoothed.Lat
+		session.LastLongitude = smoothed.Lon
+	}
+
+	last := fixes[len(fixes)-1]
+	fmt.Fprintf(w, "Трек закончился, тайник не найден: до него %d м, пройдено %s\n",
+		calculateDistanceMeters(last.Lat, last.Lon, cache.Latitude, cache.Longitude), formatDistance(LangRu, UnitsMetric, session.DistanceWalked))
+	return false
+}
+
+// formatClock форматирует время от начала трека как ММ:СС или Ч:ММ:СС
+func formatClock(d time.Duration) string {
+	seconds := int(d.Round(time.Second).Seconds())
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
+
+// findSimulationTarget ищет тайник по кодовому слову; для маршрута берется его первый этап
+func findSimulationTarget(db Store, codeWord string) (*Cache, error) {
+	cache, err := db.GetCacheByCodeWord(codeWord)
+	if err != sql.ErrNoRows {
+		return cache, err
+	}
+
+	trail, err := db.GetTrailByCodeWord(codeWord)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("тайник или маршрут «%s» не найден", codeWord)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stages, err := db.GetTrailStages(trail.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("в маршруте «%s» нет этапов", codeWord)
+	}
+	return db.GetCacheByID(stages[0].CacheID)
+}
+
+// runSimulateCommand проигрывает записанный трек против тайника без Telegram:
+// geocaching-bot simulate -track walk.gpx -code "старый дуб" [-speed 1.4] [-interval 5s] [-radius 30] [-replay 10] [-lang en]
+// [-units imperial] [-bearing] [-points 16] [-compact] [-max-accuracy 50] [-smoothing 3] [-confirmations 2]
+func runSimulateCommand(args []string) error {
+	config := loadConfig()
+
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	trackPath := flags.String("track", "", "трек в формате GPX или CSV (lat, lon[, time, accuracy])")
+	codeWord := flags.String("code", "", "кодовое слово тайника или маршрута")
+	speed := flags.Float64("speed", 0, "скорость движения в м/с (0 - по времени точек трека, без времени - 1.4 м/с)")
+	interval := flags.Duration("interval", time.Duration(config.UpdateIntervalSeconds)*time.Second, "интервал между обновлениями геопозиции")
+	radius := flags.Float64("radius", 0, "радиус обнаружения в метрах вместо сохраненного у тайника")
+	navigation := flags.String("navigation", "", "режим навигации вместо сохраненного у тайника: compass, distance или hotcold")
+	replay := flags.Float64("replay", 0, "ускорение воспроизведения в реальном времени (0 - без пауз, 1 - реальное время)")
+	lang := flags.String("lang", defaultLanguage, "язык сообщений бота: "+strings.Join(languages, ", "))
+	settings := defaultDisplaySettings()
+	flags.StringVar(&settings.Units, "units", settings.Units, "единицы расстояний: metric или imperial")
+	flags.BoolVar(&settings.ShowBearing, "bearing", false, "показывать азимут в градусах")
+	flags.IntVar(&settings.CompassPoints, "points", settings.CompassPoints, "точность направления: 8 или 16 румбов")
+	flags.BoolVar(&settings.Compact, "compact", false, "навигация без ASCII-компаса")
+	flags.Float64Var(&config.MaxLocationAccuracyMeters, "max-accuracy", config.MaxLocationAccuracyMeters, "отбрасывать точки с точностью хуже, м (0 - не отбрасывать)")
+	flags.IntVar(&config.LocationSmoothingWindow, "smoothing", config.LocationSmoothingWindow, "сколько последних точек усреднять (1 - без сглаживания)")
+	flags.IntVar(&config.ArrivalConfirmations, "confirmations", config.ArrivalConfirmations, "сколько точек подряд должно попасть в радиус обнаружения")
+	flags.Parse(args)
+
+	if *trackPath == "" || *codeWord == "" {
+		flags.Usage()
+		return errors.New("нужно указать -track и -code")
+	}
+	if !isSupportedLanguage(*lang) {
+		return fmt.Errorf("неизвестный язык: %s", *lang)
+	}
+	if settings.Units != UnitsMetric && settings.Units != UnitsImperial {
+		return fmt.Errorf("неизвестные единицы: %s", settings.Units)
+	}
+	if settings.CompassPoints != 8 && settings.CompassPoints != 16 {
+		return fmt.Errorf("точность направления может быть 8 или 16 румбов, указано %d", settings.CompassPoints)
+	}
+	if *navigation != "" && !isNavigationMode(*navigation) {
+		return fmt.Errorf("неизвестный режим навигации: %s", *navigation)
+	}
+
+	data, err := os.ReadFile(*trackPath)
+	if err != nil {
+		return err
+	}
+	points, err := parseTrack(*trackPath, data)
+	if err != nil {
+		return err
+	}
+
+	if *speed == 0 && points[0].Time.IsZero() {
+		*speed = defaultWalkingSpeed
+	}
+	fixes, err := resampleTrack(points, *speed, *interval)
+	if err != nil {
+		return err
+	}
+
+	db, err := OpenStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	cache, err := findSimulationTarget(db, strings.TrimSpace(*codeWord))
+	if err != nil {
+		return err
+	}
+	if *radius > 0 {
+		cache.RadiusMeters = *radius
+	}
+	if *navigation != "" {
+		cache.NavigationMode = *navigation
+	}
+
+	bot := &Bot{Config: config, Tracker: NewLocationTracker(config)}
+	bot.simulateHunt(os.Stdout, *lang, settings, cache, fixes, *replay)
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+// Пространственный индекс тайников. Поверхность делится на ячейки сетки
+// gridCellDegrees x gridCellDegrees, номер ячейки хранится в caches.grid_cell с индексом.
+// Запрос «тайники рядом с точкой» выбирает ячейки, покрывающие прямоугольник вокруг точки, -
+// по одному диапазону номеров на ряд сетки - и уточняет результат по координатам.
+// Формула номера ячейки повторяется в миграции 0007_cache_grid - менять их нужно вместе.
+const (
+	gridCellDegrees = 0.01  // Около 1.1 км по широте
+	gridRows        = 18000 // 180 / gridCellDegrees
+	gridColumns     = 36000 // 360 / gridCellDegrees
+
+	// Если прямоугольник занимает больше рядов, запрашивается один диапазон
+	// от первой до последней ячейки: длинный список условий медленнее лишних строк
+	gridMaxRanges = 64
+)
+
+// Метров в одном градусе широты (и долготы на экваторе) - на той же сфере радиусом 6371 км,
+// по которой calculateDistance считает расстояния
+const metersPerDegree = 6371000 * math.Pi / 180
+
+// gridIndex возвращает номер ряда или столбца сетки для координаты, сдвинутой к нулю
+func gridIndex(shifted float64, count int64) int64 {
+	index := int64(math.Floor(shifted / gridCellDegrees))
+	if index < 0 {
+		return 0
+	}
+	if index >= count {
+		return count - 1
+	}
+	return index
+}
+
+// gridCell возвращает номер ячейки сетки, в которую попадает точка
+func gridCell(lat, lon float64) int64 {
+	return gridIndex(lat+90, gridRows)*gridColumns + gridIndex(lon+180, gridColumns)
+}
+
+// gridRanges возвращает диапазоны номеров ячеек (включительно), покрывающие прямоугольник координат
+func gridRanges(minLat, maxLat, minLon, maxLon float64) [][2]int64 {
+	rowMin, rowMax := gridIndex(minLat+90, gridRows), gridIndex(maxLat+90, gridRows)
+	colMin, colMax := gridIndex(minLon+180, gridColumns), gridIndex(maxLon+180, gridColumns)
+
+	// Ряды во всю ширину идут подряд и сливаются в один диапазон
+	if rowMax-rowMin+1 > gridMaxRanges || (colMin == 0 && colMax == gridColumns-1) {
+		return [][2]int64{{rowMin*gridColumns + colMin, rowMax*gridColumns + colMax}}
+	}
+
+	ranges := make([][2]int64, 0, rowMax-rowMin+1)
+	for row := rowMin; row <= rowMax; row++ {
+		ranges = append(ranges, [2]int64{row*gridColumns + colMin, row*gridColumns + colMax})
+	}
+	return ranges
+}
+
+// boundingBox возвращает прямоугольник координат, в который целиком попадает круг радиусом radiusMeters.
+// Если прямоугольник пересекает антимеридиан или полюс, долгота не ограничивается.
+func boundingBox(lat, lon, radiusMeters float64) (minLat, maxLat, minLon, maxLon float64) {
+	deltaLat := radiusMeters / metersPerDegree
+	minLat = math.Max(lat-deltaLat, -90)
+	maxLat = math.Min(lat+deltaLat, 90)
+
+	// Градус долготы короче к полюсам; берем худший случай - широту края прямоугольника, ближнего к полюсу
+	cos := math.Cos(math.Max(math.Abs(minLat), math.Abs(maxLat)) * math.Pi / 180)
+	if cos <= 0 {
+		return minLat, maxLat, -180, 180
+	}
+	deltaLon := radiusMeters / (metersPerDegree * cos)
+	minLon, maxLon = lon-deltaLon, lon+deltaLon
+	if minLon < -180 || maxLon > 180 {
+		return minLat, maxLat, -180, 180
+	}
+	return minLat, maxLat, minLon, maxLon
+}
+
+// GetCachesInBox возвращает тайники внутри прямоугольника координат; publicOnly - только публичные
+// самостоятельные тайники: этап маршрута нельзя искать отдельно от маршрута.
+// Строки выбираются по индексу idx_caches_grid_cell.
+func (d *Database) GetCachesInBox(minLat, maxLat, minLon, maxLon float64, publicOnly bool) ([]*Cache, error) {
+	var where strings.Builder
+	var args []interface{}
+
+	where.WriteString("(")
+	for i, cells := range gridRanges(minLat, maxLat, minLon, maxLon) {
+		if i > 0 {
+			where.WriteString(" OR ")
+		}
+		where.WriteString("grid_cell BETWEEN ? AND ?")
+		args = append(args, cells[0], cells[1])
+	}
+	where.WriteString(") AND latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?")
+	args = append(args, minLat, maxLat, minLon, maxLon)
+	if publicOnly {
+		where.WriteString(" AND is_public = ? AND id NOT IN (SELECT cache_id FROM trail_stages)")
+		args = append(args, true)
+	}
+
+	rows, err := d.query(`SELECT `+cacheColumns+` FROM caches WHERE `+where.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var caches []*Cache
+	for rows.Next() {
+		cache, err := scanCache(rows)
+		if err != nil {
+			return nil, err
+		}
+		caches = append(caches, cache)
+	}
+
+	return caches, rows.Err()
+}
+
+// GetCachesWithinRadius возвращает тайники не дальше radiusMeters от точки; publicOnly - только публичные самостоятельные
+func (d *Database) GetCachesWithinRadius(lat, lon, radiusMeters float64, publicOnly bool) ([]*Cache, error) {
+	minLat, maxLat, minLon, maxLon := boundingBox(lat, lon, radiusMeters)
+	caches, err := d.GetCachesInBox(minLat, maxLat, minLon, maxLon, publicOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	// В углы прямоугольника попадают тайники дальше радиуса - отсеиваем их по точному расстоянию
+	within := caches[:0]
+	for _, cache := range caches {
+		if calculateDistance(lat, lon, cache.Latitude, cache.Longitude)*1000 <= radiusMeters {
+			within = append(within, cache)
+		}
+	}
+	return within, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestBoundingBox(t *testing.T) {
+	minLat, maxLat, minLon, maxLon := boundingBox(55.75, 37.61, 1000)
+	// Стороны прямоугольника не ближе 1 км от центра - круг целиком внутри
+	for _, side := range [][2]float64{{minLat, 37.61}, {maxLat, 37.61}, {55.75, minLon}, {55.75, maxLon}} {
+		if distance := calculateDistance(55.75, 37.61, side[0], side[1]) * 1000; distance < 999.99 {
+			t.Errorf("сторона прямоугольника %v в %.0f м от центра", side, distance)
+		}
+	}
+
+	// У антимеридиана долгота не ограничивается
+	if _, _, minLon, maxLon := boundingBox(0, 179.999, 1000); minLon != -180 || maxLon != 180 {
+		t.Errorf("у антимеридиана долгота ограничена: %v..%v", minLon, maxLon)
+	}
+}
+
+func TestGridCell(t *testing.T) {
+	if a, b := gridCell(55.7501, 37.6101), gridCell(55.7599, 37.6199); a != b {
+		t.Errorf("точки одной ячейки в разных ячейках: %d и %d", a, b)
+	}
+	if a, b := gridCell(55.7599, 37.61), gridCell(55.7601, 37.61); b-a != gridColumns {
+		t.Errorf("соседние по широте ячейки: %d и %d", a, b)
+	}
+	// Края диапазона координат не выходят за сетку и не попадают в соседний ряд
+	if cell := gridCell(90, 180); cell != gridRows*gridColumns-1 {
+		t.Errorf("ячейка точки (90, 180) = %d", cell)
+	}
+	if cell := gridCell(-90, -180); cell != 0 {
+		t.Errorf("ячейка точки (-90, -180) = %d", cell)
+	}
+}
+
+// seedCaches создает count тайников, равномерно разбросанных по прямоугольнику, в одной транзакции
+func seedCaches(tb testing.TB, db *Database, count int, minLat, maxLat, minLon, maxLon float64) []*Cache {
+	tb.Helper()
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	stmt, err := tx.Prepare(`INSERT INTO caches (code_word, latitude, longitude, file_id, file_type, created_by, is_public, grid_cell)
+		VALUES (?, ?, ?, '', 'photo', 1, ?, ?)`)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	random := rand.New(rand.NewSource(1))
+	caches := make([]*Cache, 0, count)
+	for i := 0; i < count; i++ {
+		cache := &Cache{
+			CodeWord:  fmt.Sprintf("тайник %d", i),
+			Latitude:  minLat + random.Float64()*(maxLat-minLat),
+			Longitude: minLon + random.Float64()*(maxLon-minLon),
+			IsPublic:  i%2 == 0,
+		}
+		if _, err := stmt.Exec(cache.CodeWord, cache.Latitude, cache.Longitude, cache.IsPublic, gridCell(cache.Latitude, cache.Longitude)); err != nil {
+			tb.Fatal(err)
+		}
+		caches = append(caches, cache)
+	}
+
+	stmt.Close()
+	if err := tx.Commit(); err != nil {
+		tb.Fatal(err)
+	}
+	return caches
+}
+
+// scanWithinRadius - поиск без индекса: все тайники таблицы и haversine для каждого
+func scanWithinRadius(db *Database, lat, lon, radiusMeters float64) ([]*Cache, error) {
+	rows, err := db.query(`SELECT ` + cacheColumns + ` FROM caches`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var caches []*Cache
+	for rows.Next() {
+		cache, err := scanCache(rows)
+		if err != nil {
+			return nil, err
+		}
+		if calculateDistance(lat, lon, cache.Latitude, cache.Longitude)*1000 <= radiusMeters {
+			caches = append(caches, cache)
+		}
+	}
+	return caches, rows.Err()
+}
+
+func codeWords(caches []*Cache) []string {
+	words := make([]string, 0, len(caches))
+	for _, cache := range caches {
+		words = append(words, cache.CodeWord)
+	}
+	sort.Strings(words)
+	return words
+}
+
+func TestGetCachesWithinRadiusMatchesFullScan(t *testing.T) {
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "spatial.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	seedCaches(t, db, 2000, 55.5, 56.0, 37.2, 38.0)
+	// Тайники по обе стороны антимеридиана
+	for i, lon := range []float64{179.995, -179.995} {
+		if err := db.CreateCache(&Cache{CodeWord: fmt.Sprintf("антимеридиан %d", i), Latitude: -16.5, Longitude: lon}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		lat, lon, radius float64
+	}{
+		{55.75, 37.61, 500},
+		{55.75, 37.61, 5000},
+		{55.52, 37.25, 3000},   // У края области с тайниками
+		{55.75, 37.61, 100000}, // Больше gridMaxRanges рядов
+		{-16.5, 179.999, 2000}, // Через антимеридиан
+		{89.999, 0, 1000},      // У полюса
+	} {
+		got, err := db.GetCachesWithinRadius(tt.lat, tt.lon, tt.radius, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := scanWithinRadius(db, tt.lat, tt.lon, tt.radius)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(codeWords(got)) != fmt.Sprint(codeWords(want)) {
+			t.Errorf("(%v, %v, %v м): по сетке %d тайников, полным перебором %d", tt.lat, tt.lon, tt.radius, len(got), len(want))
+		}
+	}
+
+	public, err := db.GetCachesWithinRadius(55.75, 37.61, 5000, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cache := range public {
+		if !cache.IsPublic {
+			t.Fatalf("непубличный тайник в выборке только публичных: %+v", cache)
+		}
+	}
+}
+
+// Запросы по 100 000 тайников, разбросанных по Московской области (~2.5° x 5°)
+func BenchmarkCachesWithinRadius(b *testing.B) {
+	db, err := NewDatabase(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	seedCaches(b, db, 100000, 54.5, 57.0, 35.0, 40.0)
+
+	for _, radius := range []float64{1000, 5000, 20000} {
+		b.Run(fmt.Sprintf("grid_%dm", int(radius)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := db.GetCachesWithinRadius(55.75, 37.61, radius, false); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	b.Run("nearby_public_5000m", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := findNearbyCaches(db, 55.75, 37.61, 5000, nearbyResultsLimit); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("full_scan_5000m", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := scanWithinRadius(db, 55.75, 37.61, 5000); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
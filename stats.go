@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Количество игроков в рейтинге /top
+const leaderboardSize = 10
+
+// Периоды рейтинга
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+	PeriodAll  = "all"
+)
+
+// parsePeriod разбирает аргумент команды /top
+func parsePeriod(arg string) string {
+	switch strings.ToLower(strings.TrimSpace(arg)) {
+	case "day", "today", "день", "сегодня":
+		return PeriodDay
+	case "week", "неделя":
+		return PeriodWeek
+	default:
+		return PeriodAll
+	}
+}
+
+// periodStart возвращает начало периода рейтинга (нулевое время - за все время)
+func periodStart(period string, now time.Time) time.Time {
+	switch period {
+	case PeriodDay:
+		return now.Add(-24 * time.Hour)
+	case PeriodWeek:
+		return now.Add(-7 * 24 * time.Hour)
+	default:
+		return time.Time{}
+	}
+}
+
+func periodTitle(lang, period string) string {
+	switch period {
+	case PeriodDay, PeriodWeek:
+		return translate(lang, "top.period_"+period)
+	default:
+		return translate(lang, "top.period_all")
+	}
+}
+
+// displayName возвращает имя игрока для рейтинга
+func displayName(lang string, user User) string {
+	if user.FirstName != "" {
+		return user.FirstName
+	}
+	if user.Username != "" {
+		return "@" + user.Username
+	}
+	return translate(lang, "top.player", user.ID)
+}
+
+// rememberUser сохраняет имя пользователя для отображения в рейтинге
+func (b *Bot) rememberUser(from *tgbotapi.User) {
+	if from == nil {
+		return
+	}
+
+	user := &User{
+		ID:           from.ID,
+		Username:     from.UserName,
+		FirstName:    from.FirstName,
+		LanguageCode: from.LanguageCode,
+	}
+	if err := b.DB.SaveUser(user); err != nil {
+		slog.Error("Ошибка сохранения пользователя", "user_id", from.ID, "error", err)
+	}
+}
+
+// Обработчик команды /top [day|week|all]
+func (b *Bot) handleTopCommand(userID int64, args string) {
+	text, keyboard, err := b.renderLeaderboard(b.userLanguage(userID), b.displaySettings(userID).Units, parsePeriod(args))
+	if err != nil {
+		slog.Error("Ошибка получения рейтинга", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(userID, text)
+	msg.ParseMode = "Markdown"
+	msg.ReplyMarkup = keyboard
+	b.Outbox.Send(msg)
+}
+
+// Обработчик переключения периода рейтинга
+func (b *Bot) handleTopCallback(query *tgbotapi.CallbackQuery, arg string) {
+	if query.Message == nil {
+		return
+	}
+
+	text, keyboard, err := b.renderLeaderboard(b.userLanguage(query.From.ID), b.displaySettings(query.From.ID).Units, parsePeriod(arg))
+	if err != nil {
+		slog.Error("Ошибка получения рейтинга", "user_id", query.From.ID, "error", err)
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(query.Message.Chat.ID, query.Message.MessageID, text, keyboard)
+	edit.ParseMode = "Markdown"
+	if _, err := b.Outbox.Send(edit); err != nil {
+		slog.Error("Ошибка обновления рейтинга", "user_id", query.From.ID, "error", err)
+	}
+}
+
+// renderLeaderboard формирует сообщение рейтинга в стиле навигационного сообщения.
+// Расстояния выводятся в единицах units пользователя, который смотрит рейтинг.
+func (b *Bot) renderLeaderboard(lang, units, period string) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(translate(lang, "top.button_day"), "top:"+PeriodDay),
+			tgbotapi.NewInlineKeyboardButtonData(translate(lang, "top.button_week"), "top:"+PeriodWeek),
+			tgbotapi.NewInlineKeyboardButtonData(translate(lang, "top.button_all"), "top:"+PeriodAll),
+		),
+	)
+
+	entries, err := b.DB.GetLeaderboard(periodStart(period, time.Now()), leaderboardSize)
+	if err != nil {
+		return "", keyboard, err
+	}
+
+	var text strings.Builder
+	text.WriteString(translate(lang, "top.header", periodTitle(lang, period)))
+
+	if len(entries) == 0 {
+		text.WriteString(translate(lang, "top.empty"))
+	}
+
+	medals := []string{"🥇", "🥈", "🥉"}
+	for i, entry := range entries {
+		place := fmt.Sprintf("%d.", i+1)
+		if i < len(medals) {
+			place = medals[i]
+		}
+		fmt.Fprintf(&text, "%s %s - *%d* 🏆 - 👣 %s\n",
+			place, escapeMarkdown(displayName(lang, entry.User)), entry.Finds, formatDistance(lang, units, entry.DistanceMeters))
+	}
+
+	text.WriteString("\n═══════════════════")
+	return text.String(), keyboard, nil
+}
+
+// Обработчик команды /me
+func (b *Bot) handleMeCommand(userID int64) {
+	lang := b.userLanguage(userID)
+
+	stats, err := b.DB.GetUserStats(userID)
+	if err != nil {
+		slog.Error("Ошибка получения статистики", "user_id", userID, "error", err)
+		b.sendMessage(userID, translate(lang, "error.generic"))
+		return
+	}
+
+	if stats.Finds == 0 {
+		b.sendMessage(userID, translate(lang, "history.empty"))
+		return
+	}
+
+	fastest := "-"
+	if stats.FastestSeconds > 0 {
+		fastest = formatDuration(lang, time.Duration(stats.FastestSeconds)*time.Second)
+	}
+
+	text := translate(lang, "me.message", translatePlural(lang, "count.caches", stats.Finds), fastest,
+		formatDistance(lang, b.displaySettings(userID).Units, stats.DistanceMeters), stats.Rank, translatePlural(lang, "count.players", stats.Players))
+
+	msg := tgbotapi.NewMessage(userID, text)
+	msg.ParseMode = "Markdown"
+	b.Outbox.Send(msg)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLeaderboardCountsDistinctCaches(t *testing.T) {
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	const first, second int64 = 1, 2
+	now := time.Now()
+	for _, find := range []*Find{
+		// Первый игрок нашел два разных тайника
+		{UserID: first, CacheID: 10, FoundAt: now.Add(-3 * time.Hour), DurationSeconds: 600, DistanceMeters: 800},
+		{UserID: first, CacheID: 11, FoundAt: now.Add(-2 * time.Hour), DurationSeconds: 300, DistanceMeters: 500},
+		// Второй трижды «нашел» один и тот же тайник, стоя рядом с ним
+		{UserID: second, CacheID: 10, FoundAt: now.Add(-time.Hour), DurationSeconds: 900},
+		{UserID: second, CacheID: 10, FoundAt: now.Add(-30 * time.Minute), DurationSeconds: 5},
+		{UserID: second, CacheID: 10, FoundAt: now.Add(-10 * time.Minute), DurationSeconds: 5},
+	} {
+		if err := db.CreateFind(find); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := db.GetLeaderboard(time.Time{}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].User.ID != first || entries[0].Finds != 2 || entries[1].Finds != 1 {
+		t.Fatalf("повторные находки подняли игрока в рейтинге: %+v, %+v", entries[0], entries[1])
+	}
+	if entries[0].FastestSeconds != 300 || entries[0].DistanceMeters != 1300 {
+		t.Errorf("неверные время и расстояние: %+v", entries[0])
+	}
+
+	// За последний час в рейтинге только второй игрок
+	if entries, err := db.GetLeaderboard(now.Add(-90*time.Minute), 10); err != nil || len(entries) != 1 || entries[0].User.ID != second {
+		t.Errorf("фильтр по периоду не применен: %+v (ошибка %v)", entries, err)
+	}
+
+	stats, err := db.GetUserStats(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Finds != 1 || stats.Rank != 2 || stats.Players != 2 {
+		t.Errorf("повторные находки подняли место в /me: %+v", stats)
+	}
+
+	stats, err = db.GetUserStats(3)
+	if err != nil || stats.Finds != 0 || stats.Rank != 0 {
+		t.Errorf("статистика игрока без находок: %+v (ошибка %v)", stats, err)
+	}
+}
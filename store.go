@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// Store - хранилище данных бота. Реализации: SQLite (файл DATABASE_PATH) и PostgreSQL (DATABASE_URL).
+// PostgreSQL позволяет нескольким репликам бота работать с общим состоянием.
+type Store interface {
+	Close() error
+	Ping() error
+
+	// Тайники
+	CreateCache(cache *Cache) error
+	GetCacheByCodeWord(codeWord string) (*Cache, error)
+	GetCacheByID(id int64) (*Cache, error)
+	ListCaches(limit, offset int) ([]*Cache, error)
+	ExportableCaches() ([]*Cache, error)
+	CountCaches() (int, error)
+	GetCachesInBox(minLat, maxLat, minLon, maxLon float64, publicOnly bool) ([]*Cache, error)
+	GetCachesWithinRadius(lat, lon, radiusMeters float64, publicOnly bool) ([]*Cache, error)
+	UpdateCache(cache *Cache) error
+	DeleteCache(id int64) error
+	IncrementCacheFindCount(id int64) error
+
+	// Пользовательские сессии
+	CreateOrUpdateUserSession(session *UserSession) error
+	GetUserSession(userID int64) (*UserSession, error)
+	DeactivateUserSession(userID int64) error
+	SetUserSessionMessageID(userID, cacheID int64, messageID int) error
+	UpdateUserSessionPosition(session *UserSession) error
+	TouchUserSession(userID int64) error
+	CountActiveUserSessions() (int, error)
+	GetStaleUserSessions(before time.Time) ([]*UserSession, error)
+	PauseUserSession(userID int64) (bool, error)
+	GetPausedUserSession(userID int64) (*UserSession, error)
+	ResumeUserSession(userID int64) error
+
+	// Админские сессии
+	CreateOrUpdateAdminSession(session *AdminSession) error
+	GetAdminSession(userID int64) (*AdminSession, error)
+	DeleteAdminSession(userID int64) error
+
+	// Маршруты
+	CreateTrail(trail *Trail) error
+	GetTrailByCodeWord(codeWord string) (*Trail, error)
+	GetTrailByID(id int64) (*Trail, error)
+	PublishTrail(id int64) error
+	DeleteTrail(id int64) error
+	CreateTrailStage(cache *Cache, stage *TrailStage) error
+	AddTrailStage(stage *TrailStage) error
+	UpdateTrailStageClue(trailID int64, position int, clue string) error
+	GetTrailStages(trailID int64) ([]*TrailStage, error)
+	GetTrailStageByCacheID(cacheID int64) (*TrailStage, error)
+	GetNextTrailStage(trailID int64, afterPosition int) (*TrailStage, error)
+	GetTrailProgress(userID, trailID int64) (*TrailProgress, error)
+	SaveTrailProgress(progress *TrailProgress) error
+
+	// Вопросы тайников
+	SaveCachePuzzle(puzzle *CachePuzzle) error
+	GetCachePuzzle(cacheID int64) (*CachePuzzle, error)
+	DeleteCachePuzzle(cacheID int64) error
+	GetPuzzleAttempt(userID, cacheID int64) (*PuzzleAttempt, error)
+	SavePuzzleAttempt(attempt *PuzzleAttempt) error
+	ClearPuzzleAwaiting(userID int64) error
+
+	// Журнал находок
+	CreateFind(find *Find) error
+	GetFindByID(id int64) (*Find, error)
+	GetUserFinds(userID int64, limit, offset int) ([]*Find, error)
+	CountUserFinds(userID int64) (int, error)
+
+	// Подозрительные события
+	CreateSuspicionEvent(event *SuspicionEvent) error
+	CountSuspicionEvents(userID, cacheID int64, kind string, since time.Time) (int, error)
+	GetSuspicionEvents(userID int64, limit int) ([]*SuspicionEvent, error)
+
+	// Пользователи и статистика
+	SaveUser(user *User) error
+	GetUser(userID int64) (*User, error)
+	SetUserLanguage(userID int64, language string) error
+	GetDisplaySettings(userID int64) (*DisplaySettings, error)
+	SaveDisplaySettings(userID int64, settings *DisplaySettings) error
+	GetLeaderboard(since time.Time, limit int) ([]*LeaderboardEntry, error)
+	GetUserStats(userID int64) (*UserStats, error)
+}
+
+var _ Store = (*Database)(nil)
+
+// OpenStore открывает хранилище: PostgreSQL, если задан DATABASE_URL, иначе файл SQLite.
+// Непримененные миграции схемы применяются сразу.
+func OpenStore() (Store, error) {
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return NewPostgresDatabase(url)
+	}
+	return NewDatabase(getDatabasePath())
+}
+
+// openDatabase открывает настроенную базу без применения миграций (для команды migrate)
+func openDatabase() (*Database, error) {
+	if url := os.Getenv("DATABASE_URL"); url != "" {
+		return openPostgres(url)
+	}
+	return openSQLite(getDatabasePath())
+}
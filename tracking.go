@@ -0,0 +1,158 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Через сколько без обновлений окно сглаживания начинается заново:
+// после паузы старые точки только тянули бы позицию назад
+const trackStaleAfter = 2 * time.Minute
+
+// LocationFix - точка трансляции геопозиции
+type LocationFix struct {
+	Lat      float64
+	Lon      float64
+	Accuracy float64 // Радиус неопределенности в метрах (horizontal_accuracy); 0 - неизвестен
+	Heading  int     // Направление движения 1-360; 0 - неизвестно
+}
+
+// trackState - недавние точки и счетчик подтверждений прибытия для поиска одного тайника
+type trackState struct {
+	cacheID  int64
+	recent   []LocationFix
+	inside   int  // Сколько точек подряд попало в радиус обнаружения
+	warned   bool // Игроку уже сообщили о неточной геопозиции
+	lastSeen time.Time
+}
+
+// LocationTracker отбрасывает неточные точки трансляции, сглаживает скачки GPS
+// и подтверждает прибытие к тайнику. Состояние хранится в памяти и после
+// перезапуска бота набирается заново.
+type LocationTracker struct {
+	MaxAccuracy   float64 // Точки с худшей точностью отбрасываются; 0 - не отбрасывать
+	Window        int     // Сколько последних точек усредняется; 1 - без сглаживания
+	Confirmations int     // Сколько точек подряд должно попасть в радиус
+
+	mu     sync.Mutex
+	tracks map[int64]*trackState
+}
+
+// NewLocationTracker создает фильтр трансляций с порогами из конфигурации
+func NewLocationTracker(config *Config) *LocationTracker {
+	return &LocationTracker{
+		MaxAccuracy:   config.MaxLocationAccuracyMeters,
+		Window:        config.LocationSmoothingWindow,
+		Confirmations: config.ArrivalConfirmations,
+		tracks:        make(map[int64]*trackState),
+	}
+}
+
+// track возвращает состояние поиска пользователя, начиная его заново при смене тайника или после паузы
+func (t *LocationTracker) track(userID, cacheID int64, now time.Time) *trackState {
+	state, ok := t.tracks[userID]
+	if !ok || state.cacheID != cacheID || now.Sub(state.lastSeen) > trackStaleAfter {
+		state = &trackState{cacheID: cacheID}
+		t.tracks[userID] = state
+	}
+	state.lastSeen = now
+	return state
+}
+
+// Add учитывает очередную точку трансляции и возвращает сглаженную позицию.
+// ok = false, если точка отброшена из-за плохой точности.
+func (t *LocationTracker) Add(userID, cacheID int64, fix LocationFix) (smoothed LocationFix, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.track(userID, cacheID, time.Now())
+	if t.MaxAccuracy > 0 && fix.Accuracy > t.MaxAccuracy {
+		return fix, false
+	}
+
+	window := t.Window
+	if window < 1 {
+		window = 1
+	}
+	state.recent = append(state.recent, fix)
+	if len(state.recent) > window {
+		state.recent = state.recent[len(state.recent)-window:]
+	}
+
+	return smoothFixes(state.recent), true
+}
+
+// smoothFixes усредняет точки с весами, обратными квадрату неточности:
+// точные точки влияют на позицию сильнее. Направление движения и точность берутся из последней точки.
+func smoothFixes(fixes []LocationFix) LocationFix {
+	last := fixes[len(fixes)-1]
+	if len(fixes) == 1 {
+		return last
+	}
+
+	var lat, lon, total float64
+	for _, fix := range fixes {
+		weight := 1.0
+		if fix.Accuracy > 1 {
+			weight = 1 / (fix.Accuracy * fix.Accuracy)
+		}
+		lat += fix.Lat * weight
+		lon += fix.Lon * weight
+		total += weight
+	}
+
+	return LocationFix{Lat: lat / total, Lon: lon / total, Accuracy: last.Accuracy, Heading: last.Heading}
+}
+
+// Arrived учитывает точку при проверке прибытия. Игрок точно на месте, если круг неточности
+// целиком внутри радиуса обнаружения; иначе нужно Confirmations точек подряд внутри радиуса.
+func (t *LocationTracker) Arrived(userID, cacheID int64, distance, radius, accuracy float64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.track(userID, cacheID, time.Now())
+	if distance > radius {
+		state.inside = 0
+		return false
+	}
+	if accuracy > 0 && distance+accuracy <= radius {
+		return true
+	}
+
+	state.inside++
+	return state.inside >= t.Confirmations
+}
+
+// WarnPoorAccuracy возвращает true один раз за поиск - чтобы сообщить игроку,
+// что его геопозиция пока слишком неточная
+func (t *LocationTracker) WarnPoorAccuracy(userID, cacheID int64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := t.track(userID, cacheID, time.Now())
+	if state.warned {
+		return false
+	}
+	state.warned = true
+	return true
+}
+
+// Reset забывает точки пользователя, например после находки
+func (t *LocationTracker) Reset(userID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.tracks, userID)
+}
+
+// Prune удаляет состояние поисков, по которым давно не было точек
+func (t *LocationTracker) Prune(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for userID, state := range t.tracks {
+		if now.Sub(state.lastSeen) > trackStaleAfter {
+			delete(t.tracks, userID)
+		}
+	}
+}
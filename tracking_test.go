@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func newTestTracker() *LocationTracker {
+	return NewLocationTracker(&Config{MaxLocationAccuracyMeters: 50, LocationSmoothingWindow: 3, ArrivalConfirmations: 2})
+}
+
+func TestLocationTrackerDiscardsInaccurateFixes(t *testing.T) {
+	tracker := newTestTracker()
+
+	if _, ok := tracker.Add(1, 10, LocationFix{Lat: 55.75, Lon: 37.61, Accuracy: 300}); ok {
+		t.Error("точка с точностью 300 м не отброшена")
+	}
+	if _, ok := tracker.Add(1, 10, LocationFix{Lat: 55.75, Lon: 37.61}); !ok {
+		t.Error("точка без сведений о точности отброшена")
+	}
+
+	if !tracker.WarnPoorAccuracy(1, 10) || tracker.WarnPoorAccuracy(1, 10) {
+		t.Error("предупреждение о точности должно выдаваться один раз за поиск")
+	}
+}
+
+func TestLocationTrackerSmoothsJitter(t *testing.T) {
+	tracker := newTestTracker()
+
+	tracker.Add(1, 10, LocationFix{Lat: 55.7500, Lon: 37.61, Accuracy: 10})
+	tracker.Add(1, 10, LocationFix{Lat: 55.7502, Lon: 37.61, Accuracy: 10})
+	// Скачок на ~300 м с плохой точностью почти не сдвигает позицию
+	smoothed, _ := tracker.Add(1, 10, LocationFix{Lat: 55.7530, Lon: 37.61, Accuracy: 40, Heading: 90})
+
+	if jump := calculateDistanceMeters(55.7501, 37.61, smoothed.Lat, smoothed.Lon); jump > 20 {
+		t.Errorf("сглаженная позиция сместилась на %d м", jump)
+	}
+	if smoothed.Heading != 90 || smoothed.Accuracy != 40 {
+		t.Errorf("направление и точность должны браться из последней точки: %+v", smoothed)
+	}
+
+	// Окно ограничено тремя точками: старые точки вытесняются
+	for i := 0; i < 3; i++ {
+		smoothed, _ = tracker.Add(1, 10, LocationFix{Lat: 55.76, Lon: 37.62, Accuracy: 10})
+	}
+	if math.Abs(smoothed.Lat-55.76) > 1e-9 || math.Abs(smoothed.Lon-37.62) > 1e-9 {
+		t.Errorf("старые точки остались в окне: %+v", smoothed)
+	}
+
+	// Новый тайник - новое окно
+	smoothed, _ = tracker.Add(1, 11, LocationFix{Lat: 55.70, Lon: 37.50})
+	if smoothed.Lat != 55.70 {
+		t.Errorf("окно не сброшено при смене тайника: %+v", smoothed)
+	}
+}
+
+func TestLocationTrackerArrival(t *testing.T) {
+	tracker := newTestTracker()
+
+	// Одной точки внутри радиуса недостаточно
+	if tracker.Arrived(1, 10, 20, 30, 25) {
+		t.Error("прибытие засчитано по одной точке")
+	}
+	// Выход из радиуса сбрасывает счетчик
+	tracker.Arrived(1, 10, 40, 30, 25)
+	if tracker.Arrived(1, 10, 20, 30, 25) {
+		t.Error("счетчик точек внутри радиуса не сброшен")
+	}
+	if !tracker.Arrived(1, 10, 15, 30, 25) {
+		t.Error("прибытие не засчитано после двух точек подряд")
+	}
+
+	// Круг неточности целиком внутри радиуса - прибытие сразу
+	if !tracker.Arrived(2, 10, 5, 30, 10) {
+		t.Error("точная точка внутри радиуса не засчитана сразу")
+	}
+}
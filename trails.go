@@ -0,0 +1,419 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Минимальное количество этапов в маршруте
+const minTrailStages = 2
+
+// stageCodeWord формирует служебное кодовое слово тайника-этапа.
+// Такие тайники нельзя начать искать напрямую - только через маршрут.
+func stageCodeWord(trailCodeWord string, position int) string {
+	return fmt.Sprintf("%s#%d", trailCodeWord, position)
+}
+
+// Обработчик команды /create_trail
+func (b *Bot) handleCreateTrailCommand(userID int64) {
+	session := &AdminSession{
+		UserID: userID,
+		Step:   "trail_code",
+	}
+
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка создания админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	b.reply(userID, "trail.ask_code_word")
+}
+
+// Обработчик ввода кодового слова маршрута
+func (b *Bot) handleTrailCodeInput(userID int64, codeWord string) {
+	codeWord = strings.TrimSpace(codeWord)
+	if len(codeWord) < 3 {
+		b.reply(userID, "create.code_word_too_short")
+		return
+	}
+	if strings.Contains(codeWord, "#") {
+		b.reply(userID, "trail.code_word_hash")
+		return
+	}
+	if b.isCodeWordTaken(codeWord) {
+		b.reply(userID, "create.code_word_taken")
+		return
+	}
+
+	trail := &Trail{
+		CodeWord:  codeWord,
+		CreatedBy: userID,
+	}
+	if err := b.DB.CreateTrail(trail); err != nil {
+		slog.Error("Ошибка создания маршрута", "user_id", userID, "error", err)
+		b.reply(userID, "trail.create_failed")
+		return
+	}
+
+	session := &AdminSession{
+		UserID:   userID,
+		Step:     "trail_location",
+		CodeWord: codeWord,
+	}
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка обновления админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	b.askTrailStageLocation(userID, 1)
+}
+
+// askTrailStageLocation запрашивает геолокацию очередного этапа
+func (b *Bot) askTrailStageLocation(userID int64, position int) {
+	lang := b.userLanguage(userID)
+	text := translate(lang, "trail.ask_location", position)
+	if position > minTrailStages {
+		text += translate(lang, "trail.done_hint")
+	}
+
+	msg := tgbotapi.NewMessage(userID, text)
+	keyboard := tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButtonLocation(translate(lang, "button.send_location")),
+		),
+	)
+	keyboard.OneTimeKeyboard = true
+	msg.ReplyMarkup = keyboard
+	b.Outbox.Send(msg)
+}
+
+// loadDraftTrail загружает маршрут, который создается в админской сессии
+func (b *Bot) loadDraftTrail(userID int64, session *AdminSession) *Trail {
+	trail, err := b.DB.GetTrailByCodeWord(session.CodeWord)
+	if err != nil {
+		slog.Error("Ошибка получения маршрута", "user_id", userID, "error", err)
+		b.DB.DeleteAdminSession(userID)
+		b.reply(userID, "trail.draft_missing")
+		return nil
+	}
+	return trail
+}
+
+// Обработчик ввода геолокации этапа маршрута
+func (b *Bot) handleTrailLocationInput(userID int64, session *AdminSession, message *tgbotapi.Message) {
+	if message.Location == nil {
+		b.reply(userID, "trail.location_required")
+		return
+	}
+
+	session.Step = "trail_media"
+	session.Latitude = float64(message.Location.Latitude)
+	session.Longitude = float64(message.Location.Longitude)
+
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка обновления админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	msg := tgbotapi.NewMessage(userID, b.text(userID, "trail.ask_media"))
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+	b.Outbox.Send(msg)
+}
+
+// Обработчик медиафайла этапа маршрута
+func (b *Bot) handleTrailMediaInput(userID int64, session *AdminSession, message *tgbotapi.Message) {
+	fileID, fileType, ok := extractMedia(message)
+	if !ok {
+		b.reply(userID, "create.media_required")
+		return
+	}
+
+	trail := b.loadDraftTrail(userID, session)
+	if trail == nil {
+		return
+	}
+
+	stages, err := b.DB.GetTrailStages(trail.ID)
+	if err != nil {
+		slog.Error("Ошибка получения этапов маршрута", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+	position := len(stages) + 1
+
+	cache := &Cache{
+		CodeWord:  stageCodeWord(trail.CodeWord, position),
+		Latitude:  session.Latitude,
+		Longitude: session.Longitude,
+		FileID:    fileID,
+		FileType:  fileType,
+		CreatedBy: userID,
+	}
+	stage := &TrailStage{
+		TrailID:  trail.ID,
+		Position: position,
+	}
+	if err := b.DB.CreateTrailStage(cache, stage); err != nil {
+		slog.Error("Ошибка добавления этапа маршрута", "user_id", userID, "trail_id", trail.ID, "error", err)
+		b.reply(userID, "trail.stage_failed")
+		return
+	}
+
+	session.Step = "trail_clue"
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка обновления админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	b.reply(userID, "trail.ask_clue", position)
+}
+
+// Обработчик ввода подсказки этапа маршрута
+func (b *Bot) handleTrailClueInput(userID int64, session *AdminSession, clue string) {
+	clue = strings.TrimSpace(clue)
+	if clue == "" {
+		b.reply(userID, "trail.clue_required")
+		return
+	}
+	if clue == "-" {
+		clue = ""
+	}
+
+	trail := b.loadDraftTrail(userID, session)
+	if trail == nil {
+		return
+	}
+
+	stages, err := b.DB.GetTrailStages(trail.ID)
+	if err != nil || len(stages) == 0 {
+		slog.Error("Ошибка получения этапов маршрута", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+	lastStage := stages[len(stages)-1]
+
+	if err := b.DB.UpdateTrailStageClue(trail.ID, lastStage.Position, clue); err != nil {
+		slog.Error("Ошибка сохранения подсказки", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	session.Step = "trail_location"
+	if err := b.DB.CreateOrUpdateAdminSession(session); err != nil {
+		slog.Error("Ошибка обновления админской сессии", "user_id", userID, "step", session.Step, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	b.reply(userID, "trail.stage_saved", lastStage.Position)
+	b.askTrailStageLocation(userID, lastStage.Position+1)
+}
+
+// Обработчик команды /done - публикация созданного маршрута
+func (b *Bot) handleTrailDoneCommand(userID int64) {
+	session, err := b.DB.GetAdminSession(userID)
+	if err != nil || !strings.HasPrefix(session.Step, "trail_") {
+		b.reply(userID, "trail.no_draft")
+		return
+	}
+	if session.Step != "trail_location" {
+		b.reply(userID, "trail.finish_stage_first")
+		return
+	}
+
+	trail := b.loadDraftTrail(userID, session)
+	if trail == nil {
+		return
+	}
+
+	stages, err := b.DB.GetTrailStages(trail.ID)
+	if err != nil {
+		slog.Error("Ошибка получения этапов маршрута", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+	if len(stages) < minTrailStages {
+		b.sendMessage(userID, translatePlural(b.userLanguage(userID), "trail.too_few_stages", minTrailStages))
+		return
+	}
+
+	if err := b.DB.PublishTrail(trail.ID); err != nil {
+		slog.Error("Ошибка публикации маршрута", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	b.DB.DeleteAdminSession(userID)
+
+	lang := b.userLanguage(userID)
+	msg := tgbotapi.NewMessage(userID, translate(lang, "trail.published", trail.CodeWord, translatePlural(lang, "count.stages", len(stages))))
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+	b.Outbox.Send(msg)
+}
+
+// cancelDraftTrail удаляет неопубликованный маршрут при отмене его создания
+func (b *Bot) cancelDraftTrail(session *AdminSession) {
+	trail, err := b.DB.GetTrailByCodeWord(session.CodeWord)
+	if err != nil || trail.IsPublished {
+		return
+	}
+	if err := b.DB.DeleteTrail(trail.ID); err != nil {
+		slog.Error("Ошибка удаления черновика маршрута", "user_id", session.UserID, "error", err)
+	}
+}
+
+// startTrail начинает или продолжает прохождение маршрута пользователем
+func (b *Bot) startTrail(userID int64, trail *Trail) {
+	stages, err := b.DB.GetTrailStages(trail.ID)
+	if err != nil || len(stages) == 0 {
+		slog.Error("Ошибка получения этапов маршрута", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	progress, err := b.DB.GetTrailProgress(userID, trail.ID)
+	if err != nil && err != sql.ErrNoRows {
+		slog.Error("Ошибка получения прогресса маршрута", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	resumed := progress != nil && !progress.CompletedAt.Valid && progress.StagePosition > 0
+	if progress == nil || progress.CompletedAt.Valid {
+		// Новое (или повторное) прохождение маршрута
+		progress = &TrailProgress{
+			UserID:    userID,
+			TrailID:   trail.ID,
+			StartedAt: time.Now(),
+		}
+	}
+
+	stage, err := b.DB.GetNextTrailStage(trail.ID, progress.StagePosition)
+	if err != nil {
+		slog.Error("Ошибка получения этапа маршрута", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	if err := b.DB.SaveTrailProgress(progress); err != nil {
+		slog.Error("Ошибка сохранения прогресса маршрута", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	b.DB.ClearPuzzleAwaiting(userID)
+
+	// Новый поиск заменяет прежний: его навигация больше не нужна
+	b.Outbox.CancelEdit(userID)
+	b.Tracker.Reset(userID)
+
+	userSession := &UserSession{
+		UserID:   userID,
+		CacheID:  stage.CacheID,
+		IsActive: true,
+	}
+	if err := b.DB.CreateOrUpdateUserSession(userSession); err != nil {
+		slog.Error("Ошибка создания пользовательской сессии", "user_id", userID, "error", err)
+		b.reply(userID, "error.generic")
+		return
+	}
+
+	lang := b.userLanguage(userID)
+	var instruction string
+	if resumed {
+		instruction = translate(lang, "trail.resumed", trail.CodeWord, stagePosition(stages, stage), len(stages))
+	} else {
+		instruction = translate(lang, "trail.started", trail.CodeWord, translatePlural(lang, "count.stages", len(stages)))
+	}
+
+	b.sendMessage(userID, instruction)
+}
+
+// stagePosition возвращает порядковый номер этапа среди существующих этапов маршрута
+func stagePosition(stages []*TrailStage, stage *TrailStage) int {
+	for i, s := range stages {
+		if s.CacheID == stage.CacheID {
+			return i + 1
+		}
+	}
+	return stage.Position
+}
+
+// Обработчик достижения этапа маршрута
+func (b *Bot) handleStageReached(userID int64, cache *Cache, stage *TrailStage) {
+	trail, err := b.DB.GetTrailByID(stage.TrailID)
+	if err != nil {
+		slog.Error("Ошибка получения маршрута", "user_id", userID, "cache_id", cache.ID, "error", err)
+		return
+	}
+
+	stages, err := b.DB.GetTrailStages(trail.ID)
+	if err != nil {
+		slog.Error("Ошибка получения этапов маршрута", "user_id", userID, "cache_id", cache.ID, "error", err)
+		return
+	}
+
+	progress, err := b.DB.GetTrailProgress(userID, trail.ID)
+	if err != nil {
+		progress = &TrailProgress{UserID: userID, TrailID: trail.ID, StartedAt: time.Now()}
+	}
+	progress.StagePosition = stage.Position
+
+	next, err := b.DB.GetNextTrailStage(trail.ID, stage.Position)
+	if err != nil && err != sql.ErrNoRows {
+		slog.Error("Ошибка получения следующего этапа", "user_id", userID, "cache_id", cache.ID, "error", err)
+		return
+	}
+
+	if next == nil {
+		progress.CompletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		b.endUserSession(userID)
+	} else {
+		// Перенацеливаем сессию на следующий этап; навигация начнется с нового сообщения
+		userSession := &UserSession{
+			UserID:   userID,
+			CacheID:  next.CacheID,
+			IsActive: true,
+		}
+		if err := b.DB.CreateOrUpdateUserSession(userSession); err != nil {
+			slog.Error("Ошибка обновления пользовательской сессии", "user_id", userID, "cache_id", cache.ID, "error", err)
+		}
+	}
+
+	if err := b.DB.SaveTrailProgress(progress); err != nil {
+		slog.Error("Ошибка сохранения прогресса маршрута", "user_id", userID, "cache_id", cache.ID, "error", err)
+	}
+
+	lang := b.userLanguage(userID)
+	msg := tgbotapi.NewMessage(userID, translate(lang, "trail.stage_reached",
+		stagePosition(stages, stage), len(stages), trail.CodeWord))
+	if next == nil {
+		msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+	}
+	b.Outbox.Send(msg)
+
+	if err := b.sendMedia(userID, cache.FileID, cache.FileType, stage.Clue); err != nil {
+		slog.Error("Ошибка отправки медиафайла этапа", "user_id", userID, "cache_id", cache.ID, "error", err)
+		b.sendMessage(userID, translate(lang, "trail.media_failed"))
+	}
+	if stage.Clue != "" && cache.FileType == "video_note" {
+		// Видео-заметки не поддерживают подписи
+		b.sendMessage(userID, "💡 "+stage.Clue)
+	}
+
+	if next == nil {
+		b.sendMessage(userID, translate(lang, "trail.completed", trail.CodeWord, formatDuration(lang, time.Since(progress.StartedAt))))
+		return
+	}
+
+	b.sendMessage(userID, translate(lang, "trail.next_stage", stagePosition(stages, next), len(stages)))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Второй этап тестового маршрута - примерно в километре к северу от первого
+const (
+	e2eSecondStageLat = 55.7658
+	e2eSecondStageLon = 37.6173
+)
+
+func TestCreateTrailStageIsAtomic(t *testing.T) {
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "trails.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	trail := &Trail{CodeWord: "маршрут", CreatedBy: 1}
+	if err := db.CreateTrail(trail); err != nil {
+		t.Fatal(err)
+	}
+
+	first := &Cache{CodeWord: stageCodeWord(trail.CodeWord, 1), Latitude: 55.75, Longitude: 37.61}
+	stage := &TrailStage{TrailID: trail.ID, Position: 1}
+	if err := db.CreateTrailStage(first, stage); err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == 0 || stage.CacheID != first.ID {
+		t.Fatalf("ID тайника этапа не заполнен: тайник %d, этап %d", first.ID, stage.CacheID)
+	}
+
+	// Этап с занятым номером не добавляется, и тайник этапа не остается без маршрута
+	orphan := &Cache{CodeWord: "маршрут#повтор", Latitude: 55.76, Longitude: 37.62}
+	if err := db.CreateTrailStage(orphan, &TrailStage{TrailID: trail.ID, Position: 1}); err == nil {
+		t.Fatal("этап с занятым номером добавлен")
+	}
+	if _, err := db.GetCacheByCodeWord(orphan.CodeWord); err != sql.ErrNoRows {
+		t.Fatalf("тайник неудавшегося этапа остался в базе (ошибка %v)", err)
+	}
+}
+
+func TestE2ETrailProgress(t *testing.T) {
+	h := newE2EHarness(t)
+
+	trail := &Trail{CodeWord: "маршрут", CreatedBy: e2eAdminID}
+	if err := h.db.CreateTrail(trail); err != nil {
+		t.Fatal(err)
+	}
+	points := [][2]float64{{e2eCacheLat, e2eCacheLon}, {e2eSecondStageLat, e2eSecondStageLon}}
+	for i, point := range points {
+		stage := &Cache{CodeWord: stageCodeWord(trail.CodeWord, i+1), Latitude: point[0], Longitude: point[1],
+			FileID: "STAGE_PHOTO", FileType: "photo", CreatedBy: e2eAdminID}
+		if err := h.db.CreateTrailStage(stage, &TrailStage{TrailID: trail.ID, Position: i + 1, Clue: "ищите у старого дуба"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.db.PublishTrail(trail.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	h.sendText(e2ePlayerID, "маршрут")
+	h.expect(t, "sendMessage", e2ePlayerID, "Маршрут найден: маршрут")
+
+	// Первый этап: подсказка приходит подписью к медиафайлу, сессия переходит ко второму этапу
+	liveID := h.sendLocation(e2ePlayerID, 55.7600, 37.6173, 3600)
+	h.expect(t, "sendMessage", e2ePlayerID, "Направление к тайнику")
+	h.moveTo(e2ePlayerID, liveID, e2eCacheLat, e2eCacheLon)
+	h.expect(t, "sendMessage", e2ePlayerID, "Этап 1 из 2 маршрута «маршрут» пройден")
+	h.expect(t, "sendPhoto", e2ePlayerID, "ищите у старого дуба")
+	h.expect(t, "sendMessage", e2ePlayerID, "Следующий этап: 2 из 2")
+
+	progress, err := h.db.GetTrailProgress(e2ePlayerID, trail.ID)
+	if err != nil || progress.StagePosition != 1 || progress.CompletedAt.Valid {
+		t.Fatalf("прогресс после первого этапа: %+v (ошибка %v)", progress, err)
+	}
+
+	// После остановки поиска кодовое слово продолжает маршрут, а не начинает его заново
+	h.sendText(e2ePlayerID, "/stop")
+	h.sendText(e2ePlayerID, "маршрут")
+	if resumed := h.expect(t, "sendMessage", e2ePlayerID, "Продолжаем маршрут: маршрут"); !strings.Contains(resumed.Text(), "Этап 2 из 2") {
+		t.Errorf("маршрут продолжен не со второго этапа:\n%s", resumed.Text())
+	}
+
+	liveID = h.sendLocation(e2ePlayerID, 55.7600, 37.6173, 3600)
+	h.expect(t, "sendMessage", e2ePlayerID, "Направление к тайнику")
+	h.moveTo(e2ePlayerID, liveID, e2eSecondStageLat, e2eSecondStageLon)
+	h.expect(t, "sendMessage", e2ePlayerID, "Этап 2 из 2 маршрута «маршрут» пройден")
+	h.expect(t, "sendMessage", e2ePlayerID, "Маршрут «маршрут» полностью пройден")
+
+	progress, err = h.db.GetTrailProgress(e2ePlayerID, trail.ID)
+	if err != nil || progress.StagePosition != 2 || !progress.CompletedAt.Valid {
+		t.Fatalf("маршрут не отмечен пройденным: %+v (ошибка %v)", progress, err)
+	}
+	if _, err := h.db.GetUserSession(e2ePlayerID); err != sql.ErrNoRows {
+		t.Fatalf("сессия поиска осталась активной (ошибка %v)", err)
+	}
+	if finds, err := h.db.CountUserFinds(e2ePlayerID); err != nil || finds != 2 {
+		t.Errorf("записано находок этапов: %d (ошибка %v), ожидалось 2", finds, err)
+	}
+}
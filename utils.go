@@ -4,20 +4,50 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/umahmood/haversine"
 )
 
-// Направления компаса
+// Направления компаса. Названия для пользователя - в каталоге сообщений по ключу "direction.<направление>".
 const (
-	DirectionNorth     = "Север"
-	DirectionNorthEast = "Северо-восток"
-	DirectionEast      = "Восток"
-	DirectionSouthEast = "Юго-восток"
-	DirectionSouth     = "Юг"
-	DirectionSouthWest = "Юго-запад"
-	DirectionWest      = "Запад"
-	DirectionNorthWest = "Северо-запад"
+	DirectionNorth     = "north"
+	DirectionNorthEast = "northeast"
+	DirectionEast      = "east"
+	DirectionSouthEast = "southeast"
+	DirectionSouth     = "south"
+	DirectionSouthWest = "southwest"
+	DirectionWest      = "west"
+	DirectionNorthWest = "northwest"
+
+	// Промежуточные румбы для компаса на 16 направлений
+	DirectionNorthNorthEast = "north-northeast"
+	DirectionEastNorthEast  = "east-northeast"
+	DirectionEastSouthEast  = "east-southeast"
+	DirectionSouthSouthEast = "south-southeast"
+	DirectionSouthSouthWest = "south-southwest"
+	DirectionWestSouthWest  = "west-southwest"
+	DirectionWestNorthWest  = "west-northwest"
+	DirectionNorthNorthWest = "north-northwest"
+)
+
+// Указания поворота относительно направления движения игрока.
+// Тексты - в каталоге сообщений по ключу "turn.<указание>".
+const (
+	TurnAhead       = "ahead"
+	TurnSlightRight = "slight_right"
+	TurnRight       = "right"
+	TurnSharpRight  = "sharp_right"
+	TurnAround      = "around"
+	TurnSharpLeft   = "sharp_left"
+	TurnLeft        = "left"
+	TurnSlightLeft  = "slight_left"
+)
+
+// Перевод расстояний в имперские единицы
+const (
+	metersPerFoot = 0.3048
+	metersPerMile = 1609.344
 )
 
 // Расчет расстояния между двумя точками в километрах
@@ -32,8 +62,8 @@ func calculateDistanceMeters(lat1, lon1, lat2, lon2 float64) int {
 	return int(km * 1000)
 }
 
-// Вычисление направления от текущей позиции к цели
-func calculateDirection(fromLat, fromLon, toLat, toLon float64) string {
+// Вычисление азимута от текущей позиции к цели в градусах (0-360, 0 - север)
+func calculateBearing(fromLat, fromLon, toLat, toLon float64) float64 {
 	// Конвертируем градусы в радианы
 	fromLatRad := fromLat * math.Pi / 180
 	fromLonRad := fromLon * math.Pi / 180
@@ -57,8 +87,12 @@ func calculateDirection(fromLat, fromLon, toLat, toLon float64) string {
 		bearingDegrees += 360
 	}
 
-	// Определяем направление по компасу
-	return degreesToDirection(bearingDegrees)
+	return bearingDegrees
+}
+
+// Вычисление направления от текущей позиции к цели
+func calculateDirection(fromLat, fromLon, toLat, toLon float64) string {
+	return degreesToDirection(calculateBearing(fromLat, fromLon, toLat, toLon))
 }
 
 // Конвертация градусов в направление компаса
@@ -79,6 +113,31 @@ func degreesToDirection(degrees float64) string {
 	return directions[index]
 }
 
+// Конвертация градусов в направление компаса на 16 румбов
+func degreesToDirection16(degrees float64) string {
+	directions := []string{
+		DirectionNorth,          // 0°
+		DirectionNorthNorthEast, // 22.5°
+		DirectionNorthEast,      // 45°
+		DirectionEastNorthEast,  // 67.5°
+		DirectionEast,           // 90°
+		DirectionEastSouthEast,  // 112.5°
+		DirectionSouthEast,      // 135°
+		DirectionSouthSouthEast, // 157.5°
+		DirectionSouth,          // 180°
+		DirectionSouthSouthWest, // 202.5°
+		DirectionSouthWest,      // 225°
+		DirectionWestSouthWest,  // 247.5°
+		DirectionWest,           // 270°
+		DirectionWestNorthWest,  // 292.5°
+		DirectionNorthWest,      // 315°
+		DirectionNorthNorthWest, // 337.5°
+	}
+
+	index := int((degrees+11.25)/22.5) % 16
+	return directions[index]
+}
+
 // Получение ASCII компаса с выделенным направлением
 func getCompass(direction string) string {
 	compasses := map[string]string{
@@ -154,6 +213,16 @@ func getDirectionArrow(direction string) string {
 		DirectionSouthWest: "↙️↙️",
 		DirectionWest:      "⬅️⬅️",
 		DirectionNorthWest: "↖️↖️",
+
+		// Промежуточные румбы - соседние стрелки по часовой стрелке
+		DirectionNorthNorthEast: "⬆️↗️",
+		DirectionEastNorthEast:  "↗️➡️",
+		DirectionEastSouthEast:  "➡️↘️",
+		DirectionSouthSouthEast: "↘️⬇️",
+		DirectionSouthSouthWest: "⬇️↙️",
+		DirectionWestSouthWest:  "↙️⬅️",
+		DirectionWestNorthWest:  "⬅️↖️",
+		DirectionNorthNorthWest: "↖️⬆️",
 	}
 
 	if arrow, exists := arrows[direction]; exists {
@@ -162,36 +231,126 @@ func getDirectionArrow(direction string) string {
 	return "📍📍"
 }
 
-// Форматирование сообщения с направлением и расстоянием
-func formatDirectionMessage(fromLat, fromLon, toLat, toLon float64) string {
+// relativeTurn возвращает указание поворота по направлению движения heading и азимуту на цель bearing (в градусах)
+func relativeTurn(heading, bearing float64) string {
+	// Угол от направления движения до цели в диапазоне [-180, 180): положительный - цель правее
+	diff := math.Mod(bearing-heading+540, 360) - 180
+	angle := math.Abs(diff)
+
+	switch {
+	case angle <= 20:
+		return TurnAhead
+	case angle >= 160:
+		return TurnAround
+	case angle <= 60:
+		if diff > 0 {
+			return TurnSlightRight
+		}
+		return TurnSlightLeft
+	case angle <= 120:
+		if diff > 0 {
+			return TurnRight
+		}
+		return TurnLeft
+	default:
+		if diff > 0 {
+			return TurnSharpRight
+		}
+		return TurnSharpLeft
+	}
+}
+
+// directionName возвращает название направления на языке lang
+func directionName(lang, direction string) string {
+	return translate(lang, "direction."+direction)
+}
+
+// Форматирование сообщения с направлением и расстоянием с учетом настроек пользователя.
+// heading - направление движения игрока из трансляции (1-360, 0 - неизвестно);
+// если оно известно, к абсолютному направлению добавляется указание поворота.
+func formatDirectionMessage(lang string, settings DisplaySettings, fromLat, fromLon, toLat, toLon float64, heading int) string {
 	distance := calculateDistanceMeters(fromLat, fromLon, toLat, toLon)
-	direction := calculateDirection(fromLat, fromLon, toLat, toLon)
+	bearing := calculateBearing(fromLat, fromLon, toLat, toLon)
+
+	direction := degreesToDirection(bearing)
+	if settings.CompassPoints == 16 {
+		direction = degreesToDirection16(bearing)
+	}
 	arrow := getDirectionArrow(direction)
-	compass := getCompass(direction)
 
-	var distanceText string
-	if distance >= 1000 {
-		distanceText = fmt.Sprintf("*%.1f км*", float64(distance)/1000)
-	} else {
-		distanceText = fmt.Sprintf("*%d м*", distance)
+	label := strings.ToUpper(directionName(lang, direction))
+	if settings.ShowBearing {
+		label += fmt.Sprintf(" %d°", int(math.Round(bearing))%360)
+	}
+
+	turn := ""
+	if heading > 0 {
+		turn = "*" + translate(lang, "turn."+relativeTurn(float64(heading), bearing)) + "*\n"
+	}
+
+	if settings.Compact {
+		return translate(lang, "nav.message_compact", arrow, label, arrow, turn, formatDistance(lang, settings.Units, float64(distance)))
+	}
+	if turn != "" {
+		turn = "   " + turn
 	}
 
+	// Компас рисуется на 8 направлений и при точности в 16 румбов
+	compass := getCompass(degreesToDirection(bearing))
+
 	// Создаем красивое форматированное сообщение с компасом
-	message := fmt.Sprintf(` ═══ НАВИГАЦИЯ ═══
-%s
+	return translate(lang, "nav.message", compass, arrow, label, arrow, turn, formatDistance(lang, settings.Units, float64(distance)))
+}
 
-   %s *%s* %s
+// Изменение расстояния до тайника меньше этого порога в режиме «теплее/холоднее» считается шумом GPS
+const hotColdThresholdMeters = 3
 
-📏 Расстояние: %s
+// formatHotColdMessage возвращает сообщение режима «теплее/холоднее»: сравнивает текущее расстояние
+// до тайника с расстоянием от предыдущей точки. previous < 0 - предыдущей точки нет (начало поиска).
+func formatHotColdMessage(lang string, previous, current float64) string {
+	switch {
+	case previous < 0:
+		return translate(lang, "nav.hotcold_start")
+	case current < previous-hotColdThresholdMeters:
+		return translate(lang, "nav.hotcold_warmer")
+	case current > previous+hotColdThresholdMeters:
+		return translate(lang, "nav.hotcold_colder")
+	default:
+		return translate(lang, "nav.hotcold_same")
+	}
+}
 
-═══════════════════`,
-		compass, arrow, strings.ToUpper(direction), arrow, distanceText)
+// formatDistance форматирует расстояние в метрах в выбранной системе единиц:
+// до километра - в метрах, дальше - в километрах; в имперской - до 1000 футов в футах, дальше в милях
+func formatDistance(lang, units string, meters float64) string {
+	if units == UnitsImperial {
+		if feet := meters / metersPerFoot; feet < 1000 {
+			return translatePlural(lang, "unit.feet", int(feet))
+		}
+		return translate(lang, "unit.miles", meters/metersPerMile)
+	}
 
-	return message
+	if meters >= 1000 {
+		return translate(lang, "unit.km", meters/1000)
+	}
+	return translatePlural(lang, "unit.meters", int(meters))
+}
+
+// Форматирование продолжительности в виде "1 ч 05 мин" или "12 мин"
+func formatDuration(lang string, d time.Duration) string {
+	if d < time.Minute {
+		return translate(lang, "unit.seconds", int(d.Seconds()))
+	}
+
+	minutes := int(d.Minutes())
+	if minutes < 60 {
+		return translate(lang, "unit.minutes", minutes)
+	}
+	return translate(lang, "unit.hours_minutes", minutes/60, minutes%60)
 }
 
-// Проверка, достиг ли пользователь цели
-func isTargetReached(fromLat, fromLon, toLat, toLon float64, targetDistance float64) bool {
-	distance := calculateDistance(fromLat, fromLon, toLat, toLon) * 1000 // в метрах
-	return distance <= targetDistance
+// Экранирование специальных символов Markdown (legacy) в пользовательском тексте
+func escapeMarkdown(text string) string {
+	replacer := strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+	return replacer.Replace(text)
 }
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDegreesToDirection16(t *testing.T) {
+	tests := []struct {
+		degrees float64
+		want    string
+	}{
+		{0, DirectionNorth},
+		{11, DirectionNorth},
+		{12, DirectionNorthNorthEast},
+		{45, DirectionNorthEast},
+		{100, DirectionEast},
+		{250, DirectionWestSouthWest},
+		{348, DirectionNorthNorthWest},
+		{359, DirectionNorth},
+	}
+
+	for _, tt := range tests {
+		if got := degreesToDirection16(tt.degrees); got != tt.want {
+			t.Errorf("degreesToDirection16(%v) = %s, ожидалось %s", tt.degrees, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDistanceUnits(t *testing.T) {
+	tests := []struct {
+		lang, units string
+		meters      float64
+		want        string
+	}{
+		{LangRu, UnitsMetric, 250, "250 м"},
+		{LangRu, UnitsMetric, 1500, "1.5 км"},
+		{LangEn, UnitsImperial, 100, "328 ft"},
+		{LangEn, UnitsImperial, 3218.688, "2.0 mi"},
+		{LangRu, UnitsImperial, 0.6096, "2 фута"},
+		{LangRu, UnitsImperial, 1609.344, "1.0 мили"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDistance(tt.lang, tt.units, tt.meters); got != tt.want {
+			t.Errorf("formatDistance(%s, %s, %v) = %q, ожидалось %q", tt.lang, tt.units, tt.meters, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDirectionMessageSettings(t *testing.T) {
+	// Цель примерно в 1.1 км к северо-северо-востоку (азимут ~22°)
+	const fromLat, fromLon, toLat, toLon = 55.75, 37.61, 55.7592, 37.6165
+
+	full := formatDirectionMessage(LangEn, defaultDisplaySettings(), fromLat, fromLon, toLat, toLon, 0)
+	if !strings.Contains(full, "NORTH") || !strings.Contains(full, "⚫") || !strings.Contains(full, "km") {
+		t.Errorf("сообщение по умолчанию без компаса, направления или километров:\n%s", full)
+	}
+
+	settings := DisplaySettings{Units: UnitsImperial, ShowBearing: true, CompassPoints: 16, Compact: true}
+	compact := formatDirectionMessage(LangEn, settings, fromLat, fromLon, toLat, toLon, 0)
+	for _, want := range []string{"NORTH-NORTHEAST", "°", "mi"} {
+		if !strings.Contains(compact, want) {
+			t.Errorf("в компактном сообщении нет %q:\n%s", want, compact)
+		}
+	}
+	if strings.Contains(compact, "⚫") {
+		t.Errorf("в компактном сообщении остался ASCII-компас:\n%s", compact)
+	}
+}
+
+func TestToggleDisplaySetting(t *testing.T) {
+	settings := defaultDisplaySettings()
+	for _, name := range []string{"units", "bearing", "points", "compact"} {
+		if !toggleDisplaySetting(&settings, name) {
+			t.Fatalf("параметр %s не переключился", name)
+		}
+	}
+
+	want := DisplaySettings{Units: UnitsImperial, ShowBearing: true, CompassPoints: 16, Compact: true}
+	if settings != want {
+		t.Errorf("после переключения %+v, ожидалось %+v", settings, want)
+	}
+	if toggleDisplaySetting(&settings, "unknown") {
+		t.Error("неизвестный параметр не должен переключаться")
+	}
+}
+
+func TestRelativeTurn(t *testing.T) {
+	tests := []struct {
+		heading, bearing float64
+		want             string
+	}{
+		{0, 10, TurnAhead},
+		{350, 5, TurnAhead},
+		{90, 130, TurnSlightRight},
+		{90, 180, TurnRight},
+		{90, 230, TurnSharpRight},
+		{90, 270, TurnAround},
+		{90, 300, TurnSharpLeft},
+		{10, 280, TurnLeft},
+		{10, 330, TurnSlightLeft},
+	}
+
+	for _, tt := range tests {
+		if got := relativeTurn(tt.heading, tt.bearing); got != tt.want {
+			t.Errorf("relativeTurn(%v, %v) = %s, ожидалось %s", tt.heading, tt.bearing, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDirectionMessageHeading(t *testing.T) {
+	// Цель к северо-северо-востоку, игрок идет на юго-юго-запад
+	const fromLat, fromLon, toLat, toLon = 55.75, 37.61, 55.7592, 37.6165
+
+	withHeading := formatDirectionMessage(LangEn, defaultDisplaySettings(), fromLat, fromLon, toLat, toLon, 200)
+	if !strings.Contains(withHeading, "Turn around") || !strings.Contains(withHeading, "⚫") {
+		t.Errorf("нет указания поворота рядом с компасом:\n%s", withHeading)
+	}
+
+	withoutHeading := formatDirectionMessage(LangEn, defaultDisplaySettings(), fromLat, fromLon, toLat, toLon, 0)
+	if strings.Contains(withoutHeading, "Turn") {
+		t.Errorf("указание поворота без направления движения:\n%s", withoutHeading)
+	}
+}
+
+func TestFormatHotColdMessage(t *testing.T) {
+	tests := []struct {
+		previous, current float64
+		want              string
+	}{
+		{-1, 500, "nav.hotcold_start"},
+		{500, 450, "nav.hotcold_warmer"},
+		{450, 520, "nav.hotcold_colder"},
+		{450, 452, "nav.hotcold_same"},
+	}
+
+	for _, tt := range tests {
+		if got, want := formatHotColdMessage(LangRu, tt.previous, tt.current), translate(LangRu, tt.want); got != want {
+			t.Errorf("formatHotColdMessage(%v, %v) = %q, ожидалось %q", tt.previous, tt.current, got, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Заголовок, в котором Telegram передает secret_token, указанный при установке вебхука
+const webhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// Максимальный размер тела запроса с обновлением
+const maxWebhookBodySize = 1 << 20
+
+// WebhookConfig - настройки приема обновлений через вебхук
+type WebhookConfig struct {
+	URL         string // Публичный адрес, например https://bot.example.com (без пути)
+	Listen      string // Адрес, на котором слушает встроенный сервер
+	Path        string // Секретный путь вебхука
+	SecretToken string // Значение заголовка X-Telegram-Bot-Api-Secret-Token
+	CertFile    string // Сертификат и ключ для встроенного HTTPS (пусто - обычный HTTP за reverse proxy)
+	KeyFile     string
+	SelfSigned  bool // Загрузить сертификат в Telegram при установке вебхука
+}
+
+// loadWebhookConfig читает настройки вебхука из переменных окружения
+func loadWebhookConfig() WebhookConfig {
+	config := WebhookConfig{
+		URL:         strings.TrimRight(getEnvString("WEBHOOK_URL", ""), "/"),
+		Listen:      getEnvString("WEBHOOK_LISTEN", ":8443"),
+		Path:        getEnvString("WEBHOOK_PATH", "/webhook"),
+		SecretToken: getEnvString("WEBHOOK_SECRET_TOKEN", ""),
+		CertFile:    getEnvString("WEBHOOK_CERT_FILE", ""),
+		KeyFile:     getEnvString("WEBHOOK_KEY_FILE", ""),
+		SelfSigned:  getEnvString("WEBHOOK_SELF_SIGNED", "") == "true",
+	}
+	if !strings.HasPrefix(config.Path, "/") {
+		config.Path = "/" + config.Path
+	}
+	return config
+}
+
+// Enabled сообщает, что бот должен работать через вебхук, а не через long polling
+func (c WebhookConfig) Enabled() bool {
+	return c.URL != ""
+}
+
+// webhookHandler принимает обновления от Telegram и передает их в общий конвейер обработки
+type webhookHandler struct {
+	secretToken string
+	dispatch    func(tgbotapi.Update)
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.secretToken != "" {
+		token := r.Header.Get(webhookSecretHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.secretToken)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize+1))
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxWebhookBodySize {
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var update tgbotapi.Update
+	if err := json.Unmarshal(body, &update); err != nil {
+		slog.Error("Некорректное обновление от вебхука", "error", err)
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	h.dispatch(update)
+	w.WriteHeader(http.StatusOK)
+}
+
+// setWebhook регистрирует вебхук в Telegram вместе с secret_token
+func setWebhook(api *tgbotapi.BotAPI, config WebhookConfig) error {
+	params := make(tgbotapi.Params)
+	params["url"] = config.URL + config.Path
+	params.AddNonEmpty("secret_token", config.SecretToken)
+
+	var (
+		resp *tgbotapi.APIResponse
+		err  error
+	)
+	if config.SelfSigned && config.CertFile != "" {
+		files := []tgbotapi.RequestFile{{Name: "certificate", Data: tgbotapi.FilePath(config.CertFile)}}
+		resp, err = api.UploadFiles("setWebhook", params, files)
+	} else {
+		resp, err = api.MakeRequest("setWebhook", params)
+	}
+	if err != nil {
+		return err
+	}
+	if !resp.Ok {
+		return errors.New(resp.Description)
+	}
+	return nil
+}
+
+// runWebhook устанавливает вебхук и принимает обновления, пока не будет отменен ctx или сервер не остановится с ошибкой
+func (b *Bot) runWebhook(ctx context.Context, config WebhookConfig, dispatcher *Dispatcher) error {
+	if config.SecretToken == "" {
+		slog.Warn("WEBHOOK_SECRET_TOKEN не задан, подлинность запросов к вебхуку не проверяется")
+	}
+
+	if err := setWebhook(b.API, config); err != nil {
+		return fmt.Errorf("ошибка установки вебхука: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(config.Path, &webhookHandler{
+		secretToken: config.SecretToken,
+		dispatch:    dispatcher.Dispatch,
+	})
+
+	server := &http.Server{
+		Addr:              config.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	// Запросы, которые уже принимаются, успевают передать обновления диспетчеру
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	var err error
+	if config.CertFile != "" && config.KeyFile != "" {
+		slog.Info("Вебхук слушает HTTPS", "addr", config.Listen)
+		err = server.ListenAndServeTLS(config.CertFile, config.KeyFile)
+	} else {
+		slog.Info("Вебхук слушает HTTP, TLS завершается на reverse proxy", "addr", config.Listen)
+		err = server.ListenAndServe()
+	}
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const fakeLocationUpdate = `{
+	"update_id": 1001,
+	"edited_message": {
+		"message_id": 42,
+		"date": 1700000000,
+		"from": {"id": 555, "is_bot": false, "first_name": "Тест"},
+		"chat": {"id": 555, "type": "private"},
+		"location": {"latitude": 55.751244, "longitude": 37.618423, "live_period": 3600}
+	}
+}`
+
+func TestWebhookHandlerDispatchesUpdates(t *testing.T) {
+	var received []tgbotapi.Update
+	server := httptest.NewServer(&webhookHandler{
+		secretToken: "s3cret",
+		dispatch:    func(update tgbotapi.Update) { received = append(received, update) },
+	})
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(fakeLocationUpdate))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookSecretHeader, "s3cret")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("статус %d, ожидался 200", resp.StatusCode)
+	}
+	if len(received) != 1 {
+		t.Fatalf("передано обновлений: %d, ожидалось 1", len(received))
+	}
+
+	update := received[0]
+	if update.UpdateID != 1001 || update.EditedMessage == nil || update.EditedMessage.Location == nil {
+		t.Fatalf("обновление разобрано неверно: %+v", update)
+	}
+	if update.SentFrom().ID != 555 || update.EditedMessage.Location.LivePeriod != 3600 {
+		t.Fatalf("неверные данные обновления: %+v", update.EditedMessage)
+	}
+}
+
+func TestWebhookHandlerRejectsBadRequests(t *testing.T) {
+	dispatched := 0
+	handler := &webhookHandler{
+		secretToken: "s3cret",
+		dispatch:    func(tgbotapi.Update) { dispatched++ },
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		secret string
+		body   string
+		status int
+	}{
+		{"без секрета", http.MethodPost, "", fakeLocationUpdate, http.StatusForbidden},
+		{"неверный секрет", http.MethodPost, "wrong", fakeLocationUpdate, http.StatusForbidden},
+		{"GET", http.MethodGet, "s3cret", "", http.StatusMethodNotAllowed},
+		{"битый JSON", http.MethodPost, "s3cret", "{", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/webhook", strings.NewReader(tt.body))
+			if tt.secret != "" {
+				req.Header.Set(webhookSecretHeader, tt.secret)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("статус %d, ожидался %d", rec.Code, tt.status)
+			}
+		})
+	}
+
+	if dispatched != 0 {
+		t.Fatalf("отклоненные запросы не должны попадать в обработку, передано: %d", dispatched)
+	}
+}
+
+func TestWebhookHandlerFeedsDispatcher(t *testing.T) {
+	done := make(chan tgbotapi.Update, 1)
+	dispatcher := NewDispatcher(2, 4, func(update tgbotapi.Update) { done <- update })
+	defer dispatcher.Stop()
+
+	handler := &webhookHandler{dispatch: dispatcher.Dispatch}
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(fakeLocationUpdate))
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("статус %d, ожидался 200", rec.Code)
+	}
+	if update := <-done; update.UpdateID != 1001 {
+		t.Fatalf("получено обновление %d, ожидалось 1001", update.UpdateID)
+	}
+}